@@ -0,0 +1,28 @@
+// Command mock-oidc runs a mock OpenID Connect provider for trying OIDC login
+// locally. It signs in without a password as the address given in the
+// login_hint parameter, or as mock.user@example.com.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"wallet-service/pkg/oidc/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9000", "address to listen on")
+	clientID := flag.String("client-id", "wallet-service", "client ID of the wallet service")
+	clientSecret := flag.String("client-secret", "wallet-service-secret", "client secret of the wallet service")
+	flag.Parse()
+
+	provider, err := oidctest.NewProvider("http://"+*addr, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatalf("Failed to create mock provider: %v", err)
+	}
+
+	log.Printf("🔑 Mock OIDC provider at http://%s/.well-known/openid-configuration", *addr)
+	if err := http.ListenAndServe(*addr, provider); err != nil {
+		log.Fatalf("Failed to start mock provider: %v", err)
+	}
+}
@@ -1,13 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 	"wallet-service/internal/api/handlers"
+	"wallet-service/internal/api/middleware"
 	"wallet-service/internal/api/routes"
 	"wallet-service/internal/config"
 	"wallet-service/internal/db"
 	"wallet-service/internal/repository"
 	"wallet-service/internal/service"
+	"wallet-service/pkg/jwtkeys"
+	"wallet-service/pkg/mailer"
+	"wallet-service/pkg/oidc"
 
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
@@ -17,6 +29,11 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	// Load configuration
 	if err := config.LoadConfig(); err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
@@ -26,45 +43,235 @@ func main() {
 	if err := viper.Unmarshal(&cfg); err != nil {
 		log.Fatalf("Failed to unmarshal configuration: %v", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
-	// Initialize database
+	// Initialize database. Schema changes are applied separately with "migrate up".
 	db.InitDB()
+	migrator, err := db.NewMigrator(db.DB)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if pending, err := migrator.Pending(context.Background()); err != nil {
+		log.Printf("⚠️ Could not check migration status: %v", err)
+	} else if pending > 0 {
+		log.Printf("⚠️ %d pending migration(s); run \"wallet-service migrate up\"", pending)
+	}
 
 	// Set up Gin mode
-	gin.SetMode(gin.DebugMode)
+	switch cfg.Server.Mode {
+	case gin.ReleaseMode, gin.DebugMode, gin.TestMode:
+		gin.SetMode(cfg.Server.Mode)
+	default:
+		log.Fatalf("Unsupported Gin mode %q", cfg.Server.Mode)
+	}
 	router := gin.Default()
+	// Client IPs key login throttling, so forwarding headers are only believed
+	// from the configured proxies.
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid server.trusted_proxies: %v", err)
+	}
+	router.Use(middleware.DBTimeout(cfg.Database.RequestTimeout))
 
 	// Initialize components
-	userRepo := repository.NewUserRepository()
-	walletRepo := repository.NewWalletRepository()
-	transactionRepo := repository.NewTransactionRepository()
+	userRepo := repository.NewUserRepository(db.DB)
+	walletRepo := repository.NewWalletRepository(db.DB)
+	transactionRepo := repository.NewTransactionRepository(db.DB)
+	ledgerRepo := repository.NewLedgerRepository(db.DB)
+	idempotencyRepo := repository.NewIdempotencyRepository(db.DB)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db.DB)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db.DB)
+	userTokenRepo := repository.NewUserTokenRepository(db.DB)
+	securityEventRepo := repository.NewSecurityEventRepository(db.DB)
+	roleRepo := repository.NewRoleRepository(db.DB)
+	apiKeyRepo := repository.NewAPIKeyRepository(db.DB)
+	oauthClientRepo := repository.NewOAuthClientRepository(db.DB)
+	oauthCodeRepo := repository.NewOAuthAuthorizationCodeRepository(db.DB)
+	oauthTokenRepo := repository.NewOAuthAccessTokenRepository(db.DB)
+	userIdentityRepo := repository.NewUserIdentityRepository(db.DB)
+	oidcStateRepo := repository.NewOIDCLoginStateRepository(db.DB)
+	txManager := repository.NewTxManager(db.DB)
+	loginAttempts, err := newLoginAttemptStore(cfg.Login)
+	if err != nil {
+		log.Fatalf("Failed to configure login protection: %v", err)
+	}
 
-	walletService := service.NewWalletService(walletRepo)
+	ledgerService := service.NewLedgerService(ledgerRepo)
+	walletService := service.NewWalletService(txManager, walletRepo)
 	transactionService := service.NewTransactionService(transactionRepo)
 
-	authService := service.NewAuthService(*userRepo, walletService, &cfg)
+	signingKeys, err := jwtkeys.NewManager(context.Background(), cfg.JWT.KeysDir, cfg.JWT.Algorithm, cfg.JWT.KeyRotationInterval, cfg.TokenRetention(), repository.NewSigningKeyRepository(db.DB))
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	mail, err := newMailer(cfg.Mail)
+	if err != nil {
+		log.Fatalf("Failed to configure mail: %v", err)
+	}
+
+	tokenVersionService := service.NewTokenVersionService(userRepo, cfg.JWT.TokenVersionCacheTTL)
+	roleService := service.NewRoleService(roleRepo, tokenVersionService)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo, roleService, &cfg)
+	oauthService := service.NewOAuthService(oauthClientRepo, oauthCodeRepo, oauthTokenRepo, userRepo, roleService, tokenVersionService, signingKeys, &cfg)
+	loginProtectionService := service.NewLoginProtectionService(loginAttempts, securityEventRepo, &cfg)
+	twoFactorService := service.NewTwoFactorService(userRepo, recoveryCodeRepo, loginProtectionService, tokenVersionService, &cfg)
+	accountService := service.NewAccountService(txManager, userRepo, userTokenRepo, tokenVersionService, mail, &cfg)
+	authService := service.NewAuthService(userRepo, refreshTokenRepo, userTokenRepo, txManager, walletService, twoFactorService, accountService, loginProtectionService, roleService, tokenVersionService, apiKeyService, oauthService, signingKeys, &cfg)
+	oidcService := service.NewOIDCService(newOIDCProvider(cfg.OIDC), oidcStateRepo, userIdentityRepo, userRepo, authService, &cfg)
 	userService := service.NewUserService(userRepo)
-	adminService := service.NewAdminService(userRepo)
+	adminService := service.NewAdminService(txManager, userRepo, ledgerService, loginProtectionService, roleService, tokenVersionService)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, &cfg)
+	healthService := service.NewHealthService(db.DB, migrator, &cfg)
 
 	authHandler := handlers.NewAuthHandler(authService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService, authService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService)
 	userHandler := handlers.NewUserHandler(userService)
 	adminHandler := handlers.NewAdminHandler(adminService)
-	walletHandler := handlers.NewWalletHandler(walletService, transactionService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	oauthHandler := handlers.NewOAuthHandler(oauthService)
+	walletHandler := handlers.NewWalletHandler(walletService)
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	healthHandler := handlers.NewHealthHandler(healthService)
 
 	// Setup routes
-	routes.SetupAuthRoutes(router, authHandler)
+	routes.SetupHealthRoutes(router, healthHandler)
+	routes.SetupAuthRoutes(router, authHandler, twoFactorHandler, accountHandler, oidcHandler, authService)
 	routes.SetupUserRoutes(router, userHandler, authService)
-	routes.SetupAdminRoutes(router, adminHandler, authService, userRepo)
-	routes.SetupWalletRoutes(router, walletHandler, authService)
-	routes.SetupTransactionRoutes(router, transactionHandler, authService)
+	routes.SetupAdminRoutes(router, adminHandler, roleHandler, oauthHandler, healthHandler, walletHandler, authService)
+	routes.SetupAPIKeyRoutes(router, apiKeyHandler, authService)
+	routes.SetupOAuthRoutes(router, oauthHandler, authService)
+	routes.SetupWalletRoutes(router, walletHandler, authService, idempotencyService, walletRepo, userRepo, &cfg)
+	routes.SetupTransactionRoutes(router, transactionHandler, authService, walletRepo)
+
+	// Stop on SIGINT or SIGTERM; a second signal kills the process at once.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Periodically purge expired idempotency keys and tokens, and rotate signing keys
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if _, err := idempotencyService.PurgeExpired(ctx); err != nil {
+				log.Printf("Failed to purge expired idempotency keys: %v", err)
+			}
+			if _, err := authService.PurgeExpiredRefreshTokens(ctx); err != nil {
+				log.Printf("Failed to purge expired refresh tokens: %v", err)
+			}
+			if _, err := accountService.PurgeExpiredTokens(ctx); err != nil {
+				log.Printf("Failed to purge expired account tokens: %v", err)
+			}
+			if _, err := loginProtectionService.PurgeStale(ctx); err != nil {
+				log.Printf("Failed to purge stale login attempts: %v", err)
+			}
+			if _, err := oauthService.PurgeExpired(ctx); err != nil {
+				log.Printf("Failed to purge expired OAuth codes and tokens: %v", err)
+			}
+			if _, err := oidcService.PurgeExpiredStates(ctx); err != nil {
+				log.Printf("Failed to purge expired external login states: %v", err)
+			}
+			tokenVersionService.Prune()
+			if err := authService.RotateSigningKeys(ctx); err != nil {
+				log.Printf("Failed to rotate JWT signing keys: %v", err)
+			}
+		}
+	}()
 
 	// Swagger UI route
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggoFiles.Handler))
 
 	// Start server
-	log.Println("🚀 Starting server on :8080")
-	if err := router.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	server := newHTTPServer(cfg.Port, cfg.Server, router)
+	go func() {
+		log.Printf("🚀 Starting server on %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+
+	// Report not ready and keep serving for a while, so that load balancers
+	// notice and stop routing new requests here before connections are refused.
+	healthService.BeginShutdown()
+	if cfg.Server.ShutdownDelay > 0 {
+		log.Printf("Shutting down; draining for %s", cfg.Server.ShutdownDelay)
+		time.Sleep(cfg.Server.ShutdownDelay)
+	}
+	log.Printf("Shutting down; waiting up to %s for in-flight requests", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("In-flight requests did not finish in time: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		log.Printf("Failed to close database connections: %v", err)
+	}
+	log.Println("Server stopped")
+}
+
+// newHTTPServer builds the HTTP server for handler from the server configuration.
+func newHTTPServer(port string, cfg config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:           ":" + port,
+		Handler:        handler,
+		ReadTimeout:    cfg.ReadTimeout,
+		WriteTimeout:   cfg.WriteTimeout,
+		IdleTimeout:    cfg.IdleTimeout,
+		MaxHeaderBytes: cfg.MaxHeaderBytes,
+	}
+}
+
+// newMailer builds the Mailer selected by the mail configuration.
+func newMailer(cfg config.MailConfig) (mailer.Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return &mailer.SMTPMailer{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+		}, nil
+	case "file":
+		return &mailer.FileMailer{Dir: cfg.Dir, From: cfg.From}, nil
+	}
+	return nil, fmt.Errorf("unsupported mail driver %q", cfg.Driver)
+}
+
+// newLoginAttemptStore builds the failed login counter store selected by the login configuration.
+func newLoginAttemptStore(cfg config.LoginConfig) (repository.LoginAttemptStore, error) {
+	switch cfg.Store {
+	case "database":
+		return repository.NewLoginAttemptRepository(db.DB), nil
+	case "memory":
+		return repository.NewMemoryLoginAttemptStore(), nil
+	}
+	return nil, fmt.Errorf("unsupported login attempt store %q", cfg.Store)
+}
+
+// newOIDCProvider builds the external OpenID Connect provider, or returns nil when
+// none is configured.
+func newOIDCProvider(cfg config.OIDCConfig) *oidc.Provider {
+	if cfg.DiscoveryURL == "" {
+		return nil
 	}
+	return oidc.NewProvider(oidc.Config{
+		DiscoveryURL: cfg.DiscoveryURL,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       cfg.Scopes,
+	})
 }
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"wallet-service/internal/models"
 	"wallet-service/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	_ "wallet-service/docs"
 )
 
@@ -22,7 +24,7 @@ func NewAdminHandler(adminService *service.AdminService) *AdminHandler {
 
 // GetAllUsers handles the request to get all users.
 // @Summary Get all users (Admin)
-// @Description Retrieve all users in the system (Admin only)
+// @Description Retrieve all users in the system (requires users:read)
 // @Tags Admin
 // @Security ApiKeyAuth
 // @Produce json
@@ -30,7 +32,7 @@ func NewAdminHandler(adminService *service.AdminService) *AdminHandler {
 // @Failure 500 {object} map[string]string
 // @Router /api/admin/users [get]
 func (h *AdminHandler) GetAllUsers(c *gin.Context) {
-	users, err := h.adminService.GetAllUsers()
+	users, err := h.adminService.GetAllUsers(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
 		return
@@ -40,7 +42,7 @@ func (h *AdminHandler) GetAllUsers(c *gin.Context) {
 
 // UpdateUserRole handles the request to update a user's role.
 // @Summary Update user role (Admin)
-// @Description Update a user's role (Admin only)
+// @Description Assign a role to a user (requires users:write and roles:write)
 // @Tags Admin
 // @Security ApiKeyAuth
 // @Accept json
@@ -49,6 +51,7 @@ func (h *AdminHandler) GetAllUsers(c *gin.Context) {
 // @Param request body models.UpdateUserRoleRequest true "Role update request"
 // @Success 200 {object} models.User
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/admin/users/{id}/role [put]
 func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
@@ -64,8 +67,12 @@ func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	user, err := h.adminService.UpdateUserRole(uint(id), req.Role)
+	user, err := h.adminService.UpdateUserRole(c.Request.Context(), uint(id), req.Role)
 	if err != nil {
+		if errors.Is(err, service.ErrRoleNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -75,7 +82,7 @@ func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
 
 // DeleteUser handles the request to delete a specific user by ID.
 // @Summary Delete user by ID (Admin)
-// @Description Delete a specific user by their ID (Admin only)
+// @Description Delete a specific user by their ID (requires users:write)
 // @Tags Admin
 // @Security ApiKeyAuth
 // @Produce json
@@ -91,7 +98,7 @@ func (h *AdminHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.adminService.DeleteUser(uint(id)); err != nil {
+	if err := h.adminService.DeleteUser(c.Request.Context(), uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		return
 	}
@@ -100,7 +107,7 @@ func (h *AdminHandler) DeleteUser(c *gin.Context) {
 
 // DeleteAllUsers handles the request to delete all users.
 // @Summary Delete all users (Admin)
-// @Description Delete all users in the system (Admin only)
+// @Description Delete all users in the system (requires users:write)
 // @Tags Admin
 // @Security ApiKeyAuth
 // @Produce json
@@ -108,9 +115,92 @@ func (h *AdminHandler) DeleteUser(c *gin.Context) {
 // @Failure 500 {object} map[string]string
 // @Router /api/admin/users [delete]
 func (h *AdminHandler) DeleteAllUsers(c *gin.Context) {
-	if err := h.adminService.DeleteAllUsers(); err != nil {
+	if err := h.adminService.DeleteAllUsers(c.Request.Context()); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete users"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "All users deleted successfully"})
 }
+
+// ReconcileLedger handles the request to reconcile wallet balances against the ledger.
+// @Summary Reconcile ledger (Admin)
+// @Description List wallets whose cached balance differs from the balance derived from ledger postings (requires ledger:read)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.LedgerDiscrepancy
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/ledger/reconcile [get]
+func (h *AdminHandler) ReconcileLedger(c *gin.Context) {
+	discrepancies, err := h.adminService.ReconcileLedger(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile ledger"})
+		return
+	}
+	c.JSON(http.StatusOK, discrepancies)
+}
+
+// UnlockUser handles the request to lift a failed-login lockout.
+// @Summary Unlock user account (Admin)
+// @Description Clear a user's failed login attempts and lift any lockout of their account (requires users:write)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/users/{id}/unlock [post]
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	if err := h.adminService.UnlockUser(c.Request.Context(), uint(id), uint(adminID.(float64))); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked successfully"})
+}
+
+// GetSecurityEvents handles the request to list security events.
+// @Summary List security events (Admin)
+// @Description List recent security events such as account lockouts, newest first (requires security_events:read)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param user_id query int false "Only events of this user"
+// @Param limit query int false "Maximum number of events (default 50, max 500)"
+// @Success 200 {array} models.SecurityEvent
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/security-events [get]
+func (h *AdminHandler) GetSecurityEvents(c *gin.Context) {
+	var query models.SecurityEventQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = 50
+	}
+
+	events, err := h.adminService.SecurityEvents(c.Request.Context(), query.UserID, query.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve security events"})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
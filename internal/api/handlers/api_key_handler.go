@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	_ "wallet-service/docs"
+)
+
+// APIKeyHandler handles API key management HTTP requests.
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler.
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// CreateAPIKey handles requests to create an API key.
+// @Summary Create API key
+// @Description Create an API key that authenticates as the current user through the X-API-Key header.
+// @Description Its permissions must be granted by the user's role. The key is shown only in this response.
+// @Description Users with two-factor enabled need a recent step-up token, and users without a password, such as those signing in through an identity provider, a recent login. Other users must send their current password.
+// @Tags API Keys
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.CreateAPIKeyRequest true "Create API key request"
+// @Success 201 {object} models.APIKeyCreated
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var authAt, mfaAt time.Time
+	value, _ := c.Get("token_claims")
+	if claims, ok := value.(jwt.MapClaims); ok {
+		if at, ok := claims["auth_time"].(float64); ok {
+			authAt = time.Unix(int64(at), 0)
+		}
+		if at, ok := claims["mfa_at"].(float64); ok {
+			mfaAt = time.Unix(int64(at), 0)
+		}
+	}
+
+	created, err := h.apiKeyService.Create(c.Request.Context(), uint(userID.(float64)), authAt, mfaAt, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyPermissionNotHeld) || errors.Is(err, service.ErrInvalidAPIKeyExpiry) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, service.ErrAPIKeyReauthenticationRequired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// ListAPIKeys handles requests to list the current user's API keys.
+// @Summary List API keys
+// @Description List the current user's API keys, including revoked and expired ones. Secrets are never returned.
+// @Tags API Keys
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.APIKey
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	keys, err := h.apiKeyService.List(c.Request.Context(), uint(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey handles requests to revoke one of the current user's API keys.
+// @Summary Revoke API key
+// @Description Revoke one of the current user's API keys; it stops working immediately
+// @Tags API Keys
+// @Security ApiKeyAuth
+// @Produce json
+// @Param keyID path int true "API key ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/api-keys/{keyID} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	keyID, err := strconv.ParseUint(c.Param("keyID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(c.Request.Context(), uint(userID.(float64)), uint(keyID)); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"wallet-service/internal/models"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	_ "wallet-service/docs"
+)
+
+// HealthHandler handles the liveness, readiness and admin health endpoints.
+type HealthHandler struct {
+	healthService *service.HealthService
+}
+
+// NewHealthHandler creates a new HealthHandler.
+func NewHealthHandler(healthService *service.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+// Healthz reports that the process is running.
+// @Summary Liveness probe
+// @Description Returns 200 while the process is running and able to serve HTTP. It checks no dependencies,
+// @Description so a database outage does not cause the orchestrator to restart the service.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": models.HealthStatusOK})
+}
+
+// Readyz reports whether the service can handle requests.
+// @Summary Readiness probe
+// @Description Returns 200 when the database answers, every migration has been applied and the configuration
+// @Description is valid, and 503 otherwise. It also returns 503 once the server has begun shutting down.
+// @Description Check errors are not included; see /api/admin/health.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} models.HealthReport
+// @Failure 503 {object} models.HealthReport
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	report := h.healthService.Ready(c.Request.Context())
+	c.JSON(healthStatusCode(report), report)
+}
+
+// AdminHealth returns a detailed health report.
+// @Summary Detailed health report
+// @Description Runs the readiness checks and returns each one's latency and error, with the uptime and
+// @Description database connection pool statistics. Requires the health:read permission.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} models.HealthReport
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 503 {object} models.HealthReport
+// @Router /api/admin/health [get]
+func (h *HealthHandler) AdminHealth(c *gin.Context) {
+	report := h.healthService.Report(c.Request.Context())
+	c.JSON(healthStatusCode(report), report)
+}
+
+// healthStatusCode maps a health report to its HTTP status.
+func healthStatusCode(report *models.HealthReport) int {
+	if report.Status != models.HealthStatusOK {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"wallet-service/internal/models"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	_ "wallet-service/docs"
+)
+
+// OAuthHandler handles the OAuth2 authorization server's HTTP requests and the
+// admin API for registering clients.
+type OAuthHandler struct {
+	oauthService *service.OAuthService
+}
+
+// NewOAuthHandler creates a new OAuthHandler.
+func NewOAuthHandler(oauthService *service.OAuthService) *OAuthHandler {
+	return &OAuthHandler{oauthService: oauthService}
+}
+
+// Authorize handles a signed-in user's approval of a client's authorization request.
+// @Summary Approve OAuth authorization request
+// @Description Called by the front end once the signed-in user approves a client. Issues a single-use
+// @Description authorization code bound to the PKCE code challenge (S256) and returns the client's
+// @Description redirect URI carrying the code and state, to which the user's browser should be sent.
+// @Tags OAuth
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.OAuthAuthorizeRequest true "Authorization request"
+// @Success 200 {object} models.OAuthAuthorizeResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/oauth/authorize [post]
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var req models.OAuthAuthorizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	resp, err := h.oauthService.Authorize(c.Request.Context(), uint(userID.(float64)), &req)
+	if err != nil {
+		respondOAuthError(c, err, "Failed to authorize client")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Token handles OAuth2 token requests.
+// @Summary OAuth token endpoint
+// @Description Issue an access token with the client_credentials grant, acting as the client's service account,
+// @Description or with the authorization_code grant, exchanging a code and its PKCE code_verifier. Clients
+// @Description authenticate with HTTP Basic auth or client_id and client_secret; public clients send client_id only.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "client_credentials or authorization_code"
+// @Param scope formData string false "Space-separated scopes; defaults to every scope the client is registered for"
+// @Param code formData string false "Authorization code"
+// @Param redirect_uri formData string false "Redirect URI the code was issued for"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param client_id formData string false "Client ID, if not using HTTP Basic auth"
+// @Param client_secret formData string false "Client secret, if not using HTTP Basic auth"
+// @Success 200 {object} models.OAuthTokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /oauth/token [post]
+func (h *OAuthHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req models.OAuthTokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	clientID, clientSecret := clientCredentials(c, req.ClientID, req.ClientSecret)
+	resp, err := h.oauthService.Token(c.Request.Context(), &req, clientID, clientSecret)
+	if err != nil {
+		respondOAuthError(c, err, "Failed to issue token")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Introspect handles OAuth2 token introspection requests.
+// @Summary OAuth token introspection
+// @Description Report whether an access token issued to the calling client is active (RFC 7662).
+// @Description Only confidential clients may introspect tokens.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Access token"
+// @Param client_id formData string false "Client ID, if not using HTTP Basic auth"
+// @Param client_secret formData string false "Client secret, if not using HTTP Basic auth"
+// @Success 200 {object} models.OAuthIntrospection
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /oauth/introspect [post]
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	var req models.OAuthTokenActionRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	clientID, clientSecret := clientCredentials(c, req.ClientID, req.ClientSecret)
+	resp, err := h.oauthService.Introspect(c.Request.Context(), req.Token, clientID, clientSecret)
+	if err != nil {
+		respondOAuthError(c, err, "Failed to introspect token")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Revoke handles OAuth2 token revocation requests.
+// @Summary OAuth token revocation
+// @Description Revoke an access token issued to the calling client (RFC 7009). Unknown tokens are not an error.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Access token"
+// @Param client_id formData string false "Client ID, if not using HTTP Basic auth"
+// @Param client_secret formData string false "Client secret, if not using HTTP Basic auth"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /oauth/revoke [post]
+func (h *OAuthHandler) Revoke(c *gin.Context) {
+	var req models.OAuthTokenActionRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+
+	clientID, clientSecret := clientCredentials(c, req.ClientID, req.ClientSecret)
+	if err := h.oauthService.Revoke(c.Request.Context(), req.Token, clientID, clientSecret); err != nil {
+		respondOAuthError(c, err, "Failed to revoke token")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked successfully"})
+}
+
+// CreateClient handles requests to register an OAuth client.
+// @Summary Register OAuth client
+// @Description Register an OAuth client. Scopes are wallet:read, wallet:write or permission names.
+// @Description client_credentials clients must be confidential and name the service account they act as.
+// @Description The client secret is shown only in this response.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.CreateOAuthClientRequest true "Create OAuth client request"
+// @Success 201 {object} models.OAuthClientCreated
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/oauth/clients [post]
+func (h *OAuthHandler) CreateClient(c *gin.Context) {
+	var req models.CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.oauthService.CreateClient(c.Request.Context(), &req)
+	if err != nil {
+		respondOAuthClientError(c, err, "Failed to register OAuth client")
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// ListClients handles requests to list OAuth clients.
+// @Summary List OAuth clients
+// @Description List every registered OAuth client, including revoked ones. Secrets are never returned.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.OAuthClient
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/oauth/clients [get]
+func (h *OAuthHandler) ListClients(c *gin.Context) {
+	clients, err := h.oauthService.ListClients(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve OAuth clients"})
+		return
+	}
+	c.JSON(http.StatusOK, clients)
+}
+
+// RevokeClient handles requests to revoke an OAuth client.
+// @Summary Revoke OAuth client
+// @Description Revoke an OAuth client and every access token issued to it
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param clientID path string true "Client ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/oauth/clients/{clientID} [delete]
+func (h *OAuthHandler) RevokeClient(c *gin.Context) {
+	if err := h.oauthService.RevokeClient(c.Request.Context(), c.Param("clientID")); err != nil {
+		respondOAuthClientError(c, err, "Failed to revoke OAuth client")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "OAuth client revoked successfully"})
+}
+
+// clientCredentials returns the client ID and secret from HTTP Basic auth, whose
+// parts are form-encoded (RFC 6749, section 2.3.1), or else from the request body.
+func clientCredentials(c *gin.Context, formID, formSecret string) (string, string) {
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		return formID, formSecret
+	}
+	clientID, err := url.QueryUnescape(username)
+	if err != nil {
+		return "", ""
+	}
+	clientSecret, err := url.QueryUnescape(password)
+	if err != nil {
+		return "", ""
+	}
+	return clientID, clientSecret
+}
+
+// respondOAuthError maps OAuth service errors to the error responses of RFC 6749.
+func respondOAuthError(c *gin.Context, err error, fallback string) {
+	var oauthErr *service.OAuthError
+	if !errors.As(err, &oauthErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error", "error_description": fallback})
+		return
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr == service.ErrOAuthInvalidClient {
+		status = http.StatusUnauthorized
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	c.JSON(status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+}
+
+// respondOAuthClientError maps OAuth client management errors to HTTP responses.
+func respondOAuthClientError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrOAuthClientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrUnknownOAuthScope), errors.Is(err, service.ErrOAuthRedirectURIRequired),
+		errors.Is(err, service.ErrOAuthServiceAccountRequired), errors.Is(err, service.ErrOAuthPublicClientCredentials):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Service account user not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"wallet-service/internal/models"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	_ "wallet-service/docs"
+)
+
+// OIDCHandler handles login through an external OpenID Connect provider.
+type OIDCHandler struct {
+	oidcService *service.OIDCService
+}
+
+// NewOIDCHandler creates a new OIDCHandler.
+func NewOIDCHandler(oidcService *service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{oidcService: oidcService}
+}
+
+// Authorize handles requests to start an external login.
+// @Summary Start external login
+// @Description Start a login through the configured OpenID Connect provider. Send the user's browser to
+// @Description authorization_url; the provider returns them to the front end with a code and the state,
+// @Description which the front end should check against the state returned here before calling the callback.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} models.OIDCAuthorizationResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/oidc/authorize [get]
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	resp, err := h.oidcService.Authorize(c.Request.Context())
+	if err != nil {
+		respondOIDCError(c, err, "Failed to start external login")
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Callback handles the code and state returned by the provider.
+// @Summary Complete external login
+// @Description Complete a login through the OpenID Connect provider. On first login the provider account is
+// @Description linked to the user with the same verified email address, or a new user with a primary wallet
+// @Description is created. Users with two-factor enabled receive mfa_required and an mfa_token, as at login.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.OIDCCallbackRequest true "Callback request"
+// @Success 200 {object} models.AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/oidc/callback [post]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	var req models.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.oidcService.Callback(c.Request.Context(), &req)
+	if err != nil {
+		respondOIDCError(c, err, "Failed to complete external login")
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// respondOIDCError maps external login errors to HTTP responses.
+func respondOIDCError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrOIDCDisabled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidOIDCState), errors.Is(err, service.ErrOIDCEmailRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOIDCLoginFailed):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOIDCAccountExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
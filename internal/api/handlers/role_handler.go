@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"wallet-service/internal/models"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	_ "wallet-service/docs"
+)
+
+// RoleHandler handles role and permission management HTTP requests.
+type RoleHandler struct {
+	roleService *service.RoleService
+}
+
+// NewRoleHandler creates a new RoleHandler.
+func NewRoleHandler(roleService *service.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// ListRoles handles the request to list roles.
+// @Summary List roles (Admin)
+// @Description List every role with its permissions (requires roles:read)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.Role
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/roles [get]
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleService.ListRoles(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve roles"})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// GetRole handles the request to get one role.
+// @Summary Get role (Admin)
+// @Description Retrieve a role with its permissions (requires roles:read)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} models.Role
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/roles/{name} [get]
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	role, err := h.roleService.GetRole(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		respondRoleError(c, err, "Failed to retrieve role")
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+// CreateRole handles the request to create a role.
+// @Summary Create role (Admin)
+// @Description Create a custom role with a set of permissions (requires roles:write)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.CreateRoleRequest true "Create role request"
+// @Success 201 {object} models.Role
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/roles [post]
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req models.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.CreateRole(c.Request.Context(), &req)
+	if err != nil {
+		respondRoleError(c, err, "Failed to create role")
+		return
+	}
+	c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole handles the request to change a role.
+// @Summary Update role (Admin)
+// @Description Replace a role's description and permissions. The admin role cannot be changed (requires roles:write)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param name path string true "Role name"
+// @Param request body models.UpdateRoleRequest true "Update role request"
+// @Success 200 {object} models.Role
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/roles/{name} [put]
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(c.Request.Context(), c.Param("name"), &req)
+	if err != nil {
+		respondRoleError(c, err, "Failed to update role")
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole handles the request to delete a role.
+// @Summary Delete role (Admin)
+// @Description Delete a custom role that no user holds (requires roles:write)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/roles/{name} [delete]
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	if err := h.roleService.DeleteRole(c.Request.Context(), c.Param("name")); err != nil {
+		respondRoleError(c, err, "Failed to delete role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
+
+// ListPermissions handles the request to list permissions.
+// @Summary List permissions (Admin)
+// @Description List every permission that can be granted to a role (requires roles:read)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.Permission
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/permissions [get]
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.roleService.ListPermissions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve permissions"})
+		return
+	}
+	c.JSON(http.StatusOK, permissions)
+}
+
+// respondRoleError maps role service errors to HTTP responses.
+func respondRoleError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrRoleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidRoleName), errors.Is(err, service.ErrUnknownPermission):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrRoleExists), errors.Is(err, service.ErrRoleBuiltIn),
+		errors.Is(err, service.ErrRoleImmutable), errors.Is(err, service.ErrRoleInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"strconv"
+	"wallet-service/internal/api/middleware"
+	"wallet-service/internal/models"
 	"wallet-service/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -19,29 +21,54 @@ func NewTransactionHandler(transactionService *service.TransactionService) *Tran
 	return &TransactionHandler{transactionService: transactionService}
 }
 
-// GetTransactions handles the request to get all transactions for a wallet.
+// GetTransactions handles the request to list a wallet's transactions.
 // @Summary Get wallet transactions
-// @Description Retrieve all transactions for a specific wallet
+// @Description Retrieve a page of transactions for a wallet owned by the current user (admins may view any wallet).
+// @Description Results are ordered by creation time; pass next_cursor back as cursor to fetch the following page.
+// @Description A cursor is only valid with the order and filters of the request that returned it.
 // @Tags Transactions
 // @Security ApiKeyAuth
 // @Produce json
 // @Param walletID path int true "Wallet ID"
-// @Success 200 {array} models.Transaction
+// @Param type query string false "Transaction type" Enums(deposit, withdrawal, transfer, reversal, adjustment)
+// @Param status query string false "Transaction status" Enums(pending, completed, failed)
+// @Param min_amount query string false "Minimum amount, inclusive"
+// @Param max_amount query string false "Maximum amount, inclusive"
+// @Param from query string false "Earliest creation time, RFC 3339"
+// @Param to query string false "Latest creation time, RFC 3339"
+// @Param reference query string false "Case-insensitive reference substring"
+// @Param order query string false "Sort order by creation time" Enums(asc, desc) default(desc)
+// @Param cursor query string false "Cursor from a previous page's next_cursor, sent with the same order and filters"
+// @Param limit query int false "Page size" minimum(1) maximum(100) default(20)
+// @Success 200 {object} models.TransactionPage
 // @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/wallets/{walletID}/transactions [get]
 func (h *TransactionHandler) GetTransactions(c *gin.Context) {
-	walletID, err := strconv.ParseUint(c.Param("walletID"), 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet ID"})
+	// The wallet has already been authorized by middleware.WalletAccessMiddleware
+	wallet, ok := middleware.CurrentWallet(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+		return
+	}
+
+	var query models.TransactionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	transactions, err := h.transactionService.GetTransactionsByWalletID(uint(walletID))
+	page, err := h.transactionService.ListTransactions(c.Request.Context(), wallet, query)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) || errors.Is(err, service.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transactions"})
 		return
 	}
 
-	c.JSON(http.StatusOK, transactions)
+	c.JSON(http.StatusOK, page)
 }
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"wallet-service/internal/models"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	_ "wallet-service/docs"
+)
+
+// TwoFactorHandler handles two-factor authentication HTTP requests.
+type TwoFactorHandler struct {
+	twoFactorService *service.TwoFactorService
+	authService      *service.AuthService
+}
+
+// NewTwoFactorHandler creates a new TwoFactorHandler.
+func NewTwoFactorHandler(twoFactorService *service.TwoFactorService, authService *service.AuthService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: twoFactorService, authService: authService}
+}
+
+// Enroll handles requests to start two-factor enrollment.
+// @Summary Start two-factor enrollment
+// @Description Generate a TOTP secret and otpauth URI to add to an authenticator app. Two-factor is not enforced until confirmed.
+// @Tags Two-Factor Authentication
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} models.TwoFactorEnrollment
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/2fa/enroll [post]
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(c.Request.Context(), uint(userID.(float64)))
+	if err != nil {
+		respondTwoFactorError(c, err, "Failed to start two-factor enrollment")
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// Confirm handles requests to finish two-factor enrollment.
+// @Summary Confirm two-factor enrollment
+// @Description Enable two-factor authentication with a code from the authenticator app and receive recovery codes
+// @Tags Two-Factor Authentication
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} models.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/2fa/confirm [post]
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	userID, req, ok := bindTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.twoFactorService.Confirm(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "Failed to confirm two-factor enrollment")
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// Disable handles requests to turn two-factor authentication off.
+// @Summary Disable two-factor authentication
+// @Description Turn two-factor authentication off with a TOTP or recovery code
+// @Tags Two-Factor Authentication
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	userID, req, ok := bindTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	if err := h.twoFactorService.Disable(c.Request.Context(), userID, req.Code); err != nil {
+		respondTwoFactorError(c, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes handles requests to replace the recovery codes.
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes with new ones. Requires a TOTP code; recovery codes are not accepted.
+// @Tags Two-Factor Authentication
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} models.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, req, ok := bindTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+// Verify handles the second step of a two-factor login.
+// @Summary Complete two-factor login
+// @Description Exchange the MFA token returned by login, together with a TOTP or recovery code, for access and refresh tokens.
+// @Description Each MFA token completes one login. Wrong codes are counted, and too many lock the second factor; 429 responses carry Retry-After.
+// @Tags Two-Factor Authentication
+// @Accept json
+// @Produce json
+// @Param request body models.MFAVerifyRequest true "Two-factor login request"
+// @Success 200 {object} models.AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/2fa/verify [post]
+func (h *TwoFactorHandler) Verify(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.authService.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMFAToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		respondTwoFactorError(c, err, "Failed to verify two-factor code")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// StepUp handles requests for an access token that records a fresh second factor.
+// @Summary Step-up authentication
+// @Description Present a TOTP or recovery code to obtain an access token accepted by endpoints that demand a recent second factor, such as funding, withdrawals and transfers
+// @Tags Two-Factor Authentication
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} models.StepUpResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/2fa/step-up [post]
+func (h *TwoFactorHandler) StepUp(c *gin.Context) {
+	userID, req, ok := bindTwoFactorCode(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.authService.StepUp(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err, "Failed to verify two-factor code")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// bindTwoFactorCode reads the authenticated user ID and the code request body.
+// It writes the error response and returns false on failure.
+func bindTwoFactorCode(c *gin.Context) (uint, models.TwoFactorCodeRequest, bool) {
+	var req models.TwoFactorCodeRequest
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return 0, req, false
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, req, false
+	}
+	return uint(userID.(float64)), req, true
+}
+
+// respondTwoFactorError maps two-factor service errors to HTTP responses.
+func respondTwoFactorError(c *gin.Context, err error, fallback string) {
+	var throttled *service.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTwoFactorNotEnrolled), errors.Is(err, service.ErrTwoFactorNotEnabled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
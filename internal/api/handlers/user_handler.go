@@ -20,15 +20,16 @@ func NewUserHandler(svc *service.UserService) *UserHandler {
 
 // GetUsers retrieves all users
 // @Summary Get all users
-// @Description Retrieve a list of all users in the system
+// @Description Retrieve a list of all users in the system (requires users:read)
 // @Tags Users
 // @Security ApiKeyAuth
 // @Produce json
 // @Success 200 {array} models.User
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/users [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
-	users, err := h.Service.GetAllUsers()
+	users, err := h.Service.GetAllUsers(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -38,17 +39,18 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 
 // GetUserByID retrieves a user by ID
 // @Summary Get user by ID
-// @Description Retrieve a specific user by their ID
+// @Description Retrieve a specific user by their ID (requires users:read)
 // @Tags Users
 // @Security ApiKeyAuth
 // @Produce json
 // @Param id path int true "User ID"
 // @Success 200 {object} models.User
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /api/users/{id} [get]
 func (h *UserHandler) GetUserByID(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	user, err := h.Service.GetUserByID(uint(id))
+	user, err := h.Service.GetUserByID(c.Request.Context(), uint(id))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -58,17 +60,18 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 
 // GetUserByEmail retrieves a user by email
 // @Summary Get user by email
-// @Description Retrieve a specific user by their email address
+// @Description Retrieve a specific user by their email address (requires users:read)
 // @Tags Users
 // @Security ApiKeyAuth
 // @Produce json
 // @Param email path string true "User Email"
 // @Success 200 {object} models.User
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /api/users/email/{email} [get]
 func (h *UserHandler) GetUserByEmail(c *gin.Context) {
 	email := c.Param("email")
-	user, err := h.Service.GetUserByEmail(email)
+	user, err := h.Service.GetUserByEmail(c.Request.Context(), email)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -78,7 +81,7 @@ func (h *UserHandler) GetUserByEmail(c *gin.Context) {
 
 // CreateUser creates a new user
 // @Summary Create a new user
-// @Description Create a new user account
+// @Description Create a new user account with the user role (requires users:write)
 // @Tags Users
 // @Security ApiKeyAuth
 // @Accept json
@@ -86,6 +89,7 @@ func (h *UserHandler) GetUserByEmail(c *gin.Context) {
 // @Param user body models.User true "User object"
 // @Success 200 {object} models.User
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
@@ -95,11 +99,10 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.Service.CreateUser(&user); err != nil {
+	if err := h.Service.CreateUser(c.Request.Context(), &user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, user)
 }
-
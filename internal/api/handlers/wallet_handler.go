@@ -1,29 +1,33 @@
 package handlers
 
 import (
-	"log"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"wallet-service/internal/api/middleware"
 	"wallet-service/internal/models"
 	"wallet-service/internal/service"
+	"wallet-service/pkg/money"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	_ "wallet-service/docs"
 )
 
 // WalletHandler handles wallet-related HTTP requests.
 type WalletHandler struct {
-	walletService      *service.WalletService
-	transactionService *service.TransactionService
+	walletService *service.WalletService
 }
 
 // NewWalletHandler creates a new WalletHandler.
-func NewWalletHandler(walletService *service.WalletService, transactionService *service.TransactionService) *WalletHandler {
-	return &WalletHandler{walletService: walletService, transactionService: transactionService}
+func NewWalletHandler(walletService *service.WalletService) *WalletHandler {
+	return &WalletHandler{walletService: walletService}
 }
 
-// GetWallet handles the request to get the current user's wallet.
+// GetWallet handles the request to get the current user's default wallet.
 // @Summary Get user wallet
-// @Description Retrieve the current user's wallet information
+// @Description Retrieve the current user's default wallet information
 // @Tags Wallets
 // @Security ApiKeyAuth
 // @Produce json
@@ -38,7 +42,7 @@ func (h *WalletHandler) GetWallet(c *gin.Context) {
 		return
 	}
 
-	wallet, err := h.walletService.GetWalletByUserID(uint(userID.(float64)))
+	wallet, err := h.walletService.GetWalletByUserID(c.Request.Context(), uint(userID.(float64)))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
 		return
@@ -47,24 +51,200 @@ func (h *WalletHandler) GetWallet(c *gin.Context) {
 	c.JSON(http.StatusOK, wallet)
 }
 
-// FundWallet handles the request to add funds to the user's wallet.
+// ListWallets handles the request to list the current user's wallets.
+// @Summary List user wallets
+// @Description Retrieve all wallets belonging to the current user
+// @Tags Wallets
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.Wallet
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets [get]
+func (h *WalletHandler) ListWallets(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	wallets, err := h.walletService.ListWallets(c.Request.Context(), uint(userID.(float64)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve wallets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, wallets)
+}
+
+// CreateWallet handles the request to open an additional wallet.
+// @Summary Create wallet
+// @Description Open a new wallet in the given currency for the current user
+// @Tags Wallets
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.CreateWalletRequest true "Wallet creation request"
+// @Success 201 {object} models.Wallet
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets [post]
+func (h *WalletHandler) CreateWallet(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return
+	}
+
+	var req models.CreateWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	wallet, err := h.walletService.CreateWallet(c.Request.Context(), uint(userID.(float64)), req.Name, req.Currency)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create wallet"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, wallet)
+}
+
+// GetWalletByID handles the request to get one of the current user's wallets.
+// @Summary Get wallet by ID
+// @Description Retrieve one of the current user's wallets (admins may view any wallet)
+// @Tags Wallets
+// @Security ApiKeyAuth
+// @Produce json
+// @Param walletID path int true "Wallet ID"
+// @Success 200 {object} models.Wallet
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/wallets/{walletID} [get]
+func (h *WalletHandler) GetWalletByID(c *gin.Context) {
+	_, wallet, ok := h.resolveWallet(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, wallet)
+}
+
+// RenameWallet handles the request to rename one of the current user's wallets.
+// @Summary Rename wallet
+// @Description Change the name of one of the current user's wallets
+// @Tags Wallets
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param walletID path int true "Wallet ID"
+// @Param request body models.RenameWalletRequest true "Wallet rename request"
+// @Success 200 {object} models.Wallet
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets/{walletID} [patch]
+func (h *WalletHandler) RenameWallet(c *gin.Context) {
+	userID, wallet, ok := h.resolveWallet(c)
+	if !ok {
+		return
+	}
+
+	var req models.RenameWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	wallet, err := h.walletService.RenameWallet(c.Request.Context(), userID, wallet.ID, req.Name)
+	if err != nil {
+		respondWalletError(c, err, "Failed to rename wallet")
+		return
+	}
+
+	c.JSON(http.StatusOK, wallet)
+}
+
+// SetDefaultWallet handles the request to make a wallet the current user's default.
+// @Summary Set default wallet
+// @Description Make one of the current user's wallets their default wallet
+// @Tags Wallets
+// @Security ApiKeyAuth
+// @Produce json
+// @Param walletID path int true "Wallet ID"
+// @Success 200 {object} models.Wallet
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets/{walletID}/default [post]
+func (h *WalletHandler) SetDefaultWallet(c *gin.Context) {
+	userID, wallet, ok := h.resolveWallet(c)
+	if !ok {
+		return
+	}
+
+	wallet, err := h.walletService.SetDefaultWallet(c.Request.Context(), userID, wallet.ID)
+	if err != nil {
+		respondWalletError(c, err, "Failed to set default wallet")
+		return
+	}
+
+	c.JSON(http.StatusOK, wallet)
+}
+
+// FundWallet handles the request to add funds to the user's default wallet.
 // @Summary Fund wallet
-// @Description Add funds to the user's wallet
+// @Description Add funds to the user's default wallet
 // @Tags Wallets
 // @Security ApiKeyAuth
 // @Accept json
 // @Produce json
 // @Param request body models.FundWalletRequest true "Funding request"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 200 {object} models.Wallet
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/wallet/fund [post]
 func (h *WalletHandler) FundWallet(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+	h.fund(c)
+}
+
+// FundWalletByID handles the request to add funds to one of the user's wallets.
+// @Summary Fund wallet by ID
+// @Description Add funds to one of the user's wallets
+// @Tags Wallets
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param walletID path int true "Wallet ID"
+// @Param request body models.FundWalletRequest true "Funding request"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 200 {object} models.Wallet
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets/{walletID}/fund [post]
+func (h *WalletHandler) FundWalletByID(c *gin.Context) {
+	h.fund(c)
+}
+
+func (h *WalletHandler) fund(c *gin.Context) {
+	userID, wallet, ok := h.resolveWallet(c)
+	if !ok {
 		return
 	}
 
@@ -74,36 +254,310 @@ func (h *WalletHandler) FundWallet(c *gin.Context) {
 		return
 	}
 
-	// Get the wallet to find its ID and current balance
-	wallet, err := h.walletService.GetWalletByUserID(uint(userID.(float64)))
+	amount, err := money.Parse(req.Amount, wallet.Currency)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Fund the wallet
-	updatedWallet, err := h.walletService.FundWallet(uint(userID.(float64)), req.Amount)
+	// The deposit transaction is recorded atomically with the balance change
+	updatedWallet, err := h.walletService.FundWallet(c.Request.Context(), userID, wallet.ID, amount)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fund wallet"})
+		respondWalletError(c, err, "Failed to fund wallet")
 		return
 	}
 
-	// Create a transaction record
-	transaction := &models.Transaction{
-		WalletID:       wallet.ID,
-		Type:           "deposit",
-		Amount:         req.Amount,
-		Description:    "Wallet funding",
-		Status:         "completed",
-		Reference:      "",
-		BalanceBefore:  wallet.Balance,
-		BalanceAfter:   updatedWallet.Balance,
+	c.JSON(http.StatusOK, updatedWallet)
+}
+
+// WithdrawWallet handles the request to withdraw funds from the user's default wallet.
+// @Summary Withdraw from wallet
+// @Description Debit the user's default wallet and record a withdrawal transaction
+// @Tags Wallets
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.WithdrawWalletRequest true "Withdrawal request"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 200 {object} models.Wallet
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallet/withdraw [post]
+func (h *WalletHandler) WithdrawWallet(c *gin.Context) {
+	h.withdraw(c)
+}
+
+// WithdrawWalletByID handles the request to withdraw funds from one of the user's wallets.
+// @Summary Withdraw from wallet by ID
+// @Description Debit one of the user's wallets and record a withdrawal transaction
+// @Tags Wallets
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param walletID path int true "Wallet ID"
+// @Param request body models.WithdrawWalletRequest true "Withdrawal request"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 200 {object} models.Wallet
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets/{walletID}/withdraw [post]
+func (h *WalletHandler) WithdrawWalletByID(c *gin.Context) {
+	h.withdraw(c)
+}
+
+func (h *WalletHandler) withdraw(c *gin.Context) {
+	userID, wallet, ok := h.resolveWallet(c)
+	if !ok {
+		return
+	}
+
+	var req models.WithdrawWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	amount, err := money.Parse(req.Amount, wallet.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if err := h.transactionService.CreateTransaction(transaction); err != nil {
-		// Log the error, but don't fail the funding
-		log.Printf("Failed to create transaction for wallet %d: %v", wallet.ID, err)
+	updatedWallet, err := h.walletService.Withdraw(c.Request.Context(), userID, wallet.ID, amount, req.Description)
+	if err != nil {
+		respondWalletError(c, err, "Failed to withdraw from wallet")
+		return
 	}
 
 	c.JSON(http.StatusOK, updatedWallet)
 }
+
+// TransferFunds handles the request to transfer funds from the user's default wallet.
+// @Summary Transfer funds
+// @Description Move funds from the user's default wallet to another wallet, identified by wallet ID or owner email
+// @Tags Wallets
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param request body models.TransferRequest true "Transfer request"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 200 {object} models.TransferResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallet/transfer [post]
+func (h *WalletHandler) TransferFunds(c *gin.Context) {
+	h.transfer(c)
+}
+
+// TransferFundsByID handles the request to transfer funds from one of the user's wallets.
+// @Summary Transfer funds from wallet by ID
+// @Description Move funds from one of the user's wallets to another wallet, identified by wallet ID or owner email
+// @Tags Wallets
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param walletID path int true "Wallet ID"
+// @Param request body models.TransferRequest true "Transfer request"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 200 {object} models.TransferResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/wallets/{walletID}/transfer [post]
+func (h *WalletHandler) TransferFundsByID(c *gin.Context) {
+	h.transfer(c)
+}
+
+func (h *WalletHandler) transfer(c *gin.Context) {
+	userID, wallet, ok := h.resolveWallet(c)
+	if !ok {
+		return
+	}
+
+	var req models.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	amount, err := money.Parse(req.Amount, wallet.Currency)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.walletService.Transfer(c.Request.Context(), userID, wallet.ID, req.ToWalletID, req.ToEmail, amount, req.Note)
+	if err != nil {
+		respondWalletError(c, err, "Failed to transfer funds")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// resolveWallet loads the wallet a request operates on: the wallet authorized by
+// the wallet access middleware, the wallet named by the walletID path parameter
+// when present, otherwise the user's default wallet.
+// It writes the error response and returns false when the wallet cannot be resolved.
+func (h *WalletHandler) resolveWallet(c *gin.Context) (uint, *models.Wallet, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+		return 0, nil, false
+	}
+	uid := uint(userID.(float64))
+
+	// Wallet-scoped routes have already been authorized by middleware
+	if wallet, ok := middleware.CurrentWallet(c); ok {
+		return uid, wallet, true
+	}
+
+	var (
+		wallet *models.Wallet
+		err    error
+	)
+	if param := c.Param("walletID"); param != "" {
+		walletID, parseErr := strconv.ParseUint(param, 10, 32)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet ID"})
+			return 0, nil, false
+		}
+		wallet, err = h.walletService.GetWallet(c.Request.Context(), uid, uint(walletID))
+	} else {
+		wallet, err = h.walletService.GetWalletByUserID(c.Request.Context(), uid)
+	}
+	if err != nil {
+		respondWalletError(c, err, "Failed to retrieve wallet")
+		return 0, nil, false
+	}
+
+	return uid, wallet, true
+}
+
+// ReverseTransaction handles the request to reverse a completed transaction.
+// @Summary Reverse transaction (Admin)
+// @Description Undo a completed deposit, withdrawal or transfer (requires transactions:reverse). Reversing either leg of a transfer reverses both.
+// @Description The original transaction is marked "reversed" and the opposite movement is recorded as "reversal" transactions.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param transactionID path int true "Transaction ID"
+// @Param request body models.ReverseTransactionRequest false "Reversal request"
+// @Success 200 {object} models.ReversalResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/transactions/{transactionID}/reverse [post]
+func (h *WalletHandler) ReverseTransaction(c *gin.Context) {
+	transactionID, err := strconv.ParseUint(c.Param("transactionID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+	var req models.ReverseTransactionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	resp, err := h.walletService.ReverseTransaction(c.Request.Context(), uint(transactionID), req.Reason)
+	if err != nil {
+		respondWalletError(c, err, "Failed to reverse transaction")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// AdjustBalance handles the request to adjust any wallet's balance.
+// @Summary Adjust wallet balance (Admin)
+// @Description Credit a wallet, or debit it with a negative amount, against the settlement account (requires wallets:adjust).
+// @Description The change is recorded as an "adjustment" transaction carrying the reason. Adjustments that would overdraw the wallet are refused.
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param walletID path int true "Wallet ID"
+// @Param request body models.AdjustBalanceRequest true "Adjustment request"
+// @Success 200 {object} models.Transaction
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/wallets/{walletID}/adjust [post]
+func (h *WalletHandler) AdjustBalance(c *gin.Context) {
+	walletID, err := strconv.ParseUint(c.Param("walletID"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet ID"})
+		return
+	}
+	var req models.AdjustBalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	delta, err := money.Parse(req.Amount, strings.ToUpper(req.Currency))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transaction, err := h.walletService.AdjustBalance(c.Request.Context(), uint(walletID), delta, req.Reason)
+	if err != nil {
+		respondWalletError(c, err, "Failed to adjust wallet balance")
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+// respondWalletError maps wallet service errors to HTTP responses.
+func respondWalletError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrWalletNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+	case errors.Is(err, service.ErrRecipientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipient wallet not found"})
+	case errors.Is(err, service.ErrTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrTransactionNotReversible):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrSelfTransfer), errors.Is(err, service.ErrAmbiguousRecipient):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInsufficientFunds):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Insufficient funds"})
+	case errors.Is(err, service.ErrCurrencyMismatch), errors.Is(err, service.ErrWalletInactive):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -1,32 +0,0 @@
-package middleware
-
-import (
-	"net/http"
-	"wallet-service/internal/repository"
-
-	"github.com/gin-gonic/gin"
-)
-
-// AdminMiddleware creates a middleware handler to check for admin privileges.
-func AdminMiddleware(userRepo *repository.UserRepository) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userID, exists := c.Get("user_id")
-		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
-			return
-		}
-
-		user, err := userRepo.FindByID(uint(userID.(float64)))
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "User not found"})
-			return
-		}
-
-		if user.Role != "admin" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
-			return
-		}
-
-		c.Next()
-	}
-}
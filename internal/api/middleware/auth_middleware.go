@@ -6,11 +6,30 @@ import (
 	"wallet-service/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware creates a middleware handler for JWT authentication.
+// APIKeyHeader is the request header that carries an API key.
+const APIKeyHeader = "X-API-Key"
+
+// AuthMiddleware creates a middleware handler that authenticates a request with
+// either a "Bearer <jwt>" Authorization header or an API key in the X-API-Key
+// header. Both set the same user_id and token_claims context values.
 func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := c.GetHeader(APIKeyHeader); apiKey != "" {
+			claims, err := authService.ValidateAPIKey(c.Request.Context(), apiKey)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired API key"})
+				return
+			}
+
+			c.Set("user_id", claims["user_id"])
+			c.Set("token_claims", claims)
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is missing"})
@@ -23,13 +42,34 @@ func AuthMiddleware(authService *service.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		claims, err := authService.ValidateToken(tokenString)
+		claims, err := authService.ValidateToken(c.Request.Context(), tokenString)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			return
 		}
 
 		c.Set("user_id", claims["user_id"])
+		c.Set("token_claims", claims)
+		c.Next()
+	}
+}
+
+// UserSessionMiddleware rejects requests authenticated with an API key or an
+// OAuth access token, for routes that manage credentials and must only be used
+// from a user's own session. It must run after AuthMiddleware.
+func UserSessionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("token_claims")
+		if claims, ok := value.(jwt.MapClaims); ok {
+			if claims["typ"] == service.TokenTypeAPIKey {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint cannot be used with an API key"})
+				return
+			}
+			if _, ok := claims["client_id"]; ok {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint cannot be used with an OAuth access token"})
+				return
+			}
+		}
 		c.Next()
 	}
 }
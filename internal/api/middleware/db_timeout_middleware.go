@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DBTimeout bounds the database work of each request. Services run their queries
+// with the request's context, so once timeout elapses, or the client disconnects,
+// queries still in flight are cancelled and later ones fail at once with the
+// context's error. A timeout of zero leaves requests unbounded.
+func DBTimeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
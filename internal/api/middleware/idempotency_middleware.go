@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header clients use to make retries safe.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyMiddleware replays the stored response when a user repeats a request with the
+// same Idempotency-Key header, and rejects reuse of a key with a different payload.
+// Requests without the header pass through unchanged. It must run after AuthMiddleware.
+func IdempotencyMiddleware(idempotencyService *service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+		record, replay, err := idempotencyService.Begin(c.Request.Context(), uint(userID.(float64)), key, fingerprint)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrIdempotencyKeyReused):
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			case errors.Is(err, service.ErrIdempotencyKeyInFlight), errors.Is(err, service.ErrIdempotencyOutcomeUnknown):
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to process Idempotency-Key"})
+			}
+			return
+		}
+
+		if replay {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.StatusCode, record.ContentType, record.Body)
+			c.Abort()
+			return
+		}
+
+		// The outcome is recorded even if the client has gone or the request timed out,
+		// so that the key is not left in progress.
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		// Keep the key in flight for as long as the handler runs, so that a slow request
+		// is never mistaken for one whose process died.
+		stopRenewing := renewLease(ctx, idempotencyService, record, key)
+
+		// A panicking handler produced no response to replay, so free the key for a
+		// retry before the panic reaches the recovery middleware.
+		defer func() {
+			if r := recover(); r != nil {
+				stopRenewing()
+				if err := idempotencyService.Release(ctx, record); err != nil {
+					log.Printf("Failed to release idempotency key %q: %v", key, err)
+				}
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		stopRenewing()
+
+		// Server errors are not cached so that the client can retry them.
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := idempotencyService.Release(ctx, record); err != nil {
+				log.Printf("Failed to release idempotency key %q: %v", key, err)
+			}
+			return
+		}
+		if err := idempotencyService.Complete(ctx, record, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			// The key stays in flight and, once its lease runs out, retries are refused
+			// because the outcome is unknown.
+			log.Printf("Failed to store response for idempotency key %q: %v", key, err)
+		}
+	}
+}
+
+// renewLease renews record's lease every third of the lease until the returned
+// function is called. The function waits for any renewal in progress to finish.
+func renewLease(ctx context.Context, idempotencyService *service.IdempotencyService, record *models.IdempotencyKey, key string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(idempotencyService.Lease() / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := idempotencyService.Renew(ctx, record); err != nil {
+					log.Printf("Failed to renew idempotency key %q: %v", key, err)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// responseRecorder captures the response body while still writing it to the client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RequirePermission only lets through users whose access token grants every one
+// of the given permissions in its "perms" claim. It must run after AuthMiddleware.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("token_claims"); !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token claims not found"})
+			return
+		}
+		if !HasPermissions(c, permissions...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// HasPermissions reports whether the access token authenticated by AuthMiddleware
+// grants every one of the given permissions.
+func HasPermissions(c *gin.Context, permissions ...string) bool {
+	value, _ := c.Get("token_claims")
+	claims, ok := value.(jwt.MapClaims)
+	if !ok {
+		return false
+	}
+	granted, _ := claims["perms"].([]interface{})
+
+	held := make(map[string]bool, len(granted))
+	for _, permission := range granted {
+		if name, ok := permission.(string); ok {
+			held[name] = true
+		}
+	}
+	for _, permission := range permissions {
+		if !held[permission] {
+			return false
+		}
+	}
+	return true
+}
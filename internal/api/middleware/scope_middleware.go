@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RequireScope only lets through OAuth access tokens whose "scope" claim includes
+// scope. Tokens from a login and API keys carry no scope and are not restricted,
+// since permissions and wallet ownership still apply to them. It must run after
+// AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("token_claims")
+		claims, ok := value.(jwt.MapClaims)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token claims not found"})
+			return
+		}
+
+		if granted, scoped := claims["scope"].(string); scoped {
+			for _, name := range strings.Fields(granted) {
+				if name == scope {
+					c.Next()
+					return
+				}
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token lacks the " + scope + " scope"})
+			return
+		}
+
+		c.Next()
+	}
+}
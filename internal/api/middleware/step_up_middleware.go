@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// StepUpMiddleware demands a recent second factor from users who have two-factor
+// authentication enabled. Their access token must carry an "mfa_at" claim no older
+// than maxAge, obtained from a two-factor login or POST /api/auth/2fa/step-up.
+// Users without two-factor enabled are let through, as are API keys: creating one
+// takes a recent second factor, and the routes it may reach are limited by the
+// permissions it was granted. It must run after AuthMiddleware.
+func StepUpMiddleware(maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("token_claims")
+		claims, ok := value.(jwt.MapClaims)
+		if !exists || !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token claims not found"})
+			return
+		}
+
+		if typ, _ := claims["typ"].(string); typ == service.TokenTypeAPIKey {
+			c.Next()
+			return
+		}
+
+		if enabled, _ := claims["mfa"].(bool); !enabled {
+			c.Next()
+			return
+		}
+
+		mfaAt, _ := claims["mfa_at"].(float64)
+		if mfaAt == 0 || time.Since(time.Unix(int64(mfaAt), 0)) > maxAge {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Step-up authentication required"})
+			return
+		}
+
+		c.Next()
+	}
+}
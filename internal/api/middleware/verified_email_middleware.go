@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"wallet-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// VerifiedEmailMiddleware only lets through users who have verified their email
+// address. It reads the user from the database rather than the token, so that a
+// verification takes effect without logging in again. It must run after AuthMiddleware.
+func VerifiedEmailMiddleware(userRepo repository.UserStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+			return
+		}
+
+		user, err := userRepo.FindByID(c.Request.Context(), uint(userID.(float64)))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to load user"})
+			return
+		}
+		if !user.EmailVerified() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Email address not verified"})
+			return
+		}
+
+		c.Next()
+	}
+}
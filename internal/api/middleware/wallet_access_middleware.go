@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// walletContextKey is the gin context key under which the authorized wallet is stored.
+const walletContextKey = "wallet"
+
+// WalletAccessMiddleware authorizes access to the wallet named by the walletID
+// path parameter. The wallet's owner and users with the wallets:read permission
+// are let through; everyone else gets a 404 so that the existence of other users'
+// wallets is not revealed. Use it on read-only wallet-scoped routes.
+func WalletAccessMiddleware(walletRepo repository.WalletStore) gin.HandlerFunc {
+	return walletAuthorization(walletRepo, true)
+}
+
+// WalletOwnerMiddleware authorizes access to the wallet named by the walletID
+// path parameter for its owner only, with no permission override. Use it on routes
+// that change the wallet or move its funds.
+func WalletOwnerMiddleware(walletRepo repository.WalletStore) gin.HandlerFunc {
+	return walletAuthorization(walletRepo, false)
+}
+
+// CurrentWallet returns the wallet authorized by WalletAccessMiddleware or WalletOwnerMiddleware.
+func CurrentWallet(c *gin.Context) (*models.Wallet, bool) {
+	value, exists := c.Get(walletContextKey)
+	if !exists {
+		return nil, false
+	}
+	wallet, ok := value.(*models.Wallet)
+	return wallet, ok
+}
+
+// walletAuthorization resolves the walletID path parameter and checks the
+// wallet's owner against the JWT user_id. Users with the wallets:read permission
+// are allowed through when allowReaders is set.
+func walletAuthorization(walletRepo repository.WalletStore, allowReaders bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in token"})
+			return
+		}
+
+		walletID, err := strconv.ParseUint(c.Param("walletID"), 10, 32)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet ID"})
+			return
+		}
+
+		wallet, err := walletRepo.FindByID(c.Request.Context(), uint(walletID))
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve wallet"})
+			return
+		}
+		if err != nil || !canAccessWallet(c, uint(userID.(float64)), wallet, allowReaders) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+			return
+		}
+
+		c.Set(walletContextKey, wallet)
+		c.Next()
+	}
+}
+
+func canAccessWallet(c *gin.Context, userID uint, wallet *models.Wallet, allowReaders bool) bool {
+	return wallet.UserID == userID || (allowReaders && HasPermissions(c, models.PermissionWalletsRead))
+}
@@ -3,21 +3,42 @@ package routes
 import (
 	"wallet-service/internal/api/handlers"
 	"wallet-service/internal/api/middleware"
-	"wallet-service/internal/repository"
+	"wallet-service/internal/models"
 	"wallet-service/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SetupAdminRoutes configures the admin-only routes.
-func SetupAdminRoutes(router *gin.Engine, adminHandler *handlers.AdminHandler, authService *service.AuthService, userRepo *repository.UserRepository) {
+// SetupAdminRoutes configures the back-office routes. Each route requires the
+// permissions it needs, so read-only roles such as support_agent and auditor can
+// use the read routes.
+func SetupAdminRoutes(router *gin.Engine, adminHandler *handlers.AdminHandler, roleHandler *handlers.RoleHandler, oauthHandler *handlers.OAuthHandler, healthHandler *handlers.HealthHandler, walletHandler *handlers.WalletHandler, authService *service.AuthService) {
+	require := middleware.RequirePermission
+
 	adminRoutes := router.Group("/api/admin")
 	adminRoutes.Use(middleware.AuthMiddleware(authService))
-	adminRoutes.Use(middleware.AdminMiddleware(userRepo))
 	{
-		adminRoutes.GET("/users", adminHandler.GetAllUsers)
-		adminRoutes.PUT("/users/:id/role", adminHandler.UpdateUserRole)
-		adminRoutes.DELETE("/users/:id", adminHandler.DeleteUser)
-		adminRoutes.DELETE("/users", adminHandler.DeleteAllUsers)
+		adminRoutes.GET("/users", require(models.PermissionUsersRead), adminHandler.GetAllUsers)
+		adminRoutes.PUT("/users/:id/role", require(models.PermissionUsersWrite, models.PermissionRolesWrite), adminHandler.UpdateUserRole)
+		adminRoutes.DELETE("/users/:id", require(models.PermissionUsersWrite), adminHandler.DeleteUser)
+		adminRoutes.DELETE("/users", require(models.PermissionUsersWrite), adminHandler.DeleteAllUsers)
+		adminRoutes.POST("/users/:id/unlock", require(models.PermissionUsersWrite), adminHandler.UnlockUser)
+		adminRoutes.GET("/security-events", require(models.PermissionSecurityEventsRead), adminHandler.GetSecurityEvents)
+		adminRoutes.GET("/ledger/reconcile", require(models.PermissionLedgerRead), adminHandler.ReconcileLedger)
+		adminRoutes.POST("/transactions/:transactionID/reverse", require(models.PermissionTransactionsReverse), walletHandler.ReverseTransaction)
+		adminRoutes.POST("/wallets/:walletID/adjust", require(models.PermissionWalletsAdjust), walletHandler.AdjustBalance)
+
+		adminRoutes.GET("/roles", require(models.PermissionRolesRead), roleHandler.ListRoles)
+		adminRoutes.POST("/roles", require(models.PermissionRolesWrite), roleHandler.CreateRole)
+		adminRoutes.GET("/roles/:name", require(models.PermissionRolesRead), roleHandler.GetRole)
+		adminRoutes.PUT("/roles/:name", require(models.PermissionRolesWrite), roleHandler.UpdateRole)
+		adminRoutes.DELETE("/roles/:name", require(models.PermissionRolesWrite), roleHandler.DeleteRole)
+		adminRoutes.GET("/permissions", require(models.PermissionRolesRead), roleHandler.ListPermissions)
+
+		adminRoutes.GET("/oauth/clients", require(models.PermissionOAuthClientsRead), oauthHandler.ListClients)
+		adminRoutes.POST("/oauth/clients", require(models.PermissionOAuthClientsWrite), oauthHandler.CreateClient)
+		adminRoutes.DELETE("/oauth/clients/:clientID", require(models.PermissionOAuthClientsWrite), oauthHandler.RevokeClient)
+
+		adminRoutes.GET("/health", require(models.PermissionHealthRead), healthHandler.AdminHealth)
 	}
 }
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"wallet-service/internal/api/handlers"
+	"wallet-service/internal/api/middleware"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAPIKeyRoutes configures the API key management routes. They can only be
+// used from a user's session, so that an API key cannot mint or revoke keys.
+func SetupAPIKeyRoutes(router *gin.Engine, apiKeyHandler *handlers.APIKeyHandler, authService *service.AuthService) {
+	apiKeyRoutes := router.Group("/api/api-keys")
+	apiKeyRoutes.Use(middleware.AuthMiddleware(authService), middleware.UserSessionMiddleware())
+	{
+		apiKeyRoutes.GET("", apiKeyHandler.ListAPIKeys)
+		apiKeyRoutes.POST("", apiKeyHandler.CreateAPIKey)
+		apiKeyRoutes.DELETE("/:keyID", apiKeyHandler.RevokeAPIKey)
+	}
+}
@@ -2,16 +2,41 @@ package routes
 
 import (
 	"wallet-service/internal/api/handlers"
+	"wallet-service/internal/api/middleware"
+	"wallet-service/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupAuthRoutes configures the authentication routes.
-func SetupAuthRoutes(router *gin.Engine, authHandler *handlers.AuthHandler) {
+func SetupAuthRoutes(router *gin.Engine, authHandler *handlers.AuthHandler, twoFactorHandler *handlers.TwoFactorHandler, accountHandler *handlers.AccountHandler, oidcHandler *handlers.OIDCHandler, authService *service.AuthService) {
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
 	authGroup := router.Group("/api/auth")
 	{
 		authGroup.POST("/register", authHandler.Register)
 		authGroup.POST("/login", authHandler.Login)
 		authGroup.POST("/refresh", authHandler.RefreshToken)
+		authGroup.POST("/logout", authHandler.Logout)
+		authGroup.POST("/logout-all", middleware.AuthMiddleware(authService), middleware.UserSessionMiddleware(), authHandler.LogoutAll)
+		authGroup.POST("/forgot-password", accountHandler.ForgotPassword)
+		authGroup.POST("/reset-password", accountHandler.ResetPassword)
+		authGroup.POST("/verify-email", accountHandler.VerifyEmail)
+		authGroup.POST("/resend-verification", middleware.AuthMiddleware(authService), accountHandler.ResendVerification)
+		authGroup.GET("/oidc/authorize", oidcHandler.Authorize)
+		authGroup.POST("/oidc/callback", oidcHandler.Callback)
+	}
+
+	twoFactorGroup := router.Group("/api/auth/2fa")
+	{
+		twoFactorGroup.POST("/verify", twoFactorHandler.Verify)
+
+		authenticated := twoFactorGroup.Group("")
+		authenticated.Use(middleware.AuthMiddleware(authService), middleware.UserSessionMiddleware())
+		authenticated.POST("/enroll", twoFactorHandler.Enroll)
+		authenticated.POST("/confirm", twoFactorHandler.Confirm)
+		authenticated.POST("/disable", twoFactorHandler.Disable)
+		authenticated.POST("/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+		authenticated.POST("/step-up", twoFactorHandler.StepUp)
 	}
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"wallet-service/internal/api/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupHealthRoutes configures the unauthenticated liveness and readiness probes.
+// The detailed report is an admin route.
+func SetupHealthRoutes(router *gin.Engine, healthHandler *handlers.HealthHandler) {
+	router.GET("/healthz", healthHandler.Healthz)
+	router.GET("/readyz", healthHandler.Readyz)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"wallet-service/internal/api/handlers"
+	"wallet-service/internal/api/middleware"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupOAuthRoutes configures the OAuth2 authorization server routes. Clients
+// authenticate to the /oauth endpoints themselves; approving a client requires
+// the user's own session, so that one client cannot authorize another.
+func SetupOAuthRoutes(router *gin.Engine, oauthHandler *handlers.OAuthHandler, authService *service.AuthService) {
+	oauthGroup := router.Group("/oauth")
+	{
+		oauthGroup.POST("/token", oauthHandler.Token)
+		oauthGroup.POST("/introspect", oauthHandler.Introspect)
+		oauthGroup.POST("/revoke", oauthHandler.Revoke)
+	}
+
+	router.POST("/api/oauth/authorize", middleware.AuthMiddleware(authService), middleware.UserSessionMiddleware(), oauthHandler.Authorize)
+}
@@ -3,16 +3,23 @@ package routes
 import (
 	"wallet-service/internal/api/handlers"
 	"wallet-service/internal/api/middleware"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
 	"wallet-service/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupTransactionRoutes configures the transaction-related routes.
-func SetupTransactionRoutes(router *gin.Engine, transactionHandler *handlers.TransactionHandler, authService *service.AuthService) {
+// Transaction history is visible to the wallet's owner and to users with the wallets:read permission.
+// OAuth access tokens need the wallet:read scope.
+func SetupTransactionRoutes(router *gin.Engine, transactionHandler *handlers.TransactionHandler, authService *service.AuthService, walletRepo repository.WalletStore) {
+	walletAccess := middleware.WalletAccessMiddleware(walletRepo)
+	canRead := middleware.RequireScope(models.ScopeWalletRead)
+
 	transactionRoutes := router.Group("/api")
 	transactionRoutes.Use(middleware.AuthMiddleware(authService))
 	{
-		transactionRoutes.GET("/wallets/:walletID/transactions", transactionHandler.GetTransactions)
+		transactionRoutes.GET("/wallets/:walletID/transactions", canRead, walletAccess, transactionHandler.GetTransactions)
 	}
 }
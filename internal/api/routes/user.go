@@ -3,19 +3,24 @@ package routes
 import (
 	"wallet-service/internal/api/handlers"
 	"wallet-service/internal/api/middleware"
+	"wallet-service/internal/models"
 	"wallet-service/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SetupUserRoutes configures the user-related routes.
+// SetupUserRoutes configures the user-related routes. They expose other users'
+// accounts, so they require the users:read and users:write permissions.
 func SetupUserRoutes(router *gin.Engine, userHandler *handlers.UserHandler, authService *service.AuthService) {
+	canRead := middleware.RequirePermission(models.PermissionUsersRead)
+	canWrite := middleware.RequirePermission(models.PermissionUsersWrite)
+
 	userRoutes := router.Group("/api/users")
 	userRoutes.Use(middleware.AuthMiddleware(authService))
 	{
-		userRoutes.GET("", userHandler.GetUsers)
-		userRoutes.GET("/:id", userHandler.GetUserByID)
-		userRoutes.GET("/email/:email", userHandler.GetUserByEmail)
-		userRoutes.POST("", userHandler.CreateUser)
+		userRoutes.GET("", canRead, userHandler.GetUsers)
+		userRoutes.GET("/:id", canRead, userHandler.GetUserByID)
+		userRoutes.GET("/email/:email", canRead, userHandler.GetUserByEmail)
+		userRoutes.POST("", canWrite, userHandler.CreateUser)
 	}
 }
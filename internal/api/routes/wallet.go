@@ -3,17 +3,55 @@ package routes
 import (
 	"wallet-service/internal/api/handlers"
 	"wallet-service/internal/api/middleware"
+	"wallet-service/internal/config"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
 	"wallet-service/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupWalletRoutes configures the wallet-related routes.
-func SetupWalletRoutes(router *gin.Engine, walletHandler *handlers.WalletHandler, authService *service.AuthService) {
+// Routes under /api/wallet act on the user's default wallet; routes under
+// /api/wallets are scoped to a wallet ID. Users with the wallets:read permission
+// may read any wallet, but only its owner may change it or move its funds.
+// Opening, renaming and choosing the default wallet require the wallets:write
+// permission, funding and withdrawing wallets:fund, and transfers
+// wallets:transfer; API keys only pass if they were granted them. Creating wallets and moving funds
+// require a verified email address, and moving funds demands a recent second
+// factor from users with two-factor authentication enabled. OAuth access tokens
+// need the wallet:read scope to read wallets and wallet:write to change them.
+func SetupWalletRoutes(router *gin.Engine, walletHandler *handlers.WalletHandler, authService *service.AuthService, idempotencyService *service.IdempotencyService, walletRepo repository.WalletStore, userRepo repository.UserStore, cfg *config.Config) {
+	idempotent := middleware.IdempotencyMiddleware(idempotencyService)
+	stepUp := middleware.StepUpMiddleware(cfg.MFA.StepUpMaxAge)
+	verified := middleware.VerifiedEmailMiddleware(userRepo)
+	walletAccess := middleware.WalletAccessMiddleware(walletRepo)
+	walletOwner := middleware.WalletOwnerMiddleware(walletRepo)
+	canRead := middleware.RequireScope(models.ScopeWalletRead)
+	canWrite := middleware.RequireScope(models.ScopeWalletWrite)
+	canManage := middleware.RequirePermission(models.PermissionWalletsWrite)
+	canFund := middleware.RequirePermission(models.PermissionWalletsFund)
+	canTransfer := middleware.RequirePermission(models.PermissionWalletsTransfer)
+
 	walletRoutes := router.Group("/api/wallet")
 	walletRoutes.Use(middleware.AuthMiddleware(authService))
 	{
-		walletRoutes.GET("", walletHandler.GetWallet)
-		walletRoutes.POST("/fund", walletHandler.FundWallet)
+		walletRoutes.GET("", canRead, walletHandler.GetWallet)
+		walletRoutes.POST("/fund", canWrite, canFund, verified, stepUp, idempotent, walletHandler.FundWallet)
+		walletRoutes.POST("/withdraw", canWrite, canFund, verified, stepUp, idempotent, walletHandler.WithdrawWallet)
+		walletRoutes.POST("/transfer", canWrite, canTransfer, verified, stepUp, idempotent, walletHandler.TransferFunds)
+	}
+
+	walletsRoutes := router.Group("/api/wallets")
+	walletsRoutes.Use(middleware.AuthMiddleware(authService))
+	{
+		walletsRoutes.GET("", canRead, walletHandler.ListWallets)
+		walletsRoutes.POST("", canWrite, canManage, verified, walletHandler.CreateWallet)
+		walletsRoutes.GET("/:walletID", canRead, walletAccess, walletHandler.GetWalletByID)
+		walletsRoutes.PATCH("/:walletID", canWrite, canManage, walletOwner, walletHandler.RenameWallet)
+		walletsRoutes.POST("/:walletID/default", canWrite, canManage, walletOwner, walletHandler.SetDefaultWallet)
+		walletsRoutes.POST("/:walletID/fund", canWrite, canFund, walletOwner, verified, stepUp, idempotent, walletHandler.FundWalletByID)
+		walletsRoutes.POST("/:walletID/withdraw", canWrite, canFund, walletOwner, verified, stepUp, idempotent, walletHandler.WithdrawWalletByID)
+		walletsRoutes.POST("/:walletID/transfer", canWrite, canTransfer, walletOwner, verified, stepUp, idempotent, walletHandler.TransferFundsByID)
 	}
 }
@@ -3,13 +3,155 @@ package config
 import "time"
 
 type Config struct {
-	DatabaseURL string `mapstructure:"DATABASE_URL"`
-	Port        string `mapstructure:"PORT"`
-	JWT         JWTConfig `mapstructure:"jwt"`
+	DatabaseURL string            `mapstructure:"DATABASE_URL"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	Port        string            `mapstructure:"PORT"`
+	Server      ServerConfig      `mapstructure:"server"`
+	JWT         JWTConfig         `mapstructure:"jwt"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	MFA         MFAConfig         `mapstructure:"mfa"`
+	Account     AccountConfig     `mapstructure:"account"`
+	Mail        MailConfig        `mapstructure:"mail"`
+	Login       LoginConfig       `mapstructure:"login"`
+	OAuth       OAuthConfig       `mapstructure:"oauth"`
+	OIDC        OIDCConfig        `mapstructure:"oidc"`
+}
+
+type ServerConfig struct {
+	// Mode is the Gin mode: "release", "debug" or "test".
+	Mode string `mapstructure:"mode"`
+	// ReadTimeout bounds reading a whole request, body included.
+	ReadTimeout time.Duration `mapstructure:"read_timeout"`
+	// WriteTimeout bounds handling a request and writing its response.
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	// IdleTimeout is how long a keep-alive connection may wait for its next request.
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+	// MaxHeaderBytes limits the size of request headers.
+	MaxHeaderBytes int `mapstructure:"max_header_bytes"`
+	// ShutdownTimeout is how long in-flight requests may take to finish after
+	// SIGTERM or SIGINT before the server closes their connections.
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// ShutdownDelay is how long the server keeps serving after SIGTERM or SIGINT
+	// while /readyz reports it unavailable, so that load balancers stop routing
+	// to it before it stops accepting connections.
+	ShutdownDelay time.Duration `mapstructure:"shutdown_delay"`
+	// TrustedProxies lists the IP addresses and CIDR ranges of reverse proxies
+	// whose X-Forwarded-For and X-Real-IP headers are believed when working out
+	// a client's IP address. When empty, the connection's remote address is used.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	// RequestTimeout bounds the database work of a single API request; queries
+	// still running when it elapses are cancelled. Zero disables the limit.
+	RequestTimeout time.Duration `mapstructure:"request_timeout"`
 }
 
 type JWTConfig struct {
-	Secret            string        `mapstructure:"secret"`
 	Expiration        time.Duration `mapstructure:"expiration"`
 	RefreshExpiration time.Duration `mapstructure:"refresh_expiration"`
+
+	// Algorithm is the algorithm of newly generated signing keys: RS256 or EdDSA.
+	Algorithm string `mapstructure:"algorithm"`
+	// KeysDir holds the signing and verification keys as <kid>.pem files.
+	KeysDir string `mapstructure:"keys_dir"`
+	// KeyRotationInterval is how old the signing key may get before a new one is
+	// generated. Zero disables generation, leaving key management to operators.
+	KeyRotationInterval time.Duration `mapstructure:"key_rotation_interval"`
+	// TokenVersionCacheTTL is how long a user's token version is cached. Access
+	// tokens revoked on another instance are accepted here for at most this long.
+	TokenVersionCacheTTL time.Duration `mapstructure:"token_version_cache_ttl"`
+}
+
+type IdempotencyConfig struct {
+	// TTL is how long a stored Idempotency-Key response is replayed before the key can be reused.
+	TTL time.Duration `mapstructure:"ttl"`
+	// Lease is how long a reserved key stays in flight without being renewed.
+	// The request holding it renews it while it runs; once it lapses without a
+	// response, retries are refused because the outcome is unknown. It must be
+	// longer than database.request_timeout.
+	Lease time.Duration `mapstructure:"lease"`
+}
+
+type MFAConfig struct {
+	// Issuer is the account issuer shown in authenticator apps.
+	Issuer string `mapstructure:"issuer"`
+	// ChallengeTTL is how long the MFA token returned by a two-factor login stays valid.
+	ChallengeTTL time.Duration `mapstructure:"challenge_ttl"`
+	// StepUpMaxAge is how recently a user with two-factor enabled must have
+	// presented a second factor to call money-moving endpoints.
+	StepUpMaxAge time.Duration `mapstructure:"step_up_max_age"`
+	// EncryptionKey is the base64-encoded 32-byte AES key that encrypts TOTP
+	// secrets in the database.
+	EncryptionKey string `mapstructure:"encryption_key"`
+}
+
+type AccountConfig struct {
+	// LinkBaseURL is the front-end address that emailed password reset and
+	// verification links point at; the token is appended as a query parameter.
+	LinkBaseURL string `mapstructure:"link_base_url"`
+	// PasswordResetTTL is how long a password reset link stays valid.
+	PasswordResetTTL time.Duration `mapstructure:"password_reset_ttl"`
+	// EmailVerificationTTL is how long an email verification link stays valid.
+	EmailVerificationTTL time.Duration `mapstructure:"email_verification_ttl"`
+}
+
+type MailConfig struct {
+	// Driver selects how mail is delivered: "smtp", or "file" for local development.
+	Driver string `mapstructure:"driver"`
+	// From is the sender address of outgoing mail.
+	From string `mapstructure:"from"`
+	// Dir is where the file driver writes messages. When empty, messages are logged instead.
+	Dir  string     `mapstructure:"dir"`
+	SMTP SMTPConfig `mapstructure:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+type LoginConfig struct {
+	// Store selects where failed login counters are kept: "database", shared by
+	// every instance, or "memory", for tests and single-instance deployments.
+	Store string `mapstructure:"store"`
+	// MaxAccountFailures is how many failed logins for one email lock that account.
+	MaxAccountFailures int `mapstructure:"max_account_failures"`
+	// MaxIPFailures is how many failed logins from one IP address lock out that address.
+	MaxIPFailures int `mapstructure:"max_ip_failures"`
+	// MaxTwoFactorFailures is how many wrong two-factor codes lock a user's
+	// second factor, for logins, step-up and two-factor settings alike.
+	MaxTwoFactorFailures int `mapstructure:"max_two_factor_failures"`
+	// FailureWindow is how long a failed login keeps counting towards a lockout.
+	FailureWindow time.Duration `mapstructure:"failure_window"`
+	// LockoutDuration is how long a lockout lasts.
+	LockoutDuration time.Duration `mapstructure:"lockout_duration"`
+	// BaseDelay is the wait enforced after the first failure; it doubles with each
+	// further failure, up to MaxDelay.
+	BaseDelay time.Duration `mapstructure:"base_delay"`
+	MaxDelay  time.Duration `mapstructure:"max_delay"`
+}
+
+type OAuthConfig struct {
+	// AccessTokenTTL is how long an access token issued by the OAuth2 token endpoint stays valid.
+	AccessTokenTTL time.Duration `mapstructure:"access_token_ttl"`
+	// CodeTTL is how long an authorization code may wait to be exchanged for a token.
+	CodeTTL time.Duration `mapstructure:"code_ttl"`
+}
+
+type OIDCConfig struct {
+	// DiscoveryURL is the external OpenID Connect provider's configuration document,
+	// usually <issuer>/.well-known/openid-configuration. Login through the provider
+	// is disabled when it is empty.
+	DiscoveryURL string `mapstructure:"discovery_url"`
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	// RedirectURL is the front-end page the provider returns the user to; it posts
+	// the code and state it receives to /api/auth/oidc/callback.
+	RedirectURL string   `mapstructure:"redirect_url"`
+	Scopes      []string `mapstructure:"scopes"`
+	// StateTTL is how long a user has to complete a login at the provider.
+	StateTTL time.Duration `mapstructure:"state_ttl"`
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -16,6 +17,48 @@ func LoadConfig() error {
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath("./config")
 	viper.AutomaticEnv()
+	// Map nested keys to environment variables, e.g. login.max_delay to LOGIN_MAX_DELAY
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
+	viper.SetDefault("port", "8080")
+	viper.SetDefault("server.mode", "release")
+	viper.SetDefault("server.read_timeout", "15s")
+	viper.SetDefault("server.write_timeout", "30s")
+	viper.SetDefault("server.idle_timeout", "60s")
+	viper.SetDefault("server.max_header_bytes", 1<<20)
+	viper.SetDefault("server.shutdown_timeout", "30s")
+	viper.SetDefault("server.shutdown_delay", "5s")
+	viper.SetDefault("server.trusted_proxies", []string{})
+	viper.SetDefault("database.request_timeout", "10s")
+	viper.SetDefault("idempotency.ttl", "24h")
+	viper.SetDefault("idempotency.lease", "1m")
+	viper.SetDefault("jwt.expiration", "24h")
+	viper.SetDefault("jwt.refresh_expiration", "168h")
+	viper.SetDefault("jwt.algorithm", "RS256")
+	viper.SetDefault("jwt.keys_dir", "./keys")
+	viper.SetDefault("jwt.key_rotation_interval", "720h")
+	viper.SetDefault("jwt.token_version_cache_ttl", "30s")
+	viper.SetDefault("mfa.issuer", "Wallet Service")
+	viper.SetDefault("mfa.challenge_ttl", "5m")
+	viper.SetDefault("mfa.step_up_max_age", "10m")
+	viper.SetDefault("account.link_base_url", "http://localhost:8080")
+	viper.SetDefault("account.password_reset_ttl", "1h")
+	viper.SetDefault("account.email_verification_ttl", "48h")
+	viper.SetDefault("mail.driver", "file")
+	viper.SetDefault("mail.from", "Wallet Service <no-reply@wallet-service.local>")
+	viper.SetDefault("mail.smtp.port", 587)
+	viper.SetDefault("login.store", "database")
+	viper.SetDefault("login.max_account_failures", 5)
+	viper.SetDefault("login.max_ip_failures", 20)
+	viper.SetDefault("login.max_two_factor_failures", 5)
+	viper.SetDefault("login.failure_window", "15m")
+	viper.SetDefault("login.lockout_duration", "15m")
+	viper.SetDefault("login.base_delay", "1s")
+	viper.SetDefault("login.max_delay", "30s")
+	viper.SetDefault("oauth.access_token_ttl", "1h")
+	viper.SetDefault("oauth.code_ttl", "10m")
+	viper.SetDefault("oidc.scopes", []string{"openid", "email", "profile"})
+	viper.SetDefault("oidc.state_ttl", "10m")
 
 	if err := viper.ReadInConfig(); err != nil {
 		fmt.Println("No config.yaml found, relying on .env or system env")
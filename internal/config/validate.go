@@ -0,0 +1,77 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Validate reports every setting that would stop the service from working
+// correctly, joined into a single error. It returns nil for a usable configuration.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	port, err := strconv.Atoi(c.Port)
+	check(err == nil && port > 0 && port < 65536, "PORT %q is not a valid port", c.Port)
+	check(c.Server.Mode == "release" || c.Server.Mode == "debug" || c.Server.Mode == "test",
+		"server.mode %q must be release, debug or test", c.Server.Mode)
+	check(c.Server.ReadTimeout >= 0 && c.Server.WriteTimeout >= 0 && c.Server.IdleTimeout >= 0,
+		"server timeouts must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay must not be negative")
+	check(c.Database.RequestTimeout >= 0, "database.request_timeout must not be negative")
+
+	check(c.JWT.Expiration > 0, "jwt.expiration must be positive")
+	check(c.JWT.RefreshExpiration > 0, "jwt.refresh_expiration must be positive")
+	check(c.JWT.Algorithm == "RS256" || c.JWT.Algorithm == "EdDSA", "jwt.algorithm %q must be RS256 or EdDSA", c.JWT.Algorithm)
+	check(c.Idempotency.TTL > 0, "idempotency.ttl must be positive")
+	check(c.Idempotency.Lease > 0, "idempotency.lease must be positive")
+	check(c.Database.RequestTimeout == 0 || c.Idempotency.Lease > c.Database.RequestTimeout,
+		"idempotency.lease %s must be longer than database.request_timeout %s", c.Idempotency.Lease, c.Database.RequestTimeout)
+	check(c.MFA.ChallengeTTL > 0, "mfa.challenge_ttl must be positive")
+	if _, err := c.MFA.SecretKey(); err != nil {
+		errs = append(errs, err)
+	}
+	check(c.Account.LinkBaseURL != "", "account.link_base_url is not set")
+
+	switch c.Mail.Driver {
+	case "smtp":
+		check(c.Mail.SMTP.Host != "", "mail.smtp.host is not set")
+	case "file":
+	default:
+		check(false, "mail.driver %q must be smtp or file", c.Mail.Driver)
+	}
+	check(c.Login.MaxTwoFactorFailures > 0, "login.max_two_factor_failures must be positive")
+	check(c.Login.Store == "database" || c.Login.Store == "memory", "login.store %q must be database or memory", c.Login.Store)
+	check(c.OAuth.AccessTokenTTL > 0 && c.OAuth.CodeTTL > 0, "oauth.access_token_ttl and oauth.code_ttl must be positive")
+	if c.OIDC.DiscoveryURL != "" {
+		check(c.OIDC.ClientID != "" && c.OIDC.RedirectURL != "", "oidc.client_id and oidc.redirect_url are required when oidc.discovery_url is set")
+	}
+
+	return errors.Join(errs...)
+}
+
+// TokenRetention is the longest lifetime of a token signed with the JWT keys. A
+// replaced signing key must keep verifying tokens for this long.
+func (c *Config) TokenRetention() time.Duration {
+	return max(c.JWT.Expiration, c.JWT.RefreshExpiration, c.MFA.ChallengeTTL, c.OAuth.AccessTokenTTL)
+}
+
+// SecretKey decodes EncryptionKey, the AES-256 key that encrypts TOTP secrets.
+func (c MFAConfig) SecretKey() ([]byte, error) {
+	if c.EncryptionKey == "" {
+		return nil, errors.New("mfa.encryption_key is not set")
+	}
+	key, err := base64.StdEncoding.DecodeString(c.EncryptionKey)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("mfa.encryption_key must be 32 bytes, base64-encoded")
+	}
+	return key, nil
+}
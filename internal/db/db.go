@@ -7,13 +7,12 @@ import (
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-
-	"wallet-service/internal/models"
 )
 
 var DB *gorm.DB
 
-// InitDB initializes the database connection and runs migrations
+// InitDB initializes the database connection. It does not change the schema;
+// run "wallet-service migrate up" to apply migrations.
 func InitDB() {
 	// Load .env first (if exists)
 	if err := godotenv.Load(); err != nil {
@@ -34,10 +33,13 @@ func InitDB() {
 
 	DB = db
 	log.Println("✅ Database connected successfully")
+}
 
-	// Auto-migrate your models
-	if err := DB.AutoMigrate(&models.User{}, &models.Wallet{}, &models.Transaction{}); err != nil {
-		log.Fatalf("❌ Failed to migrate tables: %v", err)
+// Close closes the database connection pool.
+func Close() error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
 	}
-	log.Println("✅ Database tables migrated successfully")
+	return sqlDB.Close()
 }
@@ -0,0 +1,67 @@
+// Package dbtest connects tests to the PostgreSQL database named by the
+// TEST_DATABASE_URL environment variable. Tests that need a database are
+// skipped when it is not set.
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+	"wallet-service/internal/db"
+	"wallet-service/internal/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// EnvVar is the environment variable holding the test database's connection string.
+const EnvVar = "TEST_DATABASE_URL"
+
+var userSeq atomic.Int64
+
+// Open connects to the test database, applies every pending migration and
+// closes the connection when the test ends. Tests share the database, so they
+// must create the rows they use rather than expect an empty schema.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(EnvVar)
+	if dsn == "" {
+		t.Skipf("%s is not set", EnvVar)
+	}
+
+	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := db.NewMigrator(gormDB)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return gormDB
+}
+
+// CreateUser creates a user with an email address no other test uses.
+func CreateUser(t testing.TB, gormDB *gorm.DB) *models.User {
+	t.Helper()
+	user := &models.User{
+		Name:  "Test User",
+		Email: fmt.Sprintf("dbtest-%d-%d@example.com", time.Now().UnixNano(), userSeq.Add(1)),
+	}
+	if err := gormDB.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
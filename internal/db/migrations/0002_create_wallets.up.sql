@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS wallets (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TEXT,
+    updated_at TEXT,
+    deleted_at TEXT,
+    user_id BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    balance DOUBLE PRECISION DEFAULT 0,
+    currency TEXT DEFAULT 'USD',
+    is_active BOOLEAN DEFAULT true
+);
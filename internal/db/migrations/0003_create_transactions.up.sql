@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS transactions (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TEXT,
+    updated_at TEXT,
+    deleted_at TEXT,
+    wallet_id BIGINT NOT NULL,
+    type TEXT NOT NULL,
+    amount DOUBLE PRECISION NOT NULL,
+    description TEXT,
+    status TEXT DEFAULT 'pending',
+    reference TEXT CONSTRAINT uni_transactions_reference UNIQUE,
+    balance_before DOUBLE PRECISION,
+    balance_after DOUBLE PRECISION
+);
+
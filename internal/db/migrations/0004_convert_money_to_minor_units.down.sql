@@ -0,0 +1,22 @@
+CREATE FUNCTION pg_temp.currency_exponent(currency TEXT) RETURNS INTEGER
+LANGUAGE SQL IMMUTABLE AS $fn$
+    SELECT CASE
+        WHEN UPPER(currency) IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW',
+                                 'PYG', 'RWF', 'UGX', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 0
+        WHEN UPPER(currency) IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 3
+        ELSE 2
+    END
+$fn$;
+
+ALTER TABLE wallets
+    ALTER COLUMN balance DROP NOT NULL,
+    ALTER COLUMN balance DROP DEFAULT,
+    ALTER COLUMN balance TYPE DOUBLE PRECISION USING balance / POWER(10::numeric, pg_temp.currency_exponent(currency)),
+    ALTER COLUMN balance SET DEFAULT 0;
+
+ALTER TABLE transactions
+    ALTER COLUMN amount TYPE DOUBLE PRECISION USING amount / POWER(10::numeric, pg_temp.currency_exponent(currency)),
+    ALTER COLUMN balance_before TYPE DOUBLE PRECISION USING balance_before / POWER(10::numeric, pg_temp.currency_exponent(currency)),
+    ALTER COLUMN balance_after TYPE DOUBLE PRECISION USING balance_after / POWER(10::numeric, pg_temp.currency_exponent(currency));
+
+ALTER TABLE transactions DROP COLUMN currency;
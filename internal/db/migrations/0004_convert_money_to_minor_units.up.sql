@@ -0,0 +1,45 @@
+-- Store balances and amounts as BIGINT minor units instead of floating point,
+-- scaling every row by the exponent of its currency.
+
+CREATE FUNCTION pg_temp.currency_exponent(currency TEXT) RETURNS INTEGER
+LANGUAGE SQL IMMUTABLE AS $fn$
+    SELECT CASE
+        WHEN UPPER(currency) IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW',
+                                 'PYG', 'RWF', 'UGX', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 0
+        WHEN UPPER(currency) IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 3
+        ELSE 2
+    END
+$fn$;
+
+-- Transactions had no currency of their own; inherit the wallet's.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency TEXT;
+UPDATE transactions t SET currency = w.currency FROM wallets w WHERE w.id = t.wallet_id AND t.currency IS NULL;
+UPDATE transactions SET currency = 'USD' WHERE currency IS NULL;
+ALTER TABLE transactions ALTER COLUMN currency SET DEFAULT 'USD', ALTER COLUMN currency SET NOT NULL;
+
+-- Only columns that are still floating point are converted, so databases that
+-- were already converted are left untouched.
+DO $$
+DECLARE
+    col RECORD;
+BEGIN
+    FOR col IN
+        SELECT table_name, column_name FROM information_schema.columns
+        WHERE table_schema = CURRENT_SCHEMA()
+          AND (table_name, column_name) IN (
+              ('wallets', 'balance'),
+              ('transactions', 'amount'),
+              ('transactions', 'balance_before'),
+              ('transactions', 'balance_after'))
+          AND data_type IN ('double precision', 'real', 'numeric')
+    LOOP
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN %I DROP DEFAULT', col.table_name, col.column_name);
+        EXECUTE format(
+            'ALTER TABLE %I ALTER COLUMN %I TYPE BIGINT USING ROUND(%I::numeric * POWER(10::numeric, pg_temp.currency_exponent(currency)))',
+            col.table_name, col.column_name, col.column_name);
+    END LOOP;
+END
+$$;
+
+UPDATE wallets SET balance = 0 WHERE balance IS NULL;
+ALTER TABLE wallets ALTER COLUMN balance SET DEFAULT 0, ALTER COLUMN balance SET NOT NULL;
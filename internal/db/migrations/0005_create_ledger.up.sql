@@ -0,0 +1,65 @@
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id BIGSERIAL PRIMARY KEY,
+    code TEXT NOT NULL,
+    type TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    wallet_id BIGINT,
+    created_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_code ON ledger_accounts (code);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_accounts_wallet_id ON ledger_accounts (wallet_id);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id BIGSERIAL PRIMARY KEY,
+    reference TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    description TEXT,
+    created_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_journal_entries_reference ON journal_entries (reference);
+
+CREATE TABLE IF NOT EXISTS postings (
+    id BIGSERIAL PRIMARY KEY,
+    journal_entry_id BIGINT NOT NULL CONSTRAINT fk_journal_entries_postings REFERENCES journal_entries (id),
+    account_id BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    currency TEXT NOT NULL,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_postings_journal_entry_id ON postings (journal_entry_id);
+CREATE INDEX IF NOT EXISTS idx_postings_account_id ON postings (account_id);
+
+-- Transactions link to the journal entry that moved the money.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS journal_entry_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_transactions_journal_entry_id ON transactions (journal_entry_id);
+
+-- Open a ledger account for every wallet that predates the ledger. Wallets that
+-- already hold a balance receive an opening-balance entry against a per-currency
+-- equity account, so the ledger reconciles from day one.
+CREATE TEMPORARY TABLE unledgered_wallets ON COMMIT DROP AS
+    SELECT id, currency, balance FROM wallets
+    WHERE id NOT IN (SELECT wallet_id FROM ledger_accounts WHERE wallet_id IS NOT NULL);
+
+INSERT INTO ledger_accounts (code, type, currency, wallet_id, created_at)
+SELECT 'wallet:' || id, 'liability', currency, id, NOW() FROM unledgered_wallets;
+
+INSERT INTO ledger_accounts (code, type, currency, created_at)
+SELECT DISTINCT ON (UPPER(currency)) 'opening-balance:' || UPPER(currency), 'equity', currency, NOW()
+FROM unledgered_wallets WHERE balance <> 0
+ON CONFLICT (code) DO NOTHING;
+
+WITH opened AS (
+    INSERT INTO journal_entries (reference, kind, description, created_at)
+    SELECT 'OPEN-WALLET-' || id, 'opening_balance', 'Opening balance', NOW()
+    FROM unledgered_wallets WHERE balance <> 0
+    RETURNING id, reference
+)
+INSERT INTO postings (journal_entry_id, account_id, amount, currency, created_at)
+SELECT o.id, a.id, leg.amount, w.currency, NOW()
+FROM opened o
+JOIN unledgered_wallets w ON o.reference = 'OPEN-WALLET-' || w.id
+CROSS JOIN LATERAL (VALUES
+    ('opening-balance:' || UPPER(w.currency), w.balance),
+    ('wallet:' || w.id, -w.balance)
+) AS leg (code, amount)
+JOIN ledger_accounts a ON a.code = leg.code;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_wallets_default_per_user;
+ALTER TABLE wallets DROP COLUMN IF EXISTS is_default;
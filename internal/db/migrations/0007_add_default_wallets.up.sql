@@ -0,0 +1,12 @@
+-- Users may hold several wallets; exactly one of them is their default.
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS is_default BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE wallets SET is_default = TRUE
+WHERE id IN (
+    SELECT MIN(id) FROM wallets
+    WHERE deleted_at IS NULL
+    GROUP BY user_id
+    HAVING NOT BOOL_OR(is_default)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallets_default_per_user ON wallets (user_id) WHERE is_default;
@@ -0,0 +1,22 @@
+-- Turn the text timestamp columns of transactions into real timestamps so
+-- history can be sorted and paged.
+DO $$
+BEGIN
+    IF (SELECT data_type FROM information_schema.columns
+        WHERE table_schema = CURRENT_SCHEMA() AND table_name = 'transactions' AND column_name = 'created_at')
+       IN ('text', 'character varying') THEN
+        ALTER TABLE transactions
+            ALTER COLUMN created_at TYPE TIMESTAMPTZ USING NULLIF(created_at, '')::timestamptz,
+            ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING NULLIF(updated_at, '')::timestamptz,
+            ALTER COLUMN deleted_at TYPE TIMESTAMPTZ USING NULLIF(deleted_at, '')::timestamptz;
+    END IF;
+END
+$$;
+
+-- Earlier versions never filled these columns in, so blank creation times are
+-- recovered from the transaction's journal entry where one exists.
+UPDATE transactions t SET created_at = j.created_at
+FROM journal_entries j
+WHERE j.id = t.journal_entry_id AND t.created_at IS NULL;
+UPDATE transactions SET created_at = NOW() WHERE created_at IS NULL;
+UPDATE transactions SET updated_at = created_at WHERE updated_at IS NULL;
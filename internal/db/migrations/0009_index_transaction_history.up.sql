@@ -0,0 +1,2 @@
+-- Supports keyset pagination of a wallet's history ordered by (created_at, id).
+CREATE INDEX IF NOT EXISTS idx_transactions_wallet_created_id ON transactions (wallet_id, created_at, id);
@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS permissions (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    id BIGSERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    built_in BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name ON roles (name);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id BIGINT NOT NULL CONSTRAINT fk_role_permissions_role REFERENCES roles (id) ON DELETE CASCADE,
+    permission TEXT NOT NULL CONSTRAINT fk_role_permissions_permission REFERENCES permissions (name) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:read', 'View users'),
+    ('users:write', 'Create, delete and unlock users'),
+    ('roles:read', 'View roles and permissions'),
+    ('roles:write', 'Manage roles and assign them to users'),
+    ('wallets:read', 'View any user''s wallets and transaction history'),
+    ('ledger:read', 'Reconcile wallet balances against the ledger'),
+    ('security_events:read', 'View security events such as account lockouts')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name, description, built_in, created_at, updated_at) VALUES
+    ('admin', 'Full access', true, NOW(), NOW()),
+    ('user', 'Wallet owner with access to their own account only', true, NOW(), NOW()),
+    ('support_agent', 'Read-only access to users, wallets and security events for customer support', true, NOW(), NOW()),
+    ('auditor', 'Read-only access to users, wallets, roles, the ledger and security events', true, NOW(), NOW())
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT roles.id, permissions.name
+FROM roles CROSS JOIN permissions
+WHERE roles.name = 'admin'
+   OR (roles.name = 'support_agent' AND permissions.name IN ('users:read', 'wallets:read', 'security_events:read'))
+   OR (roles.name = 'auditor' AND permissions.name IN ('users:read', 'roles:read', 'wallets:read', 'ledger:read', 'security_events:read'))
+ON CONFLICT DO NOTHING;
+
+-- Every user must hold a known role; anything else falls back to the unprivileged one.
+UPDATE users SET role = 'user' WHERE role IS NULL OR role NOT IN (SELECT name FROM roles);
+ALTER TABLE users ALTER COLUMN role SET NOT NULL;
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_users_role') THEN
+        ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles (name);
+    END IF;
+END $$;
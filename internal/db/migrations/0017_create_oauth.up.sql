@@ -0,0 +1,50 @@
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    secret_hash TEXT NOT NULL DEFAULT '',
+    confidential BOOLEAN NOT NULL DEFAULT false,
+    redirect_uris TEXT NOT NULL DEFAULT '[]',
+    grant_types TEXT NOT NULL DEFAULT '[]',
+    scopes TEXT NOT NULL DEFAULT '[]',
+    user_id BIGINT,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+    code_hash TEXT PRIMARY KEY,
+    client_id TEXT NOT NULL,
+    user_id BIGINT NOT NULL,
+    redirect_uri TEXT NOT NULL,
+    scope TEXT,
+    code_challenge TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_oauth_authorization_codes_client_id ON oauth_authorization_codes (client_id);
+
+CREATE TABLE IF NOT EXISTS oauth_access_tokens (
+    id TEXT PRIMARY KEY,
+    client_id TEXT NOT NULL,
+    user_id BIGINT NOT NULL,
+    scope TEXT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_oauth_access_tokens_client_id ON oauth_access_tokens (client_id);
+CREATE INDEX IF NOT EXISTS idx_oauth_access_tokens_user_id ON oauth_access_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_oauth_access_tokens_expires_at ON oauth_access_tokens (expires_at);
+
+INSERT INTO permissions (name, description) VALUES
+    ('oauth_clients:read', 'View registered OAuth clients'),
+    ('oauth_clients:write', 'Register and revoke OAuth clients')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT roles.id, permissions.name
+FROM roles CROSS JOIN permissions
+WHERE permissions.name IN ('oauth_clients:read', 'oauth_clients:write')
+  AND (roles.name = 'admin' OR (roles.name = 'auditor' AND permissions.name = 'oauth_clients:read'))
+ON CONFLICT DO NOTHING;
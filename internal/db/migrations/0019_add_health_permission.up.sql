@@ -0,0 +1,10 @@
+INSERT INTO permissions (name, description) VALUES
+    ('health:read', 'View the detailed service health report')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT roles.id, permissions.name
+FROM roles CROSS JOIN permissions
+WHERE permissions.name = 'health:read'
+  AND roles.name IN ('admin', 'auditor')
+ON CONFLICT DO NOTHING;
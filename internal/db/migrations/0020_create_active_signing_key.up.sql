@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS active_signing_keys (
+    id BIGINT PRIMARY KEY CONSTRAINT chk_active_signing_keys_single_row CHECK (id = 1),
+    kid TEXT NOT NULL,
+    updated_at TIMESTAMPTZ
+);
@@ -0,0 +1 @@
+DELETE FROM permissions WHERE name IN ('wallets:adjust', 'transactions:reverse');
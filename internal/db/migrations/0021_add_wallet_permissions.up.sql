@@ -0,0 +1,15 @@
+INSERT INTO permissions (name, description) VALUES
+    ('wallets:adjust', 'Fund, withdraw from and transfer between own wallets'),
+    ('transactions:reverse', 'Reverse completed deposits, withdrawals and transfers')
+ON CONFLICT (name) DO NOTHING;
+
+-- Every existing role keeps moving its users' own funds; only admins reverse transactions.
+INSERT INTO role_permissions (role_id, permission)
+SELECT roles.id, permissions.name
+FROM roles CROSS JOIN permissions
+WHERE permissions.name = 'wallets:adjust'
+   OR (permissions.name = 'transactions:reverse' AND roles.name = 'admin')
+ON CONFLICT DO NOTHING;
+
+-- Access tokens issued earlier lack the new permission; revoke them so clients refresh.
+UPDATE users SET token_version = token_version + 1;
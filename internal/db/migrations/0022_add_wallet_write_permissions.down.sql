@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name IN ('wallets:write', 'wallets:transfer');
+
+UPDATE permissions SET description = 'Fund, withdraw from and transfer between own wallets' WHERE name = 'wallets:adjust';
@@ -0,0 +1,18 @@
+INSERT INTO permissions (name, description) VALUES
+    ('wallets:write', 'Open, rename and choose the default of own wallets'),
+    ('wallets:transfer', 'Transfer funds out of own wallets')
+ON CONFLICT (name) DO NOTHING;
+
+UPDATE permissions SET description = 'Fund and withdraw from own wallets' WHERE name = 'wallets:adjust';
+
+-- Roles that could move their users' funds keep doing so. API keys are not
+-- changed: they only gain the new permissions if they are created with them.
+INSERT INTO role_permissions (role_id, permission)
+SELECT role_permissions.role_id, permissions.name
+FROM role_permissions CROSS JOIN permissions
+WHERE role_permissions.permission = 'wallets:adjust'
+  AND permissions.name IN ('wallets:write', 'wallets:transfer')
+ON CONFLICT DO NOTHING;
+
+-- Access tokens issued earlier lack the new permissions; revoke them so clients refresh.
+UPDATE users SET token_version = token_version + 1;
@@ -0,0 +1,5 @@
+-- Transfer legs share a transfer reference and point at each other's wallet.
+-- Databases created by AutoMigrate may have these columns already.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS transfer_reference TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS counterparty_wallet_id BIGINT;
+CREATE INDEX IF NOT EXISTS idx_transactions_transfer_reference ON transactions (transfer_reference);
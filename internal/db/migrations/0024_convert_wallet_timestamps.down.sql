@@ -0,0 +1,4 @@
+ALTER TABLE wallets
+    ALTER COLUMN created_at TYPE TEXT USING created_at::text,
+    ALTER COLUMN updated_at TYPE TEXT USING updated_at::text,
+    ALTER COLUMN deleted_at TYPE TEXT USING deleted_at::text;
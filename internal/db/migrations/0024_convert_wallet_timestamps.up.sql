@@ -0,0 +1,22 @@
+-- Turn the text timestamp columns of wallets into real timestamps, as 0008 did
+-- for transactions.
+DO $$
+BEGIN
+    IF (SELECT data_type FROM information_schema.columns
+        WHERE table_schema = CURRENT_SCHEMA() AND table_name = 'wallets' AND column_name = 'created_at')
+       IN ('text', 'character varying') THEN
+        ALTER TABLE wallets
+            ALTER COLUMN created_at TYPE TIMESTAMPTZ USING NULLIF(created_at, '')::timestamptz,
+            ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING NULLIF(updated_at, '')::timestamptz,
+            ALTER COLUMN deleted_at TYPE TIMESTAMPTZ USING NULLIF(deleted_at, '')::timestamptz;
+    END IF;
+END
+$$;
+
+-- Blank creation times are recovered from the wallet's earliest transaction
+-- where it has one.
+UPDATE wallets w SET created_at = t.first_at
+FROM (SELECT wallet_id, MIN(created_at) AS first_at FROM transactions GROUP BY wallet_id) t
+WHERE t.wallet_id = w.id AND w.created_at IS NULL;
+UPDATE wallets SET created_at = NOW() WHERE created_at IS NULL;
+UPDATE wallets SET updated_at = created_at WHERE updated_at IS NULL;
@@ -0,0 +1,12 @@
+UPDATE api_keys SET permissions = REPLACE(permissions, '"wallets:fund"', '"wallets:adjust"')
+WHERE permissions LIKE '%"wallets:fund"%';
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT role_id, 'wallets:adjust'
+FROM role_permissions
+WHERE permission = 'wallets:fund'
+ON CONFLICT DO NOTHING;
+
+DELETE FROM permissions WHERE name = 'wallets:fund';
+
+UPDATE permissions SET description = 'Fund and withdraw from own wallets' WHERE name = 'wallets:adjust';
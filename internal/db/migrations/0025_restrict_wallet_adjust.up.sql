@@ -0,0 +1,32 @@
+-- wallets:adjust was granted to every role for self-service funding. Funding
+-- and withdrawing own wallets moves to wallets:fund, and wallets:adjust becomes
+-- the admin-only permission to adjust any wallet's balance.
+INSERT INTO permissions (name, description) VALUES
+    ('wallets:fund', 'Fund and withdraw from own wallets')
+ON CONFLICT (name) DO NOTHING;
+
+UPDATE permissions SET description = 'Adjust the balance of any wallet' WHERE name = 'wallets:adjust';
+
+-- The read-only roles never move funds.
+DELETE FROM role_permissions
+WHERE permission IN ('wallets:write', 'wallets:adjust', 'wallets:transfer')
+  AND role_id IN (SELECT id FROM roles WHERE name IN ('support_agent', 'auditor'));
+
+-- Every other role that could fund its users' wallets keeps doing so.
+INSERT INTO role_permissions (role_id, permission)
+SELECT role_id, 'wallets:fund'
+FROM role_permissions
+WHERE permission = 'wallets:adjust'
+ON CONFLICT DO NOTHING;
+
+DELETE FROM role_permissions
+WHERE permission = 'wallets:adjust'
+  AND role_id NOT IN (SELECT id FROM roles WHERE name = 'admin');
+
+-- API keys created with wallets:adjust were meant to fund their owner's
+-- wallets, not to adjust anyone's balance.
+UPDATE api_keys SET permissions = REPLACE(permissions, '"wallets:adjust"', '"wallets:fund"')
+WHERE permissions LIKE '%"wallets:adjust"%';
+
+-- Access tokens issued earlier carry the old permissions; revoke them so clients refresh.
+UPDATE users SET token_version = token_version + 1;
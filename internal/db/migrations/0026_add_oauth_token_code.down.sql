@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_oauth_access_tokens_code_hash;
+ALTER TABLE oauth_access_tokens DROP COLUMN IF EXISTS code_hash;
@@ -0,0 +1,4 @@
+-- Records the authorization code an access token was issued for, so that the
+-- tokens can be revoked if the code is replayed.
+ALTER TABLE oauth_access_tokens ADD COLUMN IF NOT EXISTS code_hash TEXT;
+CREATE INDEX IF NOT EXISTS idx_oauth_access_tokens_code_hash ON oauth_access_tokens (code_hash);
@@ -3,5 +3,11 @@ package models
 // UpdateUserRoleRequest defines the structure for a request to update a user's role.
 // @Description Update user role request
 type UpdateUserRoleRequest struct {
-	Role string `json:"role" example:"admin" binding:"required,oneof=user admin"`
+	Role string `json:"role" example:"admin" binding:"required"`
+}
+
+// SecurityEventQuery defines the query parameters for listing security events.
+type SecurityEventQuery struct {
+	UserID *uint `form:"user_id" example:"1"`
+	Limit  int   `form:"limit" example:"50" binding:"omitempty,min=1,max=500"`
 }
@@ -16,11 +16,15 @@ type LoginRequest struct {
 }
 
 // AuthResponse defines the structure for a successful authentication response.
+// When the user has two-factor authentication enabled, Login returns only
+// MFARequired and an MFAToken to exchange, together with a code, at /api/auth/2fa/verify.
 // @Description Authentication response
 type AuthResponse struct {
-	AccessToken  string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"`
-	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"`
-	User         *User  `json:"user"`
+	AccessToken  string `json:"access_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"`
+	RefreshToken string `json:"refresh_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"`
+	User         *User  `json:"user,omitempty"`
+	MFARequired  bool   `json:"mfa_required,omitempty" example:"false"`
+	MFAToken     string `json:"mfa_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"`
 }
 
 // RefreshTokenRequest defines the structure for a token refresh request.
@@ -28,3 +32,54 @@ type AuthResponse struct {
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9" binding:"required"`
 }
+
+// MFAVerifyRequest defines the structure for completing a two-factor login.
+// @Description Two-factor login request
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9" binding:"required"`
+	Code     string `json:"code" example:"123456" binding:"required"`
+}
+
+// TwoFactorCodeRequest carries a TOTP code or, where accepted, a recovery code.
+// @Description Two-factor code request
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" example:"123456" binding:"required"`
+}
+
+// TwoFactorEnrollment is returned when two-factor enrollment starts.
+// @Description Two-factor enrollment
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/Wallet%20Service:john@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Wallet+Service"`
+}
+
+// RecoveryCodesResponse lists freshly generated recovery codes. They are shown only once.
+// @Description Two-factor recovery codes
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k3j9-x2mq,p8v4-t6zr"`
+}
+
+// StepUpResponse carries an access token that records a fresh second factor.
+// @Description Step-up authentication response
+type StepUpResponse struct {
+	AccessToken string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"`
+}
+
+// ForgotPasswordRequest defines the structure for requesting a password reset email.
+// @Description Forgot password request
+type ForgotPasswordRequest struct {
+	Email string `json:"email" example:"john@example.com" binding:"required,email"`
+}
+
+// ResetPasswordRequest defines the structure for setting a new password with an emailed token.
+// @Description Password reset request
+type ResetPasswordRequest struct {
+	Token    string `json:"token" example:"q3Xx0p7mYfP1nA2wKcB9dE4rT6uV8sZ1hJ5kL0oM3iQ" binding:"required"`
+	Password string `json:"password" example:"newpassword123" binding:"required,min=8"`
+}
+
+// VerifyEmailRequest defines the structure for verifying an email address with an emailed token.
+// @Description Email verification request
+type VerifyEmailRequest struct {
+	Token string `json:"token" example:"q3Xx0p7mYfP1nA2wKcB9dE4rT6uV8sZ1hJ5kL0oM3iQ" binding:"required"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// Health statuses reported by the health endpoints.
+const (
+	HealthStatusOK           = "ok"
+	HealthStatusFailed       = "failed"
+	HealthStatusUnavailable  = "unavailable"
+	HealthStatusShuttingDown = "shutting_down"
+)
+
+// HealthCheck is the outcome of checking one dependency.
+// @Description Outcome of one health check
+type HealthCheck struct {
+	Name      string  `json:"name" example:"database"`
+	Status    string  `json:"status" example:"ok"`
+	LatencyMS float64 `json:"latency_ms" example:"1.42"`
+	Error     string  `json:"error,omitempty" example:"context deadline exceeded"`
+}
+
+// DatabasePoolStats describes the database connection pool.
+// @Description Database connection pool statistics
+type DatabasePoolStats struct {
+	MaxOpenConnections int    `json:"max_open_connections" example:"0"`
+	OpenConnections    int    `json:"open_connections" example:"4"`
+	InUse              int    `json:"in_use" example:"1"`
+	Idle               int    `json:"idle" example:"3"`
+	WaitCount          int64  `json:"wait_count" example:"0"`
+	WaitDuration       string `json:"wait_duration" example:"0s"`
+}
+
+// HealthReport is the response of the readiness and admin health endpoints.
+// Status is ok only when every check passed and the server is not shutting down.
+// @Description Health report
+type HealthReport struct {
+	Status       string             `json:"status" example:"ok"`
+	Checks       []HealthCheck      `json:"checks"`
+	Uptime       string             `json:"uptime,omitempty" example:"26h3m4s"`
+	DatabasePool *DatabasePoolStats `json:"database_pool,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey stores the outcome of a request made with an Idempotency-Key header,
+// so that retries of the same request replay the original response instead of repeating it.
+type IdempotencyKey struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	Key         string `gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	Fingerprint string `gorm:"not null"`           // hash of method, path and body
+	StatusCode  int    `gorm:"not null;default:0"` // 0 while the original request is in flight
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time `gorm:"not null;index"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// UserIdentity links a user to their account at an external OpenID Connect
+// provider, identified by the provider's issuer and the subject it assigned.
+// @Description Linked external identity
+type UserIdentity struct {
+	ID        uint      `json:"id" example:"1" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" example:"1" gorm:"not null;index"`
+	Issuer    string    `json:"issuer" example:"https://accounts.google.com" gorm:"not null;uniqueIndex:idx_user_identities_issuer_subject"`
+	Subject   string    `json:"subject" example:"110169484474386276334" gorm:"not null;uniqueIndex:idx_user_identities_issuer_subject"`
+	Email     string    `json:"email" example:"john@example.com"`
+	CreatedAt time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// OIDCLoginState is a pending external login, keyed by a hash of the state
+// parameter sent to the provider. It holds the nonce expected in the ID token
+// and the PKCE code verifier, and is used at most once.
+type OIDCLoginState struct {
+	StateHash    string    `gorm:"primaryKey"`
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	UsedAt       *time.Time
+	CreatedAt    time.Time
+}
+
+// TableName overrides GORM's default of "o_id_c_login_states".
+func (OIDCLoginState) TableName() string {
+	return "oidc_login_states"
+}
+
+// OIDCAuthorizationResponse holds the provider URL to send the user to.
+// @Description External login authorization response
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url" example:"https://accounts.example.com/authorize?client_id=wallet-service&state=af0ifjsldkj"`
+	State            string `json:"state" example:"af0ifjsldkj"`
+}
+
+// OIDCCallbackRequest carries the code and state the provider returned to the front end.
+// @Description External login callback request
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" example:"SplxlOBeZQQYbYS6WxSbIA" binding:"required"`
+	State string `json:"state" example:"af0ifjsldkj" binding:"required"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+	"wallet-service/pkg/money"
+
+	"gorm.io/gorm"
+)
+
+// Ledger account types. Postings are signed with debits positive and credits
+// negative, so asset accounts carry a positive balance and liability/equity
+// accounts a negative one.
+const (
+	AccountTypeAsset     = "asset"
+	AccountTypeLiability = "liability"
+	AccountTypeEquity    = "equity"
+)
+
+// LedgerAccount is an account in the double-entry ledger. Every wallet owns a
+// liability account; money entering or leaving the system goes through
+// per-currency settlement (asset) accounts.
+// @Description Ledger account
+type LedgerAccount struct {
+	ID        uint      `json:"id" example:"1" gorm:"primaryKey"`
+	Code      string    `json:"code" example:"wallet:1" gorm:"uniqueIndex;not null"`
+	Type      string    `json:"type" example:"liability" gorm:"not null"`
+	Currency  string    `json:"currency" example:"USD" gorm:"not null"`
+	WalletID  *uint     `json:"wallet_id,omitempty" example:"1" gorm:"uniqueIndex"`
+	CreatedAt time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// JournalEntry groups the postings of a single balanced ledger movement.
+// @Description Ledger journal entry
+type JournalEntry struct {
+	ID          uint      `json:"id" example:"1" gorm:"primaryKey"`
+	Reference   string    `json:"reference" example:"DEP9F8E7D6C5B4A3921" gorm:"uniqueIndex;not null"`
+	Kind        string    `json:"kind" example:"deposit" gorm:"not null"` // "deposit", "withdrawal", "transfer", "reversal", "adjustment", "opening_balance"
+	Description string    `json:"description" example:"Wallet funding"`
+	Postings    []Posting `json:"postings" gorm:"foreignKey:JournalEntryID"`
+	CreatedAt   time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// Posting is one signed leg of a journal entry. The postings of an entry always sum to zero.
+// @Description Ledger posting
+type Posting struct {
+	ID             uint        `json:"id" example:"1" gorm:"primaryKey"`
+	JournalEntryID uint        `json:"journal_entry_id" example:"1" gorm:"index;not null"`
+	AccountID      uint        `json:"account_id" example:"1" gorm:"index;not null"`
+	Amount         money.Money `json:"amount" swaggertype:"string" example:"-100.50" gorm:"type:bigint;not null"` // debit positive, credit negative
+	Currency       string      `json:"currency" example:"USD" gorm:"not null"`
+	CreatedAt      time.Time   `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// AfterFind is a GORM hook that stamps the posting's currency onto its amount.
+func (p *Posting) AfterFind(tx *gorm.DB) error {
+	p.Amount.Currency = p.Currency
+	return nil
+}
+
+// LedgerDiscrepancy reports a wallet whose cached balance disagrees with its ledger account.
+// @Description Ledger reconciliation discrepancy
+type LedgerDiscrepancy struct {
+	WalletID      uint        `json:"wallet_id" example:"1"`
+	Currency      string      `json:"currency" example:"USD"`
+	CachedBalance money.Money `json:"cached_balance" swaggertype:"string" example:"100.50"`
+	LedgerBalance money.Money `json:"ledger_balance" swaggertype:"string" example:"100.00"`
+}
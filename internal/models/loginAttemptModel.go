@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Login attempt scopes: failed logins are counted per account and per client IP,
+// and wrong two-factor codes per user.
+const (
+	LoginAttemptScopeAccount   = "account"
+	LoginAttemptScopeIP        = "ip"
+	LoginAttemptScopeTwoFactor = "two_factor"
+)
+
+// LoginAttempt counts recent failed logins for one account or IP address, or
+// wrong two-factor codes for one user. Subject is the normalized email address,
+// the IP address or the user ID.
+type LoginAttempt struct {
+	Scope         string `gorm:"primaryKey"`
+	Subject       string `gorm:"primaryKey"`
+	Failures      int    `gorm:"not null;default:0"`
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
+
+// Locked reports whether logins are refused at now.
+func (a *LoginAttempt) Locked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
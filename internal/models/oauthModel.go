@@ -0,0 +1,183 @@
+package models
+
+import "time"
+
+// OAuth grant types supported by the token endpoint.
+const (
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeAuthorizationCode = "authorization_code"
+)
+
+// OAuth scopes for a user's own wallets. Any permission name, such as users:read,
+// is also a valid scope; it is granted only if the user's role grants the permission.
+const (
+	ScopeWalletRead  = "wallet:read"  // view the user's wallets and transaction history
+	ScopeWalletWrite = "wallet:write" // open and rename wallets and move funds
+)
+
+// WalletWritePermissions are the permissions a token with the wallet:write scope
+// carries, as far as the user's role grants them, since the routes that move
+// funds require them.
+var WalletWritePermissions = []string{PermissionWalletsWrite, PermissionWalletsFund, PermissionWalletsTransfer}
+
+// OAuthClient is an application registered to obtain tokens from the OAuth2 server.
+// Confidential clients authenticate with a secret, of which only a hash is stored;
+// public clients, such as mobile apps, have none and must use PKCE. Client-credentials
+// tokens act as the client's service account, UserID.
+// @Description OAuth client
+type OAuthClient struct {
+	ID           string     `json:"client_id" example:"5b0e7d1c9a3f4e21" gorm:"primaryKey"`
+	Name         string     `json:"name" example:"Budgeting App"`
+	SecretHash   string     `json:"-"`
+	Confidential bool       `json:"confidential" example:"true" gorm:"not null;default:false"`
+	RedirectURIs []string   `json:"redirect_uris" example:"https://app.example.com/callback" gorm:"serializer:json;not null"`
+	GrantTypes   []string   `json:"grant_types" example:"authorization_code" gorm:"serializer:json;not null"`
+	Scopes       []string   `json:"scopes" example:"wallet:read" gorm:"serializer:json;not null"`
+	UserID       *uint      `json:"user_id,omitempty" example:"7"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// TableName overrides GORM's default of "o_auth_clients".
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+// AllowsGrant reports whether the client may use the grant type.
+func (c *OAuthClient) AllowsGrant(grantType string) bool {
+	for _, allowed := range c.GrantTypes {
+		if allowed == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsRedirectURI reports whether uri exactly matches one of the client's redirect URIs.
+func (c *OAuthClient) AllowsRedirectURI(uri string) bool {
+	for _, allowed := range c.RedirectURIs {
+		if allowed == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthAuthorizationCode is a single-use code issued when a user approves a
+// client, exchanged with a PKCE verifier for an access token. Only its hash is stored.
+type OAuthAuthorizationCode struct {
+	CodeHash      string `gorm:"primaryKey"`
+	ClientID      string `gorm:"not null;index"`
+	UserID        uint   `gorm:"not null"`
+	RedirectURI   string `gorm:"not null"`
+	Scope         string
+	CodeChallenge string    `gorm:"not null"`
+	ExpiresAt     time.Time `gorm:"not null"`
+	UsedAt        *time.Time
+	CreatedAt     time.Time
+}
+
+// TableName overrides GORM's default of "o_auth_authorization_codes".
+func (OAuthAuthorizationCode) TableName() string {
+	return "oauth_authorization_codes"
+}
+
+// OAuthAccessToken records an access token issued by the OAuth2 server, keyed by
+// the token's jti, so that it can be introspected and revoked.
+type OAuthAccessToken struct {
+	ID       string `gorm:"primaryKey"`
+	ClientID string `gorm:"not null;index"`
+	UserID   uint   `gorm:"not null;index"`
+	Scope    string
+	// CodeHash is the hash of the authorization code the token was issued for,
+	// empty for the client_credentials grant.
+	CodeHash  string    `gorm:"index"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// TableName overrides GORM's default of "o_auth_access_tokens".
+func (OAuthAccessToken) TableName() string {
+	return "oauth_access_tokens"
+}
+
+// CreateOAuthClientRequest defines the structure for registering an OAuth client.
+// @Description Create OAuth client request
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" example:"Budgeting App" binding:"required,max=100"`
+	Confidential bool     `json:"confidential" example:"true"`
+	RedirectURIs []string `json:"redirect_uris" example:"https://app.example.com/callback" binding:"dive,url"`
+	GrantTypes   []string `json:"grant_types" example:"authorization_code" binding:"required,min=1,dive,oneof=client_credentials authorization_code"`
+	Scopes       []string `json:"scopes" example:"wallet:read" binding:"required,min=1"`
+	UserID       *uint    `json:"user_id" example:"7"`
+}
+
+// OAuthClientCreated is returned when a client is registered. ClientSecret is set
+// for confidential clients and is shown only once.
+// @Description Newly registered OAuth client
+type OAuthClientCreated struct {
+	Client       *OAuthClient `json:"client"`
+	ClientSecret string       `json:"client_secret,omitempty" example:"q3Xx0p7mYfP1nA2wKcB9dE4rT6uV8sZ1hJ5kL0oM3iQ"`
+}
+
+// OAuthAuthorizeRequest is sent by the first-party front end once the signed-in
+// user approves a client's authorization request.
+// @Description OAuth authorization request
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `json:"response_type" example:"code" binding:"required,eq=code"`
+	ClientID            string `json:"client_id" example:"5b0e7d1c9a3f4e21" binding:"required"`
+	RedirectURI         string `json:"redirect_uri" example:"https://app.example.com/callback" binding:"required"`
+	Scope               string `json:"scope" example:"wallet:read wallet:write"`
+	State               string `json:"state" example:"af0ifjsldkj"`
+	CodeChallenge       string `json:"code_challenge" example:"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" binding:"required"`
+	CodeChallengeMethod string `json:"code_challenge_method" example:"S256" binding:"required,eq=S256"`
+}
+
+// OAuthAuthorizeResponse holds the URI to redirect the user's browser to, carrying the code and state.
+// @Description OAuth authorization response
+type OAuthAuthorizeResponse struct {
+	RedirectURI string `json:"redirect_uri" example:"https://app.example.com/callback?code=SplxlOBeZQQYbYS6WxSbIA&state=af0ifjsldkj"`
+}
+
+// OAuthTokenRequest is the form body of a token request (RFC 6749). Clients may
+// authenticate with HTTP Basic auth instead of client_id and client_secret.
+type OAuthTokenRequest struct {
+	GrantType    string `form:"grant_type" binding:"required"`
+	Scope        string `form:"scope"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+// OAuthTokenActionRequest is the form body of a token introspection (RFC 7662) or
+// revocation (RFC 7009) request. Clients authenticate as they do for a token request.
+type OAuthTokenActionRequest struct {
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// OAuthTokenResponse is a successful token response.
+// @Description OAuth token response
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token" example:"eyJhbGciOiJSUzI1NiIsImtpZCI6IjIwMjQwMTAxVDAwMDAwMFotYWJjZCJ9"`
+	TokenType   string `json:"token_type" example:"Bearer"`
+	ExpiresIn   int64  `json:"expires_in" example:"3600"`
+	Scope       string `json:"scope" example:"wallet:read"`
+}
+
+// OAuthIntrospection is a token introspection response (RFC 7662).
+// @Description OAuth token introspection response
+type OAuthIntrospection struct {
+	Active    bool   `json:"active" example:"true"`
+	Scope     string `json:"scope,omitempty" example:"wallet:read"`
+	ClientID  string `json:"client_id,omitempty" example:"5b0e7d1c9a3f4e21"`
+	Subject   string `json:"sub,omitempty" example:"7"`
+	TokenType string `json:"token_type,omitempty" example:"Bearer"`
+	ExpiresAt int64  `json:"exp,omitempty" example:"1700000000"`
+	IssuedAt  int64  `json:"iat,omitempty" example:"1699996400"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RefreshToken is the server-side record of an issued refresh token, keyed by the token's jti.
+// Every token obtained by rotating another shares its FamilyID, so presenting a token
+// that has already been rotated away can revoke the whole chain.
+type RefreshToken struct {
+	ID         string     `gorm:"primaryKey"`
+	UserID     uint       `gorm:"not null;index"`
+	FamilyID   string     `gorm:"not null;index"`
+	ExpiresAt  time.Time  `gorm:"not null;index"`
+	RevokedAt  *time.Time // set when the token is rotated or revoked
+	ReplacedBy string     // jti of the token issued when this one was rotated
+	CreatedAt  time.Time
+}
+
+// Revoked reports whether the token can no longer be used.
+func (t *RefreshToken) Revoked() bool {
+	return t.RevokedAt != nil
+}
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Permissions checked by the API. Each is also a row of the permissions table,
+// seeded by migration, so that roles can be granted it.
+const (
+	PermissionUsersRead           = "users:read"
+	PermissionUsersWrite          = "users:write"
+	PermissionRolesRead           = "roles:read"
+	PermissionRolesWrite          = "roles:write"
+	PermissionWalletsRead         = "wallets:read"
+	PermissionWalletsWrite        = "wallets:write"
+	PermissionWalletsFund         = "wallets:fund"
+	PermissionWalletsAdjust       = "wallets:adjust"
+	PermissionWalletsTransfer     = "wallets:transfer"
+	PermissionTransactionsReverse = "transactions:reverse"
+	PermissionLedgerRead          = "ledger:read"
+	PermissionSecurityEventsRead  = "security_events:read"
+	PermissionOAuthClientsRead    = "oauth_clients:read"
+	PermissionOAuthClientsWrite   = "oauth_clients:write"
+	PermissionHealthRead          = "health:read"
+)
+
+// Built-in roles.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Role is a named set of permissions assigned to users through User.Role.
+// @Description Role
+type Role struct {
+	ID          uint      `json:"id" example:"1" gorm:"primaryKey"`
+	Name        string    `json:"name" example:"auditor" gorm:"not null;uniqueIndex"`
+	Description string    `json:"description" example:"Read-only access to users, wallets, the ledger and security events"`
+	BuiltIn     bool      `json:"built_in" example:"true" gorm:"not null;default:false"` // built-in roles cannot be deleted
+	Permissions []string  `json:"permissions" example:"users:read,wallets:read" gorm:"-"`
+	CreatedAt   time.Time `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+}
+
+// RolePermission grants a permission to a role.
+type RolePermission struct {
+	RoleID     uint   `gorm:"primaryKey"`
+	Permission string `gorm:"primaryKey"`
+}
+
+// Permission is an action that roles can be allowed to perform.
+// @Description Permission
+type Permission struct {
+	Name        string `json:"name" example:"users:read" gorm:"primaryKey"`
+	Description string `json:"description" example:"View users"`
+}
+
+// CreateRoleRequest defines the structure for a request to create a role.
+// @Description Create role request
+type CreateRoleRequest struct {
+	Name        string   `json:"name" example:"auditor" binding:"required,max=50"`
+	Description string   `json:"description" example:"Read-only access for auditors"`
+	Permissions []string `json:"permissions" example:"users:read,wallets:read"`
+}
+
+// UpdateRoleRequest defines the structure for a request to replace a role's description and permissions.
+// @Description Update role request
+type UpdateRoleRequest struct {
+	Description string   `json:"description" example:"Read-only access for auditors"`
+	Permissions []string `json:"permissions" example:"users:read,wallets:read"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Security event types.
+const (
+	SecurityEventAccountLocked   = "account_locked"
+	SecurityEventIPLocked        = "ip_locked"
+	SecurityEventAccountUnlocked = "account_unlocked"
+	SecurityEventTwoFactorLocked = "two_factor_locked"
+)
+
+// SecurityEvent records a security-relevant occurrence, such as an account lockout, for auditing.
+// @Description Security event
+type SecurityEvent struct {
+	ID        uint      `json:"id" example:"1" gorm:"primaryKey"`
+	UserID    *uint     `json:"user_id,omitempty" example:"1" gorm:"index"`
+	Type      string    `json:"type" example:"account_locked" gorm:"not null;index"`
+	IPAddress string    `json:"ip_address,omitempty" example:"203.0.113.7"`
+	Detail    string    `json:"detail,omitempty" example:"Locked for 15m0s after 5 failed attempts"`
+	CreatedAt time.Time `json:"created_at" example:"2023-01-01T00:00:00Z" gorm:"index"`
+}
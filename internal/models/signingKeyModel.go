@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ActiveSigningKey records the kid of the JWT signing key that every instance
+// signs new tokens with. The table holds at most one row, with ID 1.
+type ActiveSigningKey struct {
+	ID        uint   `gorm:"primaryKey"`
+	KID       string `gorm:"column:kid;not null"`
+	UpdatedAt time.Time
+}
@@ -1,18 +1,89 @@
 package models
 
+import (
+	"time"
+	"wallet-service/pkg/money"
+
+	"gorm.io/gorm"
+)
+
 // Transaction represents a wallet transaction
 // @Description Transaction model for wallet operations
 type Transaction struct {
-	ID            uint    `json:"id" example:"1"`
-	CreatedAt     string  `json:"created_at" example:"2023-01-01T00:00:00Z"`
-	UpdatedAt     string  `json:"updated_at" example:"2023-01-01T00:00:00Z"`
-	DeletedAt     *string `json:"deleted_at,omitempty"`
-	WalletID      uint    `json:"wallet_id" example:"1" gorm:"not null"`
-	Type          string  `json:"type" example:"deposit" gorm:"not null"` // "deposit", "withdrawal", "transfer"
-	Amount        float64 `json:"amount" example:"100.50" gorm:"not null"`
-	Description   string  `json:"description" example:"Wallet funding"`
-	Status        string  `json:"status" example:"completed" gorm:"default:pending"` // "pending", "completed", "failed"
-	Reference     string  `json:"reference" example:"REF123456" gorm:"unique"`
-	BalanceBefore float64 `json:"balance_before" example:"0.00"`
-	BalanceAfter  float64 `json:"balance_after" example:"100.50"`
+	ID            uint        `json:"id" example:"1" gorm:"primaryKey;index:idx_transactions_wallet_created_id,priority:3"`
+	CreatedAt     time.Time   `json:"created_at" example:"2023-01-01T00:00:00Z" gorm:"index:idx_transactions_wallet_created_id,priority:2"`
+	UpdatedAt     time.Time   `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+	DeletedAt     *time.Time  `json:"deleted_at,omitempty"`
+	WalletID      uint        `json:"wallet_id" example:"1" gorm:"not null;index:idx_transactions_wallet_created_id,priority:1"`
+	Type          string      `json:"type" example:"deposit" gorm:"not null"` // "deposit", "withdrawal", "transfer", "reversal", "adjustment"
+	Amount        money.Money `json:"amount" swaggertype:"string" example:"100.50" gorm:"type:bigint;not null"`
+	Currency      string      `json:"currency" example:"USD" gorm:"not null;default:'USD'"`
+	Description   string      `json:"description" example:"Wallet funding"`
+	Status        string      `json:"status" example:"completed" gorm:"default:pending"` // "pending", "completed", "failed", "reversed"
+	Reference     string      `json:"reference" example:"REF123456" gorm:"unique"`
+	BalanceBefore money.Money `json:"balance_before" swaggertype:"string" example:"0.00" gorm:"type:bigint"`
+	BalanceAfter  money.Money `json:"balance_after" swaggertype:"string" example:"100.50" gorm:"type:bigint"`
+
+	// Transfer legs share a TransferReference and point at each other's wallet.
+	TransferReference    string `json:"transfer_reference,omitempty" example:"TRF9F8E7D6C5B4A3921" gorm:"index"`
+	CounterpartyWalletID *uint  `json:"counterparty_wallet_id,omitempty" example:"2"`
+
+	// JournalEntryID links the transaction to the ledger entry that moved the money.
+	JournalEntryID *uint `json:"journal_entry_id,omitempty" example:"1" gorm:"index"`
+}
+
+// AfterFind is a GORM hook that stamps the transaction's currency onto its amounts,
+// since only the minor units are stored in the amount columns.
+func (t *Transaction) AfterFind(tx *gorm.DB) error {
+	t.Amount.Currency = t.Currency
+	t.BalanceBefore.Currency = t.Currency
+	t.BalanceAfter.Currency = t.Currency
+	return nil
+}
+
+// TransactionQuery defines the query parameters for listing a wallet's transactions.
+// Amounts are decimal strings in the wallet's currency; dates are RFC 3339.
+// @Description Transaction history query
+type TransactionQuery struct {
+	Type      string     `form:"type" example:"deposit" binding:"omitempty,oneof=deposit withdrawal transfer reversal adjustment"`
+	Status    string     `form:"status" example:"completed" binding:"omitempty,oneof=pending completed failed reversed"`
+	MinAmount string     `form:"min_amount" example:"10.00"`
+	MaxAmount string     `form:"max_amount" example:"500.00"`
+	From      *time.Time `form:"from" example:"2023-01-01T00:00:00Z" time_format:"2006-01-02T15:04:05Z07:00"`
+	To        *time.Time `form:"to" example:"2023-12-31T23:59:59Z" time_format:"2006-01-02T15:04:05Z07:00"`
+	Reference string     `form:"reference" example:"TRF9F8E"`
+	Order     string     `form:"order" example:"desc" binding:"omitempty,oneof=asc desc"`
+	Cursor    string     `form:"cursor"`
+	Limit     int        `form:"limit" example:"20" binding:"omitempty,min=1,max=100"`
+}
+
+// TransactionPage is one page of a wallet's transaction history.
+// NextCursor is empty on the last page.
+// @Description Paginated transaction history
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty" example:"eyJ0IjoiMjAyMy0wMS0wMVQwMDowMDowMFoiLCJpZCI6NDJ9"`
+}
+
+// ReverseTransactionRequest defines the request body for reversing a transaction.
+// @Description Transaction reversal request
+type ReverseTransactionRequest struct {
+	Reason string `json:"reason" example:"Card payment charged back"`
+}
+
+// AdjustBalanceRequest defines the request body for an administrative balance
+// adjustment. A negative amount debits the wallet.
+// @Description Balance adjustment request
+type AdjustBalanceRequest struct {
+	Amount   string `json:"amount" example:"-12.50" binding:"required"`
+	Currency string `json:"currency" example:"USD" binding:"required,iso4217"`
+	Reason   string `json:"reason" example:"Duplicate card settlement" binding:"required,max=255"`
+}
+
+// ReversalResponse defines the response body for a reversed transaction: the
+// reversal transactions, one per wallet whose balance was restored.
+// @Description Transaction reversal response
+type ReversalResponse struct {
+	Reference    string        `json:"reference" example:"REV9F8E7D6C5B4A3921"`
+	Transactions []Transaction `json:"transactions"`
 }
@@ -18,6 +18,25 @@ type User struct {
 	CreatedAt time.Time  `json:"created_at" example:"2023-01-01T00:00:00Z"`
 	UpdatedAt time.Time  `json:"updated_at" example:"2023-01-01T00:00:00Z"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty" gorm:"index"`
+
+	// EmailVerifiedAt is when the user proved they own Email; wallet operations require it.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" example:"2023-01-01T00:00:00Z"`
+
+	// TOTPSecret is set during two-factor enrollment and only takes effect once TOTPEnabled.
+	// It is encrypted with utils.Seal.
+	TOTPSecret  string `json:"-"`
+	TOTPEnabled bool   `json:"two_factor_enabled" example:"false" gorm:"not null;default:false"`
+	// TOTPLastStep is the time step of the last accepted code, so a code cannot be replayed.
+	TOTPLastStep int64 `json:"-" gorm:"not null;default:0"`
+
+	// TokenVersion is carried in access tokens as the "tv" claim. Bumping it, as
+	// changing the user's role does, invalidates every access token issued before.
+	TokenVersion int `json:"-" gorm:"not null;default:0"`
+}
+
+// EmailVerified reports whether the user has verified their email address.
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
 
 // BeforeCreate is a GORM hook that automatically hashes the user's password before creation.
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// User token purposes.
+const (
+	UserTokenPasswordReset     = "password_reset"
+	UserTokenEmailVerification = "email_verification"
+	UserTokenMFAChallenge      = "mfa_challenge"
+)
+
+// UserToken is a single-use token emailed to a user to reset their password or
+// verify their email address, or the ID of an MFA challenge token, which may
+// complete only one login. Only the token's hash is stored.
+type UserToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	Purpose   string    `gorm:"not null"`
+	TokenHash string    `gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -1,21 +1,77 @@
 package models
 
-// Wallet represents a user's wallet
+import (
+	"time"
+	"wallet-service/pkg/money"
+
+	"gorm.io/gorm"
+)
+
+// Wallet represents a user's wallet.
+// Balance is a cache of the wallet's ledger account and is only changed
+// together with a balanced journal entry.
 // @Description Wallet model
 type Wallet struct {
-	ID        uint    `json:"id" example:"1"`
-	CreatedAt string  `json:"created_at" example:"2023-01-01T00:00:00Z"`
-	UpdatedAt string  `json:"updated_at" example:"2023-01-01T00:00:00Z"`
-	DeletedAt *string `json:"deleted_at,omitempty"`
-	UserID    uint    `json:"user_id" example:"1" gorm:"not null"`
-	Name      string  `json:"name" example:"My Wallet" gorm:"not null"`
-	Balance   float64 `json:"balance" example:"100.50" gorm:"default:0"`
-	Currency  string  `json:"currency" example:"USD" gorm:"default:'USD'"`
-	IsActive  bool    `json:"is_active" example:"true" gorm:"default:true"`
+	ID        uint        `json:"id" example:"1"`
+	CreatedAt time.Time   `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt time.Time   `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+	DeletedAt *time.Time  `json:"deleted_at,omitempty"`
+	UserID    uint        `json:"user_id" example:"1" gorm:"not null"`
+	Name      string      `json:"name" example:"My Wallet" gorm:"not null"`
+	Balance   money.Money `json:"balance" swaggertype:"string" example:"100.50" gorm:"type:bigint;not null;default:0"` // minor units
+	Currency  string      `json:"currency" example:"USD" gorm:"default:'USD'"`
+	IsActive  bool        `json:"is_active" example:"true" gorm:"default:true"`
+	IsDefault bool        `json:"is_default" example:"true" gorm:"not null;default:false"`
+}
+
+// AfterFind is a GORM hook that stamps the wallet's currency onto its balance,
+// since only the minor units are stored in the balance column.
+func (w *Wallet) AfterFind(tx *gorm.DB) error {
+	w.Balance.Currency = w.Currency
+	return nil
+}
+
+// CreateWalletRequest defines the request body for opening an additional wallet.
+// @Description Wallet creation request
+type CreateWalletRequest struct {
+	Name     string `json:"name" example:"Savings" binding:"required,max=100"`
+	Currency string `json:"currency" example:"NGN" binding:"required,iso4217"`
+}
+
+// RenameWalletRequest defines the request body for renaming a wallet.
+// @Description Wallet rename request
+type RenameWalletRequest struct {
+	Name string `json:"name" example:"Holiday fund" binding:"required,max=100"`
 }
 
 // FundWalletRequest defines the request body for funding a wallet.
+// Amounts are decimal strings in the wallet's currency.
 // @Description Wallet funding request
 type FundWalletRequest struct {
-	Amount float64 `json:"amount" example:"100.50" binding:"required,gt=0"`
+	Amount string `json:"amount" example:"100.50" binding:"required"`
+}
+
+// WithdrawWalletRequest defines the request body for withdrawing from a wallet.
+// @Description Wallet withdrawal request
+type WithdrawWalletRequest struct {
+	Amount      string `json:"amount" example:"50.25" binding:"required"`
+	Description string `json:"description" example:"ATM withdrawal"`
+}
+
+// TransferRequest defines the request body for a wallet-to-wallet transfer.
+// The recipient is identified either by wallet ID or by the owner's email, not both.
+// @Description Wallet transfer request
+type TransferRequest struct {
+	ToWalletID uint   `json:"to_wallet_id" example:"2" binding:"required_without=ToEmail"`
+	ToEmail    string `json:"to_email" example:"jane@example.com" binding:"omitempty,email"`
+	Amount     string `json:"amount" example:"25.00" binding:"required"`
+	Note       string `json:"note" example:"Dinner split"`
+}
+
+// TransferResponse defines the response body for a completed transfer.
+// @Description Wallet transfer response
+type TransferResponse struct {
+	Reference   string       `json:"reference" example:"TRF9F8E7D6C5B4A3921"`
+	Wallet      *Wallet      `json:"wallet"`
+	Transaction *Transaction `json:"transaction"`
 }
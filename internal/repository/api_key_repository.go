@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository handles database operations for API keys.
+type APIKeyRepository struct {
+	DB *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new APIKeyRepository.
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{DB: db}
+}
+
+// Create stores a new API key.
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return r.DB.WithContext(ctx).Create(key).Error
+}
+
+// FindByPrefix finds an API key by its public prefix.
+func (r *APIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.DB.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUserID returns a user's API keys, newest first.
+func (r *APIKeyRepository) ListByUserID(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke revokes one of a user's API keys. It reports false if the user has no
+// such key that is still active.
+func (r *APIKeyRepository) Revoke(ctx context.Context, id, userID uint, now time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// TouchLastUsed records that a key was used at now. To avoid a write per request,
+// the time is only updated once it is more than interval old.
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uint, now time.Time, interval time.Duration) error {
+	return r.DB.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-interval)).
+		Update("last_used_at", now).Error
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository handles database operations for idempotency keys.
+type IdempotencyRepository struct {
+	DB *gorm.DB
+}
+
+// NewIdempotencyRepository creates a new IdempotencyRepository.
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{DB: db}
+}
+
+// Reserve inserts record unless the user already holds the same key.
+// It reports whether the record was inserted.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyKey) (bool, error) {
+	result := r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// FindByUserAndKey finds the record for a user's idempotency key.
+func (r *IdempotencyRepository) FindByUserAndKey(ctx context.Context, userID uint, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	if err := r.DB.WithContext(ctx).Where("user_id = ? AND key = ?", userID, key).First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Renew extends the lease of an in-flight record to now.
+// It reports false if the record has since completed or been removed.
+func (r *IdempotencyRepository) Renew(ctx context.Context, id uint, now time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("id = ? AND status_code = 0", id).
+		Update("updated_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// Update saves a record's stored response.
+func (r *IdempotencyRepository) Update(ctx context.Context, record *models.IdempotencyKey) error {
+	return r.DB.WithContext(ctx).Save(record).Error
+}
+
+// Delete removes a record.
+func (r *IdempotencyRepository) Delete(ctx context.Context, id uint) error {
+	return r.DB.WithContext(ctx).Delete(&models.IdempotencyKey{}, id).Error
+}
+
+// DeleteExpired removes every record that expired before now and returns how many were removed.
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/pkg/money"
+
+	"gorm.io/gorm"
+)
+
+// MemoryLedgerStore is a LedgerStore kept in process memory, for tests. It
+// enforces unique account codes and entry references. FindDiscrepancies compares
+// the ledger with the wallets of the MemoryWalletStore it was created for.
+// WithTx returns the store itself, so writes made inside a transaction are not
+// rolled back.
+type MemoryLedgerStore struct {
+	wallets *MemoryWalletStore
+
+	mu            sync.Mutex
+	accounts      []models.LedgerAccount
+	entries       []models.JournalEntry
+	nextAccountID uint
+	nextEntryID   uint
+	nextPostingID uint
+}
+
+// NewMemoryLedgerStore creates an empty MemoryLedgerStore for the wallets in wallets.
+func NewMemoryLedgerStore(wallets *MemoryWalletStore) *MemoryLedgerStore {
+	return &MemoryLedgerStore{wallets: wallets, nextAccountID: 1, nextEntryID: 1, nextPostingID: 1}
+}
+
+// WithTx returns the store itself.
+func (s *MemoryLedgerStore) WithTx(tx *gorm.DB) LedgerStore {
+	return s
+}
+
+// FindOrCreateAccount returns the account with the given code in account,
+// creating it from account if it does not exist.
+func (s *MemoryLedgerStore) FindOrCreateAccount(ctx context.Context, account *models.LedgerAccount) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.accounts {
+		if existing.Code == account.Code {
+			*account = existing
+			return nil
+		}
+	}
+
+	account.ID = s.nextAccountID
+	s.nextAccountID++
+	account.CreatedAt = time.Now()
+	stored := *account
+	if account.WalletID != nil {
+		walletID := *account.WalletID
+		stored.WalletID = &walletID
+	}
+	s.accounts = append(s.accounts, stored)
+	return nil
+}
+
+// FindAccountByWalletID finds the ledger account owned by a wallet.
+func (s *MemoryLedgerStore) FindAccountByWalletID(ctx context.Context, walletID uint) (*models.LedgerAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, account := range s.accounts {
+		if account.WalletID != nil && *account.WalletID == walletID {
+			return &account, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// CreateEntry saves a journal entry together with its postings, assigning their
+// IDs. It returns gorm.ErrDuplicatedKey if the reference is taken.
+func (s *MemoryLedgerStore) CreateEntry(ctx context.Context, entry *models.JournalEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.entries {
+		if existing.Reference == entry.Reference {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+
+	now := time.Now()
+	entry.ID = s.nextEntryID
+	s.nextEntryID++
+	entry.CreatedAt = now
+	for i := range entry.Postings {
+		entry.Postings[i].ID = s.nextPostingID
+		s.nextPostingID++
+		entry.Postings[i].JournalEntryID = entry.ID
+		entry.Postings[i].CreatedAt = now
+	}
+
+	stored := *entry
+	stored.Postings = append([]models.Posting(nil), entry.Postings...)
+	s.entries = append(s.entries, stored)
+	return nil
+}
+
+// AccountBalance returns the signed sum of all postings to an account, in minor units.
+func (s *MemoryLedgerStore) AccountBalance(ctx context.Context, accountID uint) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.balance(accountID), nil
+}
+
+// FindDiscrepancies lists wallets whose cached balance differs from the balance
+// derived from their postings, ordered by wallet ID.
+func (s *MemoryLedgerStore) FindDiscrepancies(ctx context.Context) ([]models.LedgerDiscrepancy, error) {
+	s.wallets.mu.Lock()
+	wallets := s.wallets.sorted(false)
+	s.wallets.mu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	discrepancies := []models.LedgerDiscrepancy{}
+	for _, wallet := range wallets {
+		var derived int64
+		for _, account := range s.accounts {
+			if account.WalletID != nil && *account.WalletID == wallet.ID {
+				derived = -s.balance(account.ID)
+			}
+		}
+		if wallet.Balance.Amount != derived {
+			discrepancies = append(discrepancies, models.LedgerDiscrepancy{
+				WalletID:      wallet.ID,
+				Currency:      wallet.Currency,
+				CachedBalance: wallet.Balance,
+				LedgerBalance: money.New(derived, wallet.Currency),
+			})
+		}
+	}
+	return discrepancies, nil
+}
+
+// balance sums the postings to an account. s.mu must be held.
+func (s *MemoryLedgerStore) balance(accountID uint) int64 {
+	var sum int64
+	for _, entry := range s.entries {
+		for _, posting := range entry.Postings {
+			if posting.AccountID == accountID {
+				sum += posting.Amount.Amount
+			}
+		}
+	}
+	return sum
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"wallet-service/internal/models"
+	"wallet-service/pkg/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LedgerStore stores ledger accounts and journal entries. LedgerRepository keeps
+// them in the database and MemoryLedgerStore in process memory, for tests.
+type LedgerStore interface {
+	// WithTx returns a store that runs its queries inside the given database transaction.
+	WithTx(tx *gorm.DB) LedgerStore
+	// FindOrCreateAccount returns the account with account's code in account,
+	// creating it if it does not exist.
+	FindOrCreateAccount(ctx context.Context, account *models.LedgerAccount) error
+	// FindAccountByWalletID finds a wallet's account, or returns gorm.ErrRecordNotFound.
+	FindAccountByWalletID(ctx context.Context, walletID uint) (*models.LedgerAccount, error)
+	CreateEntry(ctx context.Context, entry *models.JournalEntry) error
+	// AccountBalance returns the signed sum of an account's postings, in minor units.
+	AccountBalance(ctx context.Context, accountID uint) (int64, error)
+	FindDiscrepancies(ctx context.Context) ([]models.LedgerDiscrepancy, error)
+}
+
+// LedgerRepository is the database implementation of LedgerStore.
+type LedgerRepository struct {
+	DB *gorm.DB
+}
+
+// NewLedgerRepository creates a new LedgerRepository.
+func NewLedgerRepository(db *gorm.DB) *LedgerRepository {
+	return &LedgerRepository{DB: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside the given database transaction.
+func (r *LedgerRepository) WithTx(tx *gorm.DB) LedgerStore {
+	return &LedgerRepository{DB: tx}
+}
+
+// FindOrCreateAccount returns the account with the given code, creating it from account if it does not exist.
+// The insert skips an existing code instead of failing, so concurrent first uses of
+// the same account all end up with the one row.
+func (r *LedgerRepository) FindOrCreateAccount(ctx context.Context, account *models.LedgerAccount) error {
+	db := r.DB.WithContext(ctx)
+	err := db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).Create(account).Error
+	if err != nil || account.ID != 0 {
+		return err
+	}
+	return db.Where("code = ?", account.Code).First(account).Error
+}
+
+// FindAccountByWalletID finds the ledger account owned by a wallet.
+func (r *LedgerRepository) FindAccountByWalletID(ctx context.Context, walletID uint) (*models.LedgerAccount, error) {
+	var account models.LedgerAccount
+	if err := r.DB.WithContext(ctx).Where("wallet_id = ?", walletID).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// CreateEntry creates a journal entry together with its postings.
+func (r *LedgerRepository) CreateEntry(ctx context.Context, entry *models.JournalEntry) error {
+	return r.DB.WithContext(ctx).Create(entry).Error
+}
+
+// AccountBalance returns the signed sum of all postings to an account, in minor units.
+func (r *LedgerRepository) AccountBalance(ctx context.Context, accountID uint) (int64, error) {
+	var sum int64
+	err := r.DB.WithContext(ctx).Model(&models.Posting{}).
+		Where("account_id = ?", accountID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&sum).Error
+	return sum, err
+}
+
+// FindDiscrepancies lists wallets whose cached balance differs from the balance derived from their postings.
+func (r *LedgerRepository) FindDiscrepancies(ctx context.Context) ([]models.LedgerDiscrepancy, error) {
+	var rows []struct {
+		WalletID uint
+		Currency string
+		Cached   int64
+		Derived  int64
+	}
+	err := r.DB.WithContext(ctx).Raw(`
+		SELECT w.id AS wallet_id, w.currency, w.balance AS cached, COALESCE(-SUM(p.amount), 0) AS derived
+		FROM wallets w
+		LEFT JOIN ledger_accounts a ON a.wallet_id = w.id
+		LEFT JOIN postings p ON p.account_id = a.id
+		GROUP BY w.id, w.currency, w.balance
+		HAVING w.balance <> COALESCE(-SUM(p.amount), 0)
+		ORDER BY w.id`).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	discrepancies := make([]models.LedgerDiscrepancy, 0, len(rows))
+	for _, row := range rows {
+		discrepancies = append(discrepancies, models.LedgerDiscrepancy{
+			WalletID:      row.WalletID,
+			Currency:      row.Currency,
+			CachedBalance: money.New(row.Cached, row.Currency),
+			LedgerBalance: money.New(row.Derived, row.Currency),
+		})
+	}
+	return discrepancies, nil
+}
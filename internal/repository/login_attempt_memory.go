@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MemoryLoginAttemptStore is a LoginAttemptStore kept in process memory. It suits
+// tests and single-instance deployments; counters are lost on restart.
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[[2]string]models.LoginAttempt
+}
+
+// NewMemoryLoginAttemptStore creates an empty MemoryLoginAttemptStore.
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{attempts: make(map[[2]string]models.LoginAttempt)}
+}
+
+// Get returns a copy of the counter for scope and subject.
+func (s *MemoryLoginAttemptStore) Get(ctx context.Context, scope, subject string) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempt, ok := s.attempts[[2]string{scope, subject}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &attempt, nil
+}
+
+// RecordFailure counts a failed login at now.
+func (s *MemoryLoginAttemptStore) RecordFailure(ctx context.Context, scope, subject string, now time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := [2]string{scope, subject}
+	attempt, ok := s.attempts[key]
+	if !ok {
+		attempt = models.LoginAttempt{Scope: scope, Subject: subject}
+	}
+	lockExpired := attempt.LockedUntil != nil && !now.Before(*attempt.LockedUntil)
+	if lockExpired {
+		attempt.LockedUntil = nil
+	}
+	if lockExpired || !attempt.LastFailureAt.After(now.Add(-window)) {
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	s.attempts[key] = attempt
+	return &attempt, nil
+}
+
+// Lock refuses logins for scope and subject until the given time.
+func (s *MemoryLoginAttemptStore) Lock(ctx context.Context, scope, subject string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := [2]string{scope, subject}
+	if attempt, ok := s.attempts[key]; ok {
+		attempt.LockedUntil = &until
+		s.attempts[key] = attempt
+	}
+	return nil
+}
+
+// Reset clears the counter and any lockout.
+func (s *MemoryLoginAttemptStore) Reset(ctx context.Context, scope, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, [2]string{scope, subject})
+	return nil
+}
+
+// DeleteStale removes counters whose failures are outside the window and which are not locked.
+func (s *MemoryLoginAttemptStore) DeleteStale(ctx context.Context, now time.Time, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for key, attempt := range s.attempts {
+		if !attempt.LastFailureAt.After(now.Add(-window)) && !attempt.Locked(now) {
+			delete(s.attempts, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const testWindow = 15 * time.Minute
+
+func TestMemoryLoginAttemptStore_CountsFailuresWithinWindow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryLoginAttemptStore()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := store.Get(ctx, models.LoginAttemptScopeAccount, "a@example.com"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Get before any failure: err = %v, want gorm.ErrRecordNotFound", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		attempt, err := store.RecordFailure(ctx, models.LoginAttemptScopeAccount, "a@example.com", now.Add(time.Duration(i)*time.Minute), testWindow)
+		if err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+		if attempt.Failures != i {
+			t.Fatalf("after %d failures: Failures = %d", i, attempt.Failures)
+		}
+	}
+
+	// Counters are kept per scope and subject.
+	other, err := store.RecordFailure(ctx, models.LoginAttemptScopeIP, "a@example.com", now, testWindow)
+	if err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	if other.Failures != 1 {
+		t.Fatalf("IP scope: Failures = %d, want 1", other.Failures)
+	}
+
+	// A failure after the window has passed starts counting again.
+	attempt, err := store.RecordFailure(ctx, models.LoginAttemptScopeAccount, "a@example.com", now.Add(3*time.Minute+testWindow), testWindow)
+	if err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	if attempt.Failures != 1 {
+		t.Fatalf("after the window: Failures = %d, want 1", attempt.Failures)
+	}
+}
+
+func TestMemoryLoginAttemptStore_LockAndReset(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryLoginAttemptStore()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	until := now.Add(time.Hour)
+
+	// Locking a subject with no failures does nothing.
+	if err := store.Lock(ctx, models.LoginAttemptScopeIP, "203.0.113.7", until); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if _, err := store.Get(ctx, models.LoginAttemptScopeIP, "203.0.113.7"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Get after locking an unknown subject: err = %v, want gorm.ErrRecordNotFound", err)
+	}
+
+	if _, err := store.RecordFailure(ctx, models.LoginAttemptScopeIP, "203.0.113.7", now, testWindow); err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	if err := store.Lock(ctx, models.LoginAttemptScopeIP, "203.0.113.7", until); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	attempt, err := store.Get(ctx, models.LoginAttemptScopeIP, "203.0.113.7")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !attempt.Locked(now) || attempt.Locked(until) {
+		t.Fatalf("LockedUntil = %v, want locked until %v", attempt.LockedUntil, until)
+	}
+
+	// A failure once the lock has expired clears it and starts counting again.
+	attempt, err = store.RecordFailure(ctx, models.LoginAttemptScopeIP, "203.0.113.7", until.Add(time.Minute), 2*time.Hour)
+	if err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	if attempt.LockedUntil != nil || attempt.Failures != 1 {
+		t.Fatalf("after the lock expired: Failures = %d, LockedUntil = %v; want 1, nil", attempt.Failures, attempt.LockedUntil)
+	}
+
+	if err := store.Reset(ctx, models.LoginAttemptScopeIP, "203.0.113.7"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if _, err := store.Get(ctx, models.LoginAttemptScopeIP, "203.0.113.7"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("Get after Reset: err = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestMemoryLoginAttemptStore_GetReturnsCopy(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryLoginAttemptStore()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	attempt, err := store.RecordFailure(ctx, models.LoginAttemptScopeAccount, "a@example.com", now, testWindow)
+	if err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	attempt.Failures = 100
+
+	stored, err := store.Get(ctx, models.LoginAttemptScopeAccount, "a@example.com")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if stored.Failures != 1 {
+		t.Fatalf("Failures = %d after changing a returned counter, want 1", stored.Failures)
+	}
+}
+
+func TestMemoryLoginAttemptStore_DeleteStale(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryLoginAttemptStore()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-2 * testWindow)
+
+	for _, subject := range []string{"stale", "locked", "recent"} {
+		at := old
+		if subject == "recent" {
+			at = now.Add(-time.Minute)
+		}
+		if _, err := store.RecordFailure(ctx, models.LoginAttemptScopeAccount, subject, at, testWindow); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+	if err := store.Lock(ctx, models.LoginAttemptScopeAccount, "locked", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+
+	deleted, err := store.DeleteStale(ctx, now, testWindow)
+	if err != nil {
+		t.Fatalf("DeleteStale: %v", err)
+	}
+	if deleted != 1 {
+		t.Fatalf("DeleteStale removed %d counters, want 1", deleted)
+	}
+	if _, err := store.Get(ctx, models.LoginAttemptScopeAccount, "stale"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("stale counter was kept: err = %v", err)
+	}
+	for _, subject := range []string{"locked", "recent"} {
+		if _, err := store.Get(ctx, models.LoginAttemptScopeAccount, subject); err != nil {
+			t.Fatalf("%s counter was removed: %v", subject, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttemptStore keeps failed login counters. Counters restart once the last
+// failure is older than the failure window or a lockout has expired.
+type LoginAttemptStore interface {
+	// Get returns the counter for scope and subject, or gorm.ErrRecordNotFound if there is none.
+	Get(ctx context.Context, scope, subject string) (*models.LoginAttempt, error)
+	// RecordFailure counts a failed login at now and returns the updated counter.
+	RecordFailure(ctx context.Context, scope, subject string, now time.Time, window time.Duration) (*models.LoginAttempt, error)
+	// Lock refuses logins for scope and subject until the given time.
+	Lock(ctx context.Context, scope, subject string, until time.Time) error
+	// Reset clears the counter and any lockout.
+	Reset(ctx context.Context, scope, subject string) error
+	// DeleteStale removes counters that no longer affect logins and returns how many were removed.
+	DeleteStale(ctx context.Context, now time.Time, window time.Duration) (int64, error)
+}
+
+// LoginAttemptRepository is the database-backed LoginAttemptStore, shared by every instance.
+type LoginAttemptRepository struct {
+	DB *gorm.DB
+}
+
+// NewLoginAttemptRepository creates a new LoginAttemptRepository.
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{DB: db}
+}
+
+// Get returns the counter for scope and subject.
+func (r *LoginAttemptRepository) Get(ctx context.Context, scope, subject string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	if err := r.DB.WithContext(ctx).Where("scope = ? AND subject = ?", scope, subject).First(&attempt).Error; err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// RecordFailure counts a failed login in a single upsert, so concurrent failures are all counted.
+func (r *LoginAttemptRepository) RecordFailure(ctx context.Context, scope, subject string, now time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := r.DB.WithContext(ctx).Raw(`INSERT INTO login_attempts (scope, subject, failures, last_failure_at) VALUES (@scope, @subject, 1, @now)
+		ON CONFLICT (scope, subject) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.last_failure_at <= @window_start OR login_attempts.locked_until <= @now THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			locked_until = CASE WHEN login_attempts.locked_until <= @now THEN NULL ELSE login_attempts.locked_until END,
+			last_failure_at = @now
+		RETURNING scope, subject, failures, last_failure_at, locked_until`,
+		map[string]interface{}{"scope": scope, "subject": subject, "now": now, "window_start": now.Add(-window)},
+	).Scan(&attempt).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// Lock refuses logins for scope and subject until the given time.
+func (r *LoginAttemptRepository) Lock(ctx context.Context, scope, subject string, until time.Time) error {
+	return r.DB.WithContext(ctx).Model(&models.LoginAttempt{}).
+		Where("scope = ? AND subject = ?", scope, subject).
+		Update("locked_until", until).Error
+}
+
+// Reset clears the counter and any lockout.
+func (r *LoginAttemptRepository) Reset(ctx context.Context, scope, subject string) error {
+	return r.DB.WithContext(ctx).Where("scope = ? AND subject = ?", scope, subject).Delete(&models.LoginAttempt{}).Error
+}
+
+// DeleteStale removes counters whose failures are outside the window and which are not locked.
+func (r *LoginAttemptRepository) DeleteStale(ctx context.Context, now time.Time, window time.Duration) (int64, error) {
+	result := r.DB.WithContext(ctx).Where("last_failure_at <= ? AND (locked_until IS NULL OR locked_until <= ?)", now.Add(-window), now).
+		Delete(&models.LoginAttempt{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// OAuthAccessTokenRepository handles database operations for access tokens issued by the OAuth2 server.
+type OAuthAccessTokenRepository struct {
+	DB *gorm.DB
+}
+
+// NewOAuthAccessTokenRepository creates a new OAuthAccessTokenRepository.
+func NewOAuthAccessTokenRepository(db *gorm.DB) *OAuthAccessTokenRepository {
+	return &OAuthAccessTokenRepository{DB: db}
+}
+
+// Create stores a newly issued access token.
+func (r *OAuthAccessTokenRepository) Create(ctx context.Context, token *models.OAuthAccessToken) error {
+	return r.DB.WithContext(ctx).Create(token).Error
+}
+
+// FindByID finds an access token by its jti.
+func (r *OAuthAccessTokenRepository) FindByID(ctx context.Context, id string) (*models.OAuthAccessToken, error) {
+	var token models.OAuthAccessToken
+	if err := r.DB.WithContext(ctx).Where("id = ?", id).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Revoke revokes an access token issued to the given client. Tokens of other
+// clients and already-revoked tokens are left alone.
+func (r *OAuthAccessTokenRepository) Revoke(ctx context.Context, id, clientID string, now time.Time) error {
+	return r.DB.WithContext(ctx).Model(&models.OAuthAccessToken{}).
+		Where("id = ? AND client_id = ? AND revoked_at IS NULL", id, clientID).
+		Update("revoked_at", now).Error
+}
+
+// RevokeForClient revokes every access token issued to a client.
+func (r *OAuthAccessTokenRepository) RevokeForClient(ctx context.Context, clientID string, now time.Time) error {
+	return r.DB.WithContext(ctx).Model(&models.OAuthAccessToken{}).
+		Where("client_id = ? AND revoked_at IS NULL", clientID).
+		Update("revoked_at", now).Error
+}
+
+// RevokeForCode revokes every access token issued for the authorization code with the given hash.
+func (r *OAuthAccessTokenRepository) RevokeForCode(ctx context.Context, codeHash string, now time.Time) error {
+	return r.DB.WithContext(ctx).Model(&models.OAuthAccessToken{}).
+		Where("code_hash = ? AND revoked_at IS NULL", codeHash).
+		Update("revoked_at", now).Error
+}
+
+// DeleteExpired removes tokens that have expired and returns how many were deleted.
+func (r *OAuthAccessTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.OAuthAccessToken{})
+	return result.RowsAffected, result.Error
+}
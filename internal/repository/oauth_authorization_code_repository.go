@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OAuthAuthorizationCodeRepository handles database operations for OAuth authorization codes.
+type OAuthAuthorizationCodeRepository struct {
+	DB *gorm.DB
+}
+
+// NewOAuthAuthorizationCodeRepository creates a new OAuthAuthorizationCodeRepository.
+func NewOAuthAuthorizationCodeRepository(db *gorm.DB) *OAuthAuthorizationCodeRepository {
+	return &OAuthAuthorizationCodeRepository{DB: db}
+}
+
+// Create stores a new authorization code.
+func (r *OAuthAuthorizationCodeRepository) Create(ctx context.Context, code *models.OAuthAuthorizationCode) error {
+	return r.DB.WithContext(ctx).Create(code).Error
+}
+
+// FindByHash finds the code with the given hash, whether or not it has been used or has expired.
+func (r *OAuthAuthorizationCodeRepository) FindByHash(ctx context.Context, hash string) (*models.OAuthAuthorizationCode, error) {
+	var code models.OAuthAuthorizationCode
+	if err := r.DB.WithContext(ctx).Where("code_hash = ?", hash).First(&code).Error; err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// Consume marks an unused, unexpired code with the given hash as used and returns
+// it. It returns gorm.ErrRecordNotFound if no such code exists, so each code is
+// exchanged at most once even under concurrent requests.
+func (r *OAuthAuthorizationCodeRepository) Consume(ctx context.Context, hash string, now time.Time) (*models.OAuthAuthorizationCode, error) {
+	var code models.OAuthAuthorizationCode
+	result := r.DB.WithContext(ctx).Model(&code).Clauses(clause.Returning{}).
+		Where("code_hash = ? AND used_at IS NULL AND expires_at > ?", hash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &code, nil
+}
+
+// DeleteExpired removes codes that have expired and returns how many were deleted.
+// Used codes are kept until then so that a replay can still be recognised.
+func (r *OAuthAuthorizationCodeRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.OAuthAuthorizationCode{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// OAuthClientRepository handles database operations for OAuth clients.
+type OAuthClientRepository struct {
+	DB *gorm.DB
+}
+
+// NewOAuthClientRepository creates a new OAuthClientRepository.
+func NewOAuthClientRepository(db *gorm.DB) *OAuthClientRepository {
+	return &OAuthClientRepository{DB: db}
+}
+
+// Create stores a new OAuth client.
+func (r *OAuthClientRepository) Create(ctx context.Context, client *models.OAuthClient) error {
+	return r.DB.WithContext(ctx).Create(client).Error
+}
+
+// FindByID finds an OAuth client by its client ID.
+func (r *OAuthClientRepository) FindByID(ctx context.Context, id string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	if err := r.DB.WithContext(ctx).Where("id = ?", id).First(&client).Error; err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// List returns every OAuth client, newest first.
+func (r *OAuthClientRepository) List(ctx context.Context) ([]models.OAuthClient, error) {
+	var clients []models.OAuthClient
+	if err := r.DB.WithContext(ctx).Order("created_at DESC, id").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// Revoke revokes an OAuth client. It reports false if there is no such client
+// that is still active.
+func (r *OAuthClientRepository) Revoke(ctx context.Context, id string, now time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&models.OAuthClient{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", now)
+	return result.RowsAffected == 1, result.Error
+}
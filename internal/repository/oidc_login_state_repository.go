@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OIDCLoginStateRepository handles database operations for pending external logins.
+type OIDCLoginStateRepository struct {
+	DB *gorm.DB
+}
+
+// NewOIDCLoginStateRepository creates a new OIDCLoginStateRepository.
+func NewOIDCLoginStateRepository(db *gorm.DB) *OIDCLoginStateRepository {
+	return &OIDCLoginStateRepository{DB: db}
+}
+
+// Create stores a new pending login.
+func (r *OIDCLoginStateRepository) Create(ctx context.Context, state *models.OIDCLoginState) error {
+	return r.DB.WithContext(ctx).Create(state).Error
+}
+
+// Consume marks an unused, unexpired login state with the given hash as used and
+// returns it. It returns gorm.ErrRecordNotFound if no such state exists, so each
+// state completes at most one login.
+func (r *OIDCLoginStateRepository) Consume(ctx context.Context, hash string, now time.Time) (*models.OIDCLoginState, error) {
+	var state models.OIDCLoginState
+	result := r.DB.WithContext(ctx).Model(&state).Clauses(clause.Returning{}).
+		Where("state_hash = ? AND used_at IS NULL AND expires_at > ?", hash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &state, nil
+}
+
+// DeleteExpired removes login states that have expired or been used and returns how many were deleted.
+func (r *OIDCLoginStateRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Where("expires_at <= ? OR used_at IS NOT NULL", now).Delete(&models.OIDCLoginState{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository handles database operations for two-factor recovery codes.
+type RecoveryCodeRepository struct {
+	DB *gorm.DB
+}
+
+// NewRecoveryCodeRepository creates a new RecoveryCodeRepository.
+func NewRecoveryCodeRepository(db *gorm.DB) *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{DB: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside the given database transaction.
+func (r *RecoveryCodeRepository) WithTx(tx *gorm.DB) *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{DB: tx}
+}
+
+// Replace deletes a user's recovery codes and stores the given code hashes in their place.
+func (r *RecoveryCodeRepository) Replace(ctx context.Context, userID uint, hashes []string) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume marks a user's unused recovery code as used. It reports false if no
+// such unused code exists.
+func (r *RecoveryCodeRepository) Consume(ctx context.Context, userID uint, hash string, now time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteByUserID removes all of a user's recovery codes.
+func (r *RecoveryCodeRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.DB.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MemoryRefreshTokenStore is a RefreshTokenStore kept in process memory, for
+// tests. FindByIDForUpdate takes no lock, so a token is only rotated once when
+// units of work run one at a time, as under MemoryTxManager. WithTx returns the
+// store itself, so writes made inside a transaction are not rolled back.
+type MemoryRefreshTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]models.RefreshToken
+}
+
+// NewMemoryRefreshTokenStore creates an empty MemoryRefreshTokenStore.
+func NewMemoryRefreshTokenStore() *MemoryRefreshTokenStore {
+	return &MemoryRefreshTokenStore{tokens: make(map[string]models.RefreshToken)}
+}
+
+// WithTx returns the store itself.
+func (s *MemoryRefreshTokenStore) WithTx(tx *gorm.DB) RefreshTokenStore {
+	return s
+}
+
+// Create saves a newly issued refresh token. It returns gorm.ErrDuplicatedKey if
+// the ID is taken.
+func (s *MemoryRefreshTokenStore) Create(ctx context.Context, token *models.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tokens[token.ID]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	token.CreatedAt = time.Now()
+	s.tokens[token.ID] = *token
+	return nil
+}
+
+// FindByIDForUpdate returns a copy of the refresh token with the given ID.
+func (s *MemoryRefreshTokenStore) FindByIDForUpdate(ctx context.Context, id string) (*models.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &token, nil
+}
+
+// Rotate marks a token as revoked and replaced by the token with jti replacedBy.
+func (s *MemoryRefreshTokenStore) Rotate(ctx context.Context, id, replacedBy string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token, ok := s.tokens[id]; ok {
+		revokedAt := now
+		token.RevokedAt = &revokedAt
+		token.ReplacedBy = replacedBy
+		s.tokens[id] = token
+	}
+	return nil
+}
+
+// RevokeFamily revokes every still-active token in a family.
+func (s *MemoryRefreshTokenStore) RevokeFamily(ctx context.Context, familyID string, now time.Time) error {
+	s.revokeWhere(func(token *models.RefreshToken) bool { return token.FamilyID == familyID }, now)
+	return nil
+}
+
+// RevokeAllForUser revokes every still-active token belonging to a user.
+func (s *MemoryRefreshTokenStore) RevokeAllForUser(ctx context.Context, userID uint, now time.Time) error {
+	s.revokeWhere(func(token *models.RefreshToken) bool { return token.UserID == userID }, now)
+	return nil
+}
+
+// DeleteExpired removes every token that expired before now and returns how many were removed.
+func (s *MemoryRefreshTokenStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deleted int64
+	for id, token := range s.tokens {
+		if token.ExpiresAt.Before(now) {
+			delete(s.tokens, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// revokeWhere revokes every still-active token matching match.
+func (s *MemoryRefreshTokenStore) revokeWhere(match func(token *models.RefreshToken) bool, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, token := range s.tokens {
+		if token.RevokedAt == nil && match(&token) {
+			revokedAt := now
+			token.RevokedAt = &revokedAt
+			s.tokens[id] = token
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RefreshTokenStore stores refresh tokens. RefreshTokenRepository keeps them in
+// the database and MemoryRefreshTokenStore in process memory, for tests.
+type RefreshTokenStore interface {
+	// WithTx returns a store that runs its queries inside the given database transaction.
+	WithTx(tx *gorm.DB) RefreshTokenStore
+	Create(ctx context.Context, token *models.RefreshToken) error
+	// FindByIDForUpdate finds a token, or returns gorm.ErrRecordNotFound, and
+	// locks it until the surrounding transaction ends.
+	FindByIDForUpdate(ctx context.Context, id string) (*models.RefreshToken, error)
+	Rotate(ctx context.Context, id, replacedBy string, now time.Time) error
+	RevokeFamily(ctx context.Context, familyID string, now time.Time) error
+	RevokeAllForUser(ctx context.Context, userID uint, now time.Time) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// RefreshTokenRepository is the database implementation of RefreshTokenStore.
+type RefreshTokenRepository struct {
+	DB *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new RefreshTokenRepository.
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{DB: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside the given database transaction.
+func (r *RefreshTokenRepository) WithTx(tx *gorm.DB) RefreshTokenStore {
+	return &RefreshTokenRepository{DB: tx}
+}
+
+// Create stores a newly issued refresh token.
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return r.DB.WithContext(ctx).Create(token).Error
+}
+
+// FindByIDForUpdate finds a refresh token and locks its row until the surrounding
+// transaction ends, so that a token can only be rotated once.
+func (r *RefreshTokenRepository) FindByIDForUpdate(ctx context.Context, id string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.DB.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&token, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Rotate marks a token as revoked and replaced by the token with jti replacedBy.
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, id, replacedBy string, now time.Time) error {
+	return r.DB.WithContext(ctx).Model(&models.RefreshToken{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"revoked_at": now, "replaced_by": replacedBy}).Error
+}
+
+// RevokeFamily revokes every still-active token in a family.
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, now time.Time) error {
+	return r.DB.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error
+}
+
+// RevokeAllForUser revokes every still-active token belonging to a user.
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uint, now time.Time) error {
+	return r.DB.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+// DeleteExpired removes every token that expired before now and returns how many were removed.
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RoleRepository handles database operations for roles and their permissions.
+type RoleRepository struct {
+	DB *gorm.DB
+}
+
+// NewRoleRepository creates a new RoleRepository.
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{DB: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside the given database transaction.
+func (r *RoleRepository) WithTx(tx *gorm.DB) *RoleRepository {
+	return &RoleRepository{DB: tx}
+}
+
+// List returns every role with its permissions, ordered by name.
+func (r *RoleRepository) List(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	if err := r.DB.WithContext(ctx).Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	var grants []models.RolePermission
+	if err := r.DB.WithContext(ctx).Order("permission").Find(&grants).Error; err != nil {
+		return nil, err
+	}
+
+	byRole := make(map[uint][]string)
+	for _, grant := range grants {
+		byRole[grant.RoleID] = append(byRole[grant.RoleID], grant.Permission)
+	}
+	for i := range roles {
+		roles[i].Permissions = byRole[roles[i].ID]
+		if roles[i].Permissions == nil {
+			roles[i].Permissions = []string{}
+		}
+	}
+	return roles, nil
+}
+
+// FindByName finds a role with its permissions.
+func (r *RoleRepository) FindByName(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	if err := r.DB.WithContext(ctx).Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	role.Permissions = []string{}
+	if err := r.DB.WithContext(ctx).Model(&models.RolePermission{}).Where("role_id = ?", role.ID).
+		Order("permission").Pluck("permission", &role.Permissions).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// Create stores a new role and its permissions.
+func (r *RoleRepository) Create(ctx context.Context, role *models.Role) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(role).Error; err != nil {
+			return err
+		}
+		return r.WithTx(tx).replacePermissions(ctx, role.ID, role.Permissions)
+	})
+}
+
+// Update saves a role's description and replaces its permissions.
+func (r *RoleRepository) Update(ctx context.Context, role *models.Role) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(role).Update("description", role.Description).Error; err != nil {
+			return err
+		}
+		return r.WithTx(tx).replacePermissions(ctx, role.ID, role.Permissions)
+	})
+}
+
+// Delete removes a role and its permission grants.
+func (r *RoleRepository) Delete(ctx context.Context, id uint) error {
+	return r.DB.WithContext(ctx).Delete(&models.Role{}, id).Error
+}
+
+// CountUsers returns how many users hold the named role.
+func (r *RoleRepository) CountUsers(ctx context.Context, name string) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
+
+// ListPermissions returns every permission, ordered by name.
+func (r *RoleRepository) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	var permissions []models.Permission
+	if err := r.DB.WithContext(ctx).Order("name").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// CountPermissions returns how many of the named permissions exist.
+func (r *RoleRepository) CountPermissions(ctx context.Context, names []string) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.Permission{}).Where("name IN ?", names).Count(&count).Error
+	return count, err
+}
+
+func (r *RoleRepository) replacePermissions(ctx context.Context, roleID uint, permissions []string) error {
+	if err := r.DB.WithContext(ctx).Where("role_id = ?", roleID).Delete(&models.RolePermission{}).Error; err != nil {
+		return err
+	}
+	if len(permissions) == 0 {
+		return nil
+	}
+	grants := make([]models.RolePermission, len(permissions))
+	for i, permission := range permissions {
+		grants[i] = models.RolePermission{RoleID: roleID, Permission: permission}
+	}
+	return r.DB.WithContext(ctx).Create(&grants).Error
+}
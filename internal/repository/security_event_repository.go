@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SecurityEventRepository handles database operations for security events.
+type SecurityEventRepository struct {
+	DB *gorm.DB
+}
+
+// NewSecurityEventRepository creates a new SecurityEventRepository.
+func NewSecurityEventRepository(db *gorm.DB) *SecurityEventRepository {
+	return &SecurityEventRepository{DB: db}
+}
+
+// Create records a security event.
+func (r *SecurityEventRepository) Create(ctx context.Context, event *models.SecurityEvent) error {
+	return r.DB.WithContext(ctx).Create(event).Error
+}
+
+// List returns the most recent events, newest first, optionally only those of one user.
+func (r *SecurityEventRepository) List(ctx context.Context, userID *uint, limit int) ([]models.SecurityEvent, error) {
+	query := r.DB.WithContext(ctx).Order("created_at DESC, id DESC").Limit(limit)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	var events []models.SecurityEvent
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
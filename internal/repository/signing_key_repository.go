@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SigningKeyRepository records the active JWT signing key in the database, so
+// that every instance signs with the same key. It implements jwtkeys.ActiveKeyStore.
+type SigningKeyRepository struct {
+	DB *gorm.DB
+}
+
+// NewSigningKeyRepository creates a new SigningKeyRepository.
+func NewSigningKeyRepository(db *gorm.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{DB: db}
+}
+
+// ActiveKey returns the active kid, or "" if none has been recorded.
+func (r *SigningKeyRepository) ActiveKey(ctx context.Context) (string, error) {
+	var active models.ActiveSigningKey
+	err := r.DB.WithContext(ctx).First(&active, 1).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	return active.KID, err
+}
+
+// SetActiveKey records kid as active if previous is still the active kid ("" for
+// none), and reports whether it did.
+func (r *SigningKeyRepository) SetActiveKey(ctx context.Context, previous, kid string) (bool, error) {
+	db := r.DB.WithContext(ctx)
+	if previous == "" {
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ActiveSigningKey{ID: 1, KID: kid})
+		return result.RowsAffected == 1, result.Error
+	}
+	result := db.Model(&models.ActiveSigningKey{}).
+		Where("id = 1 AND kid = ?", previous).
+		Updates(map[string]interface{}{"kid": kid, "updated_at": time.Now()})
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MemoryTransactionStore is a TransactionStore kept in process memory, for tests.
+// It enforces unique references and applies TransactionFilter as the database
+// query does. WithTx returns the store itself, so writes made inside a
+// transaction are not rolled back.
+type MemoryTransactionStore struct {
+	mu           sync.Mutex
+	transactions []models.Transaction
+	nextID       uint
+}
+
+// NewMemoryTransactionStore creates an empty MemoryTransactionStore.
+func NewMemoryTransactionStore() *MemoryTransactionStore {
+	return &MemoryTransactionStore{nextID: 1}
+}
+
+// WithTx returns the store itself.
+func (s *MemoryTransactionStore) WithTx(tx *gorm.DB) TransactionStore {
+	return s
+}
+
+// Create saves a new transaction, assigning its ID. It returns
+// gorm.ErrDuplicatedKey if the reference is taken.
+func (s *MemoryTransactionStore) Create(ctx context.Context, transaction *models.Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.transactions {
+		if transaction.Reference != "" && existing.Reference == transaction.Reference {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+
+	now := time.Now()
+	transaction.ID = s.nextID
+	s.nextID++
+	if transaction.CreatedAt.IsZero() {
+		transaction.CreatedAt = now
+	}
+	transaction.UpdatedAt = now
+	if transaction.Status == "" {
+		transaction.Status = "pending"
+	}
+	s.transactions = append(s.transactions, *transaction)
+	return nil
+}
+
+// FindByID finds a transaction by ID.
+func (s *MemoryTransactionStore) FindByID(ctx context.Context, id uint) (*models.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, transaction := range s.transactions {
+		if transaction.ID == id {
+			return &transaction, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// MarkReversed marks a completed transaction, and the other leg of a transfer, as reversed.
+func (s *MemoryTransactionStore) MarkReversed(ctx context.Context, transaction *models.Transaction) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	marked := false
+	for i := range s.transactions {
+		existing := &s.transactions[i]
+		sameTransfer := transaction.TransferReference != "" && existing.TransferReference == transaction.TransferReference
+		if existing.Status == "completed" && (existing.ID == transaction.ID || sameTransfer) {
+			existing.Status = "reversed"
+			existing.UpdatedAt = time.Now()
+			marked = true
+		}
+	}
+	return marked, nil
+}
+
+// FindByWalletID finds all transactions for a given wallet ID, oldest first.
+func (s *MemoryTransactionStore) FindByWalletID(ctx context.Context, walletID uint) ([]models.Transaction, error) {
+	return s.List(ctx, TransactionFilter{WalletID: walletID, Ascending: true})
+}
+
+// List returns a page of a wallet's transactions ordered by created_at and id.
+func (s *MemoryTransactionStore) List(ctx context.Context, filter TransactionFilter) ([]models.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var transactions []models.Transaction
+	for _, transaction := range s.transactions {
+		if matchesFilter(&transaction, &filter) {
+			transactions = append(transactions, transaction)
+		}
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactionBefore(&transactions[i], &transactions[j]) == filter.Ascending
+	})
+	if filter.Limit > 0 && len(transactions) > filter.Limit {
+		transactions = transactions[:filter.Limit]
+	}
+	return transactions, nil
+}
+
+// matchesFilter reports whether transaction is in the page filter describes.
+func matchesFilter(transaction *models.Transaction, filter *TransactionFilter) bool {
+	switch {
+	case transaction.WalletID != filter.WalletID,
+		filter.Type != "" && transaction.Type != filter.Type,
+		filter.Status != "" && transaction.Status != filter.Status,
+		filter.MinAmount != nil && transaction.Amount.Amount < *filter.MinAmount,
+		filter.MaxAmount != nil && transaction.Amount.Amount > *filter.MaxAmount,
+		filter.From != nil && transaction.CreatedAt.Before(*filter.From),
+		filter.To != nil && transaction.CreatedAt.After(*filter.To),
+		filter.Reference != "" && !strings.Contains(strings.ToLower(transaction.Reference), strings.ToLower(filter.Reference)):
+		return false
+	}
+	if filter.AfterCreatedAt != nil {
+		cursor := models.Transaction{ID: filter.AfterID, CreatedAt: *filter.AfterCreatedAt}
+		if filter.Ascending {
+			return transactionBefore(&cursor, transaction)
+		}
+		return transactionBefore(transaction, &cursor)
+	}
+	return true
+}
+
+// transactionBefore reports whether a sorts before b by (created_at, id).
+func transactionBefore(a, b *models.Transaction) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
@@ -1,32 +1,150 @@
 package repository
 
 import (
-	"wallet-service/internal/db"
+	"context"
+	"strings"
+	"time"
 	"wallet-service/internal/models"
 
 	"gorm.io/gorm"
 )
 
-// TransactionRepository handles database operations for transactions.
+// TransactionFilter narrows and pages a wallet's transaction history.
+// Nil and empty fields are not filtered on.
+type TransactionFilter struct {
+	WalletID  uint
+	Type      string
+	Status    string
+	MinAmount *int64 // minor units, inclusive
+	MaxAmount *int64 // minor units, inclusive
+	From      *time.Time
+	To        *time.Time
+	Reference string // case-insensitive substring
+
+	// Keyset pagination: rows strictly after (AfterCreatedAt, AfterID) in the requested order.
+	AfterCreatedAt *time.Time
+	AfterID        uint
+	Ascending      bool
+	Limit          int
+}
+
+// TransactionStore stores wallet transactions. TransactionRepository keeps them in
+// the database and MemoryTransactionStore in process memory, for tests.
+type TransactionStore interface {
+	// WithTx returns a store that runs its queries inside the given database transaction.
+	WithTx(tx *gorm.DB) TransactionStore
+	Create(ctx context.Context, transaction *models.Transaction) error
+	// FindByID finds a transaction by ID, or returns gorm.ErrRecordNotFound.
+	FindByID(ctx context.Context, id uint) (*models.Transaction, error)
+	// MarkReversed marks a completed transaction, and the other leg of a transfer,
+	// as reversed. It reports false if the transaction is no longer completed.
+	MarkReversed(ctx context.Context, transaction *models.Transaction) (bool, error)
+	// FindByWalletID finds all transactions for a wallet, oldest first.
+	FindByWalletID(ctx context.Context, walletID uint) ([]models.Transaction, error)
+	// List returns a page of a wallet's transactions matching filter.
+	List(ctx context.Context, filter TransactionFilter) ([]models.Transaction, error)
+}
+
+// TransactionRepository is the database implementation of TransactionStore.
 type TransactionRepository struct {
 	DB *gorm.DB
 }
 
 // NewTransactionRepository creates a new TransactionRepository.
-func NewTransactionRepository() *TransactionRepository {
-	return &TransactionRepository{DB: db.DB}
+func NewTransactionRepository(db *gorm.DB) *TransactionRepository {
+	return &TransactionRepository{DB: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside the given database transaction.
+func (r *TransactionRepository) WithTx(tx *gorm.DB) TransactionStore {
+	return &TransactionRepository{DB: tx}
 }
 
 // Create creates a new transaction in the database.
-func (r *TransactionRepository) Create(transaction *models.Transaction) error {
-	return r.DB.Create(transaction).Error
+func (r *TransactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
+	return r.DB.WithContext(ctx).Create(transaction).Error
+}
+
+// FindByID finds a transaction by ID.
+func (r *TransactionRepository) FindByID(ctx context.Context, id uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := r.DB.WithContext(ctx).First(&transaction, id).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// MarkReversed marks a completed transaction, and the other leg of a transfer, as
+// reversed. The status check makes concurrent reversals of one transaction fail
+// for all but the first.
+func (r *TransactionRepository) MarkReversed(ctx context.Context, transaction *models.Transaction) (bool, error) {
+	query := r.DB.WithContext(ctx).Model(&models.Transaction{}).Where("status = ?", "completed")
+	if transaction.TransferReference != "" {
+		query = query.Where("id = ? OR transfer_reference = ?", transaction.ID, transaction.TransferReference)
+	} else {
+		query = query.Where("id = ?", transaction.ID)
+	}
+	result := query.Update("status", "reversed")
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindByWalletID finds all transactions for a given wallet ID, oldest first.
+func (r *TransactionRepository) FindByWalletID(ctx context.Context, walletID uint) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	if err := r.DB.WithContext(ctx).Where("wallet_id = ?", walletID).Order("created_at, id").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	return transactions, nil
 }
 
-// FindByWalletID finds all transactions for a given wallet ID.
-func (r *TransactionRepository) FindByWalletID(walletID uint) ([]models.Transaction, error) {
+// List returns a page of a wallet's transactions ordered by created_at and id.
+// It walks the (wallet_id, created_at, id) index rather than using OFFSET.
+func (r *TransactionRepository) List(ctx context.Context, filter TransactionFilter) ([]models.Transaction, error) {
+	query := r.DB.WithContext(ctx).Where("wallet_id = ?", filter.WalletID)
+
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.MinAmount != nil {
+		query = query.Where("amount >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query = query.Where("amount <= ?", *filter.MaxAmount)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at <= ?", *filter.To)
+	}
+	if filter.Reference != "" {
+		query = query.Where("reference ILIKE ? ESCAPE '\\'", "%"+escapeLike(filter.Reference)+"%")
+	}
+
+	direction := "DESC"
+	if filter.Ascending {
+		direction = "ASC"
+	}
+	if filter.AfterCreatedAt != nil {
+		comparison := "<"
+		if filter.Ascending {
+			comparison = ">"
+		}
+		query = query.Where("(created_at, id) "+comparison+" (?, ?)", *filter.AfterCreatedAt, filter.AfterID)
+	}
+
 	var transactions []models.Transaction
-	if err := r.DB.Where("wallet_id = ?", walletID).Find(&transactions).Error; err != nil {
+	err := query.Order("created_at " + direction + ", id " + direction).Limit(filter.Limit).Find(&transactions).Error
+	if err != nil {
 		return nil, err
 	}
 	return transactions, nil
 }
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
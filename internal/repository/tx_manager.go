@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxTxAttempts is how many times WithinTx runs a unit of work that keeps
+	// failing with a serialization failure or deadlock.
+	maxTxAttempts = 3
+	// txRetryBackoff is the wait before the first retry; it doubles for each later one.
+	txRetryBackoff = 20 * time.Millisecond
+)
+
+// Repositories are the repositories a unit of work runs on. Inside
+// TxManager.WithinTx every one of them is bound to the same database transaction.
+type Repositories struct {
+	Users         UserStore
+	Wallets       WalletStore
+	Transactions  TransactionStore
+	Ledger        LedgerStore
+	UserTokens    UserTokenStore
+	RefreshTokens RefreshTokenStore
+}
+
+// TxManager runs units of work that span several repositories.
+type TxManager interface {
+	// WithinTx calls fn with repositories bound to a single transaction, which is
+	// committed if fn returns nil and rolled back if it returns an error or panics.
+	// fn may be called more than once, so it must not have effects outside repos
+	// that cannot be repeated.
+	WithinTx(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+// GormTxManager is the database implementation of TxManager. A unit of work that
+// fails with a serialization failure or deadlock is rolled back and run again in
+// a new transaction, up to maxTxAttempts times.
+type GormTxManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager creates a GormTxManager that opens its transactions on db.
+func NewTxManager(db *gorm.DB) *GormTxManager {
+	return &GormTxManager{db: db}
+}
+
+// WithinTx runs fn in a database transaction, retrying it on serialization failures.
+func (m *GormTxManager) WithinTx(ctx context.Context, fn func(repos Repositories) error) error {
+	return retryTx(ctx, func() error {
+		return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(Repositories{
+				Users:         NewUserRepository(tx),
+				Wallets:       NewWalletRepository(tx),
+				Transactions:  NewTransactionRepository(tx),
+				Ledger:        NewLedgerRepository(tx),
+				UserTokens:    NewUserTokenRepository(tx),
+				RefreshTokens: NewRefreshTokenRepository(tx),
+			})
+		})
+	})
+}
+
+// retryTx calls run until it succeeds, fails with an error that is not
+// retryable, or has been called maxTxAttempts times, and returns its last error.
+func retryTx(ctx context.Context, run func() error) error {
+	backoff := txRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := run()
+		if err == nil || attempt == maxTxAttempts || !IsRetryable(err) {
+			return err
+		}
+
+		log.Printf("Retrying transaction after attempt %d failed: %v", attempt, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// IsRetryable reports whether err is a serialization failure or deadlock, after
+// which the whole transaction can succeed if run again.
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	// serialization_failure and deadlock_detected
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
+
+// MemoryTxManager is a TxManager for tests that hands every unit of work the same
+// repositories, typically the in-memory stores. Units of work run one at a time,
+// but their writes are not rolled back when they fail.
+type MemoryTxManager struct {
+	mu    sync.Mutex
+	repos Repositories
+}
+
+// NewMemoryTxManager creates a MemoryTxManager for repos. Fields left nil are
+// passed on as nil, so set the ones the code under test uses.
+func NewMemoryTxManager(repos Repositories) *MemoryTxManager {
+	return &MemoryTxManager{repos: repos}
+}
+
+// WithinTx calls fn once with the manager's repositories.
+func (m *MemoryTxManager) WithinTx(ctx context.Context, fn func(repos Repositories) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fn(m.repos)
+}
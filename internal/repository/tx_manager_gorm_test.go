@@ -0,0 +1,83 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"wallet-service/internal/db/dbtest"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/pkg/money"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestGormTxManager_CommitAndRollback(t *testing.T) {
+	gormDB := dbtest.Open(t)
+	ctx := context.Background()
+	manager := repository.NewTxManager(gormDB)
+	wallets := repository.NewWalletRepository(gormDB)
+	user := dbtest.CreateUser(t, gormDB)
+
+	createWallet := func(repos repository.Repositories) error {
+		return repos.Wallets.Create(ctx, &models.Wallet{UserID: user.ID, Name: "Test wallet", Currency: "USD", Balance: money.Zero("USD"), IsActive: true})
+	}
+	count := func() int64 {
+		t.Helper()
+		n, err := wallets.CountByUserID(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("CountByUserID: %v", err)
+		}
+		return n
+	}
+
+	if err := manager.WithinTx(ctx, createWallet); err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+	if n := count(); n != 1 {
+		t.Fatalf("after committing: user has %d wallets, want 1", n)
+	}
+
+	failure := errors.New("boom")
+	err := manager.WithinTx(ctx, func(repos repository.Repositories) error {
+		if err := createWallet(repos); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithinTx: err = %v, want the unit of work's error", err)
+	}
+	if n := count(); n != 1 {
+		t.Fatalf("after rolling back: user has %d wallets, want 1", n)
+	}
+}
+
+func TestGormTxManager_RetryExhaustion(t *testing.T) {
+	gormDB := dbtest.Open(t)
+	ctx := context.Background()
+	manager := repository.NewTxManager(gormDB)
+	user := dbtest.CreateUser(t, gormDB)
+
+	for _, code := range []string{"40001", "40P01"} {
+		calls := 0
+		err := manager.WithinTx(ctx, func(repos repository.Repositories) error {
+			calls++
+			if err := repos.Wallets.Create(ctx, &models.Wallet{UserID: user.ID, Name: "Test wallet", Currency: "USD", Balance: money.Zero("USD"), IsActive: true}); err != nil {
+				return err
+			}
+			return &pgconn.PgError{Code: code}
+		})
+		if !repository.IsRetryable(err) || calls != 3 {
+			t.Fatalf("%s on every attempt: err = %v after %d calls, want it returned after 3", code, err, calls)
+		}
+	}
+
+	n, err := repository.NewWalletRepository(gormDB).CountByUserID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("CountByUserID: %v", err)
+	}
+	if n != 0 {
+		t.Fatalf("every attempt was rolled back, but the user has %d wallets", n)
+	}
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"wallet-service/internal/models"
+	"wallet-service/pkg/money"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestMemoryTxManager_Commit(t *testing.T) {
+	ctx := context.Background()
+	wallets := NewMemoryWalletStore()
+	manager := NewMemoryTxManager(Repositories{Wallets: wallets})
+
+	err := manager.WithinTx(ctx, func(repos Repositories) error {
+		return repos.Wallets.Create(ctx, &models.Wallet{UserID: 1, Currency: "USD", Balance: money.Zero("USD")})
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+	if count, _ := wallets.CountByUserID(ctx, 1); count != 1 {
+		t.Fatalf("after committing: user has %d wallets, want 1", count)
+	}
+}
+
+func TestMemoryTxManager_Rollback(t *testing.T) {
+	ctx := context.Background()
+	wallets := NewMemoryWalletStore()
+	manager := NewMemoryTxManager(Repositories{Wallets: wallets})
+	failure := errors.New("boom")
+
+	// The error is returned, but the memory stores keep writes made before it.
+	err := manager.WithinTx(ctx, func(repos Repositories) error {
+		if err := repos.Wallets.Create(ctx, &models.Wallet{UserID: 1, Currency: "USD", Balance: money.Zero("USD")}); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithinTx: err = %v, want the unit of work's error", err)
+	}
+	if count, _ := wallets.CountByUserID(ctx, 1); count != 1 {
+		t.Fatalf("after failing: user has %d wallets, want the 1 written before the error", count)
+	}
+
+	// A failing unit of work is not retried, even with a retryable error.
+	calls := 0
+	err = manager.WithinTx(ctx, func(repos Repositories) error {
+		calls++
+		return &pgconn.PgError{Code: "40001"}
+	})
+	if !IsRetryable(err) || calls != 1 {
+		t.Fatalf("retryable failure: err = %v after %d calls, want it returned after 1", err, calls)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = manager.WithinTx(cancelled, func(repos Repositories) error {
+		t.Fatal("unit of work ran with a cancelled context")
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled context: err = %v, want context.Canceled", err)
+	}
+}
+
+func TestRetryTx(t *testing.T) {
+	ctx := context.Background()
+	failure := errors.New("boom")
+
+	for _, code := range []string{"40001", "40P01"} {
+		calls := 0
+		err := retryTx(ctx, func() error {
+			calls++
+			return fmt.Errorf("commit: %w", &pgconn.PgError{Code: code})
+		})
+		if !IsRetryable(err) || calls != maxTxAttempts {
+			t.Errorf("%s on every attempt: err = %v after %d calls, want it returned after %d", code, err, calls, maxTxAttempts)
+		}
+
+		calls = 0
+		err = retryTx(ctx, func() error {
+			calls++
+			if calls == 1 {
+				return &pgconn.PgError{Code: code}
+			}
+			return nil
+		})
+		if err != nil || calls != 2 {
+			t.Errorf("%s once: err = %v after %d calls, want success after 2", code, err, calls)
+		}
+	}
+
+	calls := 0
+	err := retryTx(ctx, func() error {
+		calls++
+		return failure
+	})
+	if !errors.Is(err, failure) || calls != 1 {
+		t.Errorf("other error: err = %v after %d calls, want it returned after 1", err, calls)
+	}
+
+	calls = 0
+	err = retryTx(ctx, func() error {
+		calls++
+		return &pgconn.PgError{Code: "23505"}
+	})
+	if calls != 1 {
+		t.Errorf("unique violation: %d calls, want 1 (err = %v)", calls, err)
+	}
+
+	// A context cancelled while waiting to retry ends the retries.
+	cancelled, cancel := context.WithCancel(ctx)
+	calls = 0
+	err = retryTx(cancelled, func() error {
+		calls++
+		cancel()
+		return &pgconn.PgError{Code: "40001"}
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("cancelled while waiting: err = %v after %d calls, want context.Canceled after 1", err, calls)
+	}
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// UserIdentityRepository handles database operations for users' external identities.
+type UserIdentityRepository struct {
+	DB *gorm.DB
+}
+
+// NewUserIdentityRepository creates a new UserIdentityRepository.
+func NewUserIdentityRepository(db *gorm.DB) *UserIdentityRepository {
+	return &UserIdentityRepository{DB: db}
+}
+
+// Create links an external identity to a user.
+func (r *UserIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	return r.DB.WithContext(ctx).Create(identity).Error
+}
+
+// FindBySubject finds the identity assigned subject by the provider issuer.
+func (r *UserIdentityRepository) FindBySubject(ctx context.Context, issuer, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := r.DB.WithContext(ctx).Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MemoryUserStore is a UserStore kept in process memory, for tests. It hashes
+// passwords on Create as the database's BeforeCreate hook does and enforces
+// unique email addresses. FindByIDForUpdate takes no lock. WithTx returns the store itself, so writes made
+// inside a transaction are not rolled back.
+type MemoryUserStore struct {
+	mu     sync.Mutex
+	users  map[uint]models.User
+	nextID uint
+}
+
+// NewMemoryUserStore creates an empty MemoryUserStore.
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{users: make(map[uint]models.User), nextID: 1}
+}
+
+// WithTx returns the store itself.
+func (s *MemoryUserStore) WithTx(tx *gorm.DB) UserStore {
+	return s
+}
+
+// GetAll returns copies of every user, ordered by ID.
+func (s *MemoryUserStore) GetAll(ctx context.Context) ([]models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := make([]models.User, 0, len(s.users))
+	for _, user := range s.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+// FindByID returns a copy of the user with the given ID.
+func (s *MemoryUserStore) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &user, nil
+}
+
+// FindByIDForUpdate returns a copy of the user with the given ID.
+func (s *MemoryUserStore) FindByIDForUpdate(ctx context.Context, id uint) (*models.User, error) {
+	return s.FindByID(ctx, id)
+}
+
+// FindByEmail returns a copy of the user with the given email address.
+func (s *MemoryUserStore) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// Create saves a new user, assigning its ID and hashing its password. It returns
+// gorm.ErrDuplicatedKey if the email address is taken.
+func (s *MemoryUserStore) Create(ctx context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.users {
+		if existing.Email == user.Email {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	if err := user.BeforeCreate(nil); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.ID = s.nextID
+	s.nextID++
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	user.CreatedAt, user.UpdatedAt = now, now
+	s.users[user.ID] = *user
+	return nil
+}
+
+// Update saves every field of an existing user.
+func (s *MemoryUserStore) Update(ctx context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user.UpdatedAt = time.Now()
+	s.users[user.ID] = *user
+	return nil
+}
+
+// Delete removes the user with the given ID.
+func (s *MemoryUserStore) Delete(ctx context.Context, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.users, id)
+	return nil
+}
+
+// DeleteAll removes every user.
+func (s *MemoryUserStore) DeleteAll(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = make(map[uint]models.User)
+	return nil
+}
+
+// UpdatePassword replaces the user's password hash.
+func (s *MemoryUserStore) UpdatePassword(ctx context.Context, id uint, hash string) error {
+	return s.modify(id, func(user *models.User) bool {
+		user.Password = hash
+		return true
+	})
+}
+
+// UpdateRole changes the user's role.
+func (s *MemoryUserStore) UpdateRole(ctx context.Context, id uint, role string) error {
+	return s.modify(id, func(user *models.User) bool {
+		user.Role = role
+		return true
+	})
+}
+
+// UpdateTOTP sets the user's TOTP secret and whether two-factor is enabled.
+func (s *MemoryUserStore) UpdateTOTP(ctx context.Context, id uint, secret string, enabled bool) error {
+	return s.modify(id, func(user *models.User) bool {
+		user.TOTPSecret, user.TOTPEnabled = secret, enabled
+		return true
+	})
+}
+
+// AdvanceTOTPStep records step as the user's last accepted TOTP step. It reports
+// false if a code from that step or a later one was already accepted.
+func (s *MemoryUserStore) AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error) {
+	advanced := false
+	err := s.modify(id, func(user *models.User) bool {
+		if user.TOTPLastStep >= step {
+			return false
+		}
+		user.TOTPLastStep, advanced = step, true
+		return true
+	})
+	return advanced, err
+}
+
+// MarkEmailVerified records that the user verified their email address, keeping
+// the original time if it was already verified.
+func (s *MemoryUserStore) MarkEmailVerified(ctx context.Context, id uint, now time.Time) error {
+	return s.modify(id, func(user *models.User) bool {
+		if user.EmailVerifiedAt != nil {
+			return false
+		}
+		user.EmailVerifiedAt = &now
+		return true
+	})
+}
+
+// TokenVersion returns the user's current token version.
+func (s *MemoryUserStore) TokenVersion(ctx context.Context, id uint) (int, error) {
+	user, err := s.FindByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	return user.TokenVersion, nil
+}
+
+// BumpTokenVersion increments the user's token version.
+func (s *MemoryUserStore) BumpTokenVersion(ctx context.Context, id uint) error {
+	return s.modify(id, func(user *models.User) bool {
+		user.TokenVersion++
+		return true
+	})
+}
+
+// BumpTokenVersionForRole increments the token version of every user holding the role.
+func (s *MemoryUserStore) BumpTokenVersionForRole(ctx context.Context, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, user := range s.users {
+		if user.Role == role {
+			user.TokenVersion++
+			s.users[id] = user
+		}
+	}
+	return nil
+}
+
+// modify applies change to the stored user, if it exists, and saves the result
+// when change reports that it changed something. Like an UPDATE matching no
+// rows, a missing user is not an error.
+func (s *MemoryUserStore) modify(id uint, change func(user *models.User) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	if !ok {
+		return nil
+	}
+	if change(&user) {
+		user.UpdatedAt = time.Now()
+		s.users[id] = user
+	}
+	return nil
+}
@@ -1,56 +1,151 @@
 package repository
 
 import (
-	"wallet-service/internal/db"
+	"context"
+	"time"
 	"wallet-service/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// UserStore stores users. UserRepository keeps them in the database and
+// MemoryUserStore in process memory, for tests. Lookups of missing users
+// return gorm.ErrRecordNotFound.
+type UserStore interface {
+	// WithTx returns a store that runs its queries inside the given database transaction.
+	WithTx(tx *gorm.DB) UserStore
+	GetAll(ctx context.Context) ([]models.User, error)
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	// FindByIDForUpdate finds a user and locks their row until the surrounding
+	// transaction ends, serialising changes that depend on the user's other rows.
+	FindByIDForUpdate(ctx context.Context, id uint) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	// Create saves a new user, hashing their password.
+	Create(ctx context.Context, user *models.User) error
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id uint) error
+	DeleteAll(ctx context.Context) error
+	// UpdatePassword replaces the user's password hash.
+	UpdatePassword(ctx context.Context, id uint, hash string) error
+	// UpdateRole changes the user's role, leaving their other columns untouched.
+	UpdateRole(ctx context.Context, id uint, role string) error
+	// UpdateTOTP sets the user's TOTP secret and whether two-factor is enabled.
+	UpdateTOTP(ctx context.Context, id uint, secret string, enabled bool) error
+	AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error)
+	MarkEmailVerified(ctx context.Context, id uint, now time.Time) error
+	TokenVersion(ctx context.Context, id uint) (int, error)
+	BumpTokenVersion(ctx context.Context, id uint) error
+	BumpTokenVersionForRole(ctx context.Context, role string) error
+}
+
+// UserRepository is the database implementation of UserStore.
 type UserRepository struct {
 	DB *gorm.DB
 }
 
-func NewUserRepository() *UserRepository {
-	return &UserRepository{DB: db.DB}
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{DB: db}
+}
+
+func (r *UserRepository) WithTx(tx *gorm.DB) UserStore {
+	return &UserRepository{DB: tx}
 }
 
-func (r *UserRepository) GetAll() ([]models.User, error) {
+func (r *UserRepository) GetAll(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	if err := r.DB.Find(&users).Error; err != nil {
+	if err := r.DB.WithContext(ctx).Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 
-func (r *UserRepository) FindByID(id uint) (*models.User, error) {
+func (r *UserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.DB.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByIDForUpdate finds a user and locks their row until the surrounding
+// transaction ends. It must be called within a transaction.
+func (r *UserRepository) FindByIDForUpdate(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	if err := r.DB.First(&user, id).Error; err != nil {
+	if err := r.DB.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, id).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	if err := r.DB.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.DB.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) Create(user *models.User) error {
-	return r.DB.Create(user).Error
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.DB.WithContext(ctx).Create(user).Error
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	return r.DB.WithContext(ctx).Save(user).Error
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id uint) error {
+	return r.DB.WithContext(ctx).Delete(&models.User{}, id).Error
 }
 
-func (r *UserRepository) Update(user *models.User) error {
-	return r.DB.Save(user).Error
+func (r *UserRepository) DeleteAll(ctx context.Context) error {
+	return r.DB.WithContext(ctx).Exec("DELETE FROM users").Error
+}
+
+// UpdatePassword replaces the user's password hash.
+func (r *UserRepository) UpdatePassword(ctx context.Context, id uint, hash string) error {
+	return r.DB.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("password", hash).Error
+}
+
+// UpdateRole changes the user's role, leaving their other columns untouched.
+func (r *UserRepository) UpdateRole(ctx context.Context, id uint, role string) error {
+	return r.DB.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+// UpdateTOTP sets the user's TOTP secret and whether two-factor is enabled.
+func (r *UserRepository) UpdateTOTP(ctx context.Context, id uint, secret string, enabled bool) error {
+	return r.DB.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"totp_secret": secret, "totp_enabled": enabled}).Error
+}
+
+// AdvanceTOTPStep records step as the user's last accepted TOTP step. It reports
+// false if a code from that step or a later one was already accepted.
+func (r *UserRepository) AdvanceTOTPStep(ctx context.Context, id uint, step int64) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&models.User{}).Where("id = ? AND totp_last_step < ?", id, step).Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+// MarkEmailVerified records that the user verified their email address, keeping
+// the original time if it was already verified.
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id uint, now time.Time) error {
+	return r.DB.WithContext(ctx).Model(&models.User{}).Where("id = ? AND email_verified_at IS NULL", id).Update("email_verified_at", now).Error
+}
+
+// TokenVersion returns the user's current token version.
+func (r *UserRepository) TokenVersion(ctx context.Context, id uint) (int, error) {
+	var user models.User
+	if err := r.DB.WithContext(ctx).Select("token_version").First(&user, id).Error; err != nil {
+		return 0, err
+	}
+	return user.TokenVersion, nil
 }
 
-func (r *UserRepository) Delete(id uint) error {
-	return r.DB.Delete(&models.User{}, id).Error
+// BumpTokenVersion increments the user's token version.
+func (r *UserRepository) BumpTokenVersion(ctx context.Context, id uint) error {
+	return r.DB.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("token_version", gorm.Expr("token_version + 1")).Error
 }
 
-func (r *UserRepository) DeleteAll() error {
-	return r.DB.Exec("DELETE FROM users").Error
+// BumpTokenVersionForRole increments the token version of every user holding the role.
+func (r *UserRepository) BumpTokenVersionForRole(ctx context.Context, role string) error {
+	return r.DB.WithContext(ctx).Model(&models.User{}).Where("role = ?", role).Update("token_version", gorm.Expr("token_version + 1")).Error
 }
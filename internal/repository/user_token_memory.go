@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MemoryUserTokenStore is a UserTokenStore kept in process memory, for tests. It
+// enforces unique token hashes. WithTx returns the store itself, so writes made
+// inside a transaction are not rolled back.
+type MemoryUserTokenStore struct {
+	mu     sync.Mutex
+	tokens []models.UserToken
+	nextID uint
+}
+
+// NewMemoryUserTokenStore creates an empty MemoryUserTokenStore.
+func NewMemoryUserTokenStore() *MemoryUserTokenStore {
+	return &MemoryUserTokenStore{nextID: 1}
+}
+
+// WithTx returns the store itself.
+func (s *MemoryUserTokenStore) WithTx(tx *gorm.DB) UserTokenStore {
+	return s
+}
+
+// Create saves a newly issued token, assigning its ID. It returns
+// gorm.ErrDuplicatedKey if the hash is taken.
+func (s *MemoryUserTokenStore) Create(ctx context.Context, token *models.UserToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.tokens {
+		if existing.TokenHash == token.TokenHash {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+
+	token.ID = s.nextID
+	s.nextID++
+	token.CreatedAt = time.Now()
+	s.tokens = append(s.tokens, *token)
+	return nil
+}
+
+// Consume marks an unused, unexpired token with the given purpose and hash as used
+// and returns a copy of it.
+func (s *MemoryUserTokenStore) Consume(ctx context.Context, purpose, hash string, now time.Time) (*models.UserToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.tokens {
+		token := &s.tokens[i]
+		if token.Purpose == purpose && token.TokenHash == hash && token.UsedAt == nil && token.ExpiresAt.After(now) {
+			usedAt := now
+			token.UsedAt = &usedAt
+			consumed := *token
+			return &consumed, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// InvalidateForUser marks every unused token of a user with the given purpose as used.
+func (s *MemoryUserTokenStore) InvalidateForUser(ctx context.Context, userID uint, purpose string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.tokens {
+		token := &s.tokens[i]
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			usedAt := now
+			token.UsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+// DeleteExpired removes tokens that have expired or been used and returns how many were deleted.
+func (s *MemoryUserTokenStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.tokens[:0]
+	for _, token := range s.tokens {
+		if token.ExpiresAt.After(now) && token.UsedAt == nil {
+			kept = append(kept, token)
+		}
+	}
+	deleted := int64(len(s.tokens) - len(kept))
+	s.tokens = kept
+	return deleted, nil
+}
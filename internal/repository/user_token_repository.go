@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserTokenStore stores single-use tokens such as password reset, email
+// verification and MFA challenge tokens. UserTokenRepository keeps them in the
+// database and MemoryUserTokenStore in process memory, for tests.
+type UserTokenStore interface {
+	// WithTx returns a store that runs its queries inside the given database transaction.
+	WithTx(tx *gorm.DB) UserTokenStore
+	Create(ctx context.Context, token *models.UserToken) error
+	// Consume marks an unused, unexpired token as used and returns it, or returns
+	// gorm.ErrRecordNotFound.
+	Consume(ctx context.Context, purpose, hash string, now time.Time) (*models.UserToken, error)
+	InvalidateForUser(ctx context.Context, userID uint, purpose string, now time.Time) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// UserTokenRepository is the database implementation of UserTokenStore.
+type UserTokenRepository struct {
+	DB *gorm.DB
+}
+
+// NewUserTokenRepository creates a new UserTokenRepository.
+func NewUserTokenRepository(db *gorm.DB) *UserTokenRepository {
+	return &UserTokenRepository{DB: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside the given database transaction.
+func (r *UserTokenRepository) WithTx(tx *gorm.DB) UserTokenStore {
+	return &UserTokenRepository{DB: tx}
+}
+
+// Create stores a newly issued token.
+func (r *UserTokenRepository) Create(ctx context.Context, token *models.UserToken) error {
+	return r.DB.WithContext(ctx).Create(token).Error
+}
+
+// Consume marks an unused, unexpired token with the given purpose and hash as used
+// and returns it. It returns gorm.ErrRecordNotFound if no such token exists, so each
+// token is accepted at most once even under concurrent requests.
+func (r *UserTokenRepository) Consume(ctx context.Context, purpose, hash string, now time.Time) (*models.UserToken, error) {
+	var token models.UserToken
+	result := r.DB.WithContext(ctx).Model(&token).Clauses(clause.Returning{}).
+		Where("purpose = ? AND token_hash = ? AND used_at IS NULL AND expires_at > ?", purpose, hash, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &token, nil
+}
+
+// InvalidateForUser marks every unused token of a user with the given purpose as used,
+// so that only the most recently issued token works.
+func (r *UserTokenRepository) InvalidateForUser(ctx context.Context, userID uint, purpose string, now time.Time) error {
+	return r.DB.WithContext(ctx).Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", now).Error
+}
+
+// DeleteExpired removes tokens that have expired or been used and returns how many were deleted.
+func (r *UserTokenRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Where("expires_at <= ? OR used_at IS NOT NULL", now).Delete(&models.UserToken{})
+	return result.RowsAffected, result.Error
+}
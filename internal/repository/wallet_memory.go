@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/pkg/money"
+
+	"gorm.io/gorm"
+)
+
+// MemoryWalletStore is a WalletStore kept in process memory, for tests. Each
+// method is atomic, so AdjustBalance never loses a concurrent update, but WithTx
+// returns the store itself and writes made inside a transaction are not rolled back.
+type MemoryWalletStore struct {
+	mu      sync.Mutex
+	wallets map[uint]models.Wallet
+	nextID  uint
+}
+
+// NewMemoryWalletStore creates an empty MemoryWalletStore.
+func NewMemoryWalletStore() *MemoryWalletStore {
+	return &MemoryWalletStore{wallets: make(map[uint]models.Wallet), nextID: 1}
+}
+
+// WithTx returns the store itself.
+func (s *MemoryWalletStore) WithTx(tx *gorm.DB) WalletStore {
+	return s
+}
+
+// Create saves a new wallet, assigning its ID.
+func (s *MemoryWalletStore) Create(ctx context.Context, wallet *models.Wallet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if wallet.Currency == "" {
+		wallet.Currency = money.DefaultCurrency
+	}
+	wallet.Balance.Currency = wallet.Currency
+
+	now := time.Now()
+	wallet.ID = s.nextID
+	s.nextID++
+	wallet.CreatedAt, wallet.UpdatedAt = now, now
+	s.wallets[wallet.ID] = *wallet
+	return nil
+}
+
+// FindByID returns a copy of the wallet with the given ID.
+func (s *MemoryWalletStore) FindByID(ctx context.Context, id uint) (*models.Wallet, error) {
+	return s.first(func(wallet *models.Wallet) bool { return wallet.ID == id })
+}
+
+// FindByUserID finds a user's default wallet, falling back to their oldest wallet.
+func (s *MemoryWalletStore) FindByUserID(ctx context.Context, userID uint) (*models.Wallet, error) {
+	return s.first(func(wallet *models.Wallet) bool { return wallet.UserID == userID })
+}
+
+// FindByIDAndUserID finds a wallet by its ID, provided it belongs to the given user.
+func (s *MemoryWalletStore) FindByIDAndUserID(ctx context.Context, id, userID uint) (*models.Wallet, error) {
+	return s.first(func(wallet *models.Wallet) bool { return wallet.ID == id && wallet.UserID == userID })
+}
+
+// FindByUserIDAndCurrency finds a user's wallet in the given currency, preferring their default wallet.
+func (s *MemoryWalletStore) FindByUserIDAndCurrency(ctx context.Context, userID uint, currency string) (*models.Wallet, error) {
+	return s.first(func(wallet *models.Wallet) bool { return wallet.UserID == userID && wallet.Currency == currency })
+}
+
+// FindAllByUserID finds all wallets belonging to a user, ordered by ID.
+func (s *MemoryWalletStore) FindAllByUserID(ctx context.Context, userID uint) ([]models.Wallet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var wallets []models.Wallet
+	for _, wallet := range s.sorted(false) {
+		if wallet.UserID == userID {
+			wallets = append(wallets, wallet)
+		}
+	}
+	return wallets, nil
+}
+
+// CountByUserID counts the wallets belonging to a user.
+func (s *MemoryWalletStore) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for _, wallet := range s.wallets {
+		if wallet.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Update saves a wallet's details, keeping its stored balance.
+func (s *MemoryWalletStore) Update(ctx context.Context, wallet *models.Wallet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	updated := *wallet
+	if stored, ok := s.wallets[wallet.ID]; ok {
+		updated.Balance = stored.Balance
+	}
+	updated.UpdatedAt = time.Now()
+	s.wallets[wallet.ID] = updated
+	return nil
+}
+
+// Rename changes a wallet's name.
+func (s *MemoryWalletStore) Rename(ctx context.Context, walletID uint, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if wallet, ok := s.wallets[walletID]; ok {
+		wallet.Name = name
+		s.wallets[walletID] = wallet
+	}
+	return nil
+}
+
+// SetDefault makes walletID the user's only default wallet.
+func (s *MemoryWalletStore) SetDefault(ctx context.Context, userID, walletID uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, wallet := range s.wallets {
+		if wallet.UserID == userID {
+			wallet.IsDefault = id == walletID
+			s.wallets[id] = wallet
+		}
+	}
+	return nil
+}
+
+// AdjustBalance adds delta to a wallet's balance and returns the updated wallet
+// together with its previous balance.
+func (s *MemoryWalletStore) AdjustBalance(ctx context.Context, walletID uint, delta money.Money) (*models.Wallet, money.Money, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wallet, ok := s.wallets[walletID]
+	if !ok {
+		return nil, money.Money{}, gorm.ErrRecordNotFound
+	}
+
+	balance, err := wallet.Balance.Add(delta)
+	if err != nil {
+		return nil, money.Money{}, err
+	}
+	if balance.IsNegative() {
+		return nil, money.Money{}, ErrNegativeBalance
+	}
+
+	previous := wallet.Balance
+	wallet.Balance = balance
+	s.wallets[walletID] = wallet
+	return &wallet, previous, nil
+}
+
+// first returns a copy of the first wallet matching match, default wallets first
+// and then by ID, as the database queries order them.
+func (s *MemoryWalletStore) first(match func(wallet *models.Wallet) bool) (*models.Wallet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, wallet := range s.sorted(true) {
+		if match(&wallet) {
+			return &wallet, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// sorted returns copies of every wallet ordered by ID, with default wallets
+// first if defaultFirst. s.mu must be held.
+func (s *MemoryWalletStore) sorted(defaultFirst bool) []models.Wallet {
+	wallets := make([]models.Wallet, 0, len(s.wallets))
+	for _, wallet := range s.wallets {
+		wallets = append(wallets, wallet)
+	}
+	sort.Slice(wallets, func(i, j int) bool {
+		if defaultFirst && wallets[i].IsDefault != wallets[j].IsDefault {
+			return wallets[i].IsDefault
+		}
+		return wallets[i].ID < wallets[j].ID
+	})
+	return wallets
+}
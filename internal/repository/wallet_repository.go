@@ -1,37 +1,165 @@
 package repository
 
 import (
-	"wallet-service/internal/db"
+	"context"
+	"errors"
 	"wallet-service/internal/models"
+	"wallet-service/pkg/money"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// WalletRepository handles database operations for wallets.
+// ErrNegativeBalance is returned by AdjustBalance when a change would overdraw the wallet.
+var ErrNegativeBalance = errors.New("wallet balance cannot go below zero")
+
+// WalletStore stores wallets. WalletRepository keeps them in the database and
+// MemoryWalletStore in process memory, for tests. Lookups of missing wallets
+// return gorm.ErrRecordNotFound.
+type WalletStore interface {
+	// WithTx returns a store that runs its queries inside the given database transaction.
+	WithTx(tx *gorm.DB) WalletStore
+	Create(ctx context.Context, wallet *models.Wallet) error
+	FindByID(ctx context.Context, id uint) (*models.Wallet, error)
+	// FindByUserID finds a user's default wallet, falling back to their oldest wallet.
+	FindByUserID(ctx context.Context, userID uint) (*models.Wallet, error)
+	FindByIDAndUserID(ctx context.Context, id, userID uint) (*models.Wallet, error)
+	// FindByUserIDAndCurrency finds a user's wallet in currency, preferring their default wallet.
+	FindByUserIDAndCurrency(ctx context.Context, userID uint, currency string) (*models.Wallet, error)
+	FindAllByUserID(ctx context.Context, userID uint) ([]models.Wallet, error)
+	CountByUserID(ctx context.Context, userID uint) (int64, error)
+	// Update saves a wallet's details, but never its balance.
+	Update(ctx context.Context, wallet *models.Wallet) error
+	Rename(ctx context.Context, walletID uint, name string) error
+	SetDefault(ctx context.Context, userID, walletID uint) error
+	// AdjustBalance adds delta to a wallet's balance, returning ErrNegativeBalance
+	// rather than overdrawing it, and returns the updated wallet and its previous balance.
+	AdjustBalance(ctx context.Context, walletID uint, delta money.Money) (*models.Wallet, money.Money, error)
+}
+
+// WalletRepository is the database implementation of WalletStore.
 type WalletRepository struct {
 	DB *gorm.DB
 }
 
 // NewWalletRepository creates a new WalletRepository.
-func NewWalletRepository() *WalletRepository {
-	return &WalletRepository{DB: db.DB}
+func NewWalletRepository(db *gorm.DB) *WalletRepository {
+	return &WalletRepository{DB: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside the given database transaction.
+func (r *WalletRepository) WithTx(tx *gorm.DB) WalletStore {
+	return &WalletRepository{DB: tx}
 }
 
 // Create creates a new wallet in the database.
-func (r *WalletRepository) Create(wallet *models.Wallet) error {
-	return r.DB.Create(wallet).Error
+func (r *WalletRepository) Create(ctx context.Context, wallet *models.Wallet) error {
+	return r.DB.WithContext(ctx).Create(wallet).Error
+}
+
+// FindByID finds a wallet by its ID.
+func (r *WalletRepository) FindByID(ctx context.Context, id uint) (*models.Wallet, error) {
+	var wallet models.Wallet
+	if err := r.DB.WithContext(ctx).First(&wallet, id).Error; err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// FindByUserID finds a user's default wallet, falling back to their oldest wallet.
+func (r *WalletRepository) FindByUserID(ctx context.Context, userID uint) (*models.Wallet, error) {
+	var wallet models.Wallet
+	if err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("is_default DESC, id").First(&wallet).Error; err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// FindByIDAndUserID finds a wallet by its ID, provided it belongs to the given user.
+func (r *WalletRepository) FindByIDAndUserID(ctx context.Context, id, userID uint) (*models.Wallet, error) {
+	var wallet models.Wallet
+	if err := r.DB.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&wallet).Error; err != nil {
+		return nil, err
+	}
+	return &wallet, nil
 }
 
-// FindByUserID finds a wallet by the user's ID.
-func (r *WalletRepository) FindByUserID(userID uint) (*models.Wallet, error) {
+// FindByUserIDAndCurrency finds a user's wallet in the given currency, preferring their default wallet.
+func (r *WalletRepository) FindByUserIDAndCurrency(ctx context.Context, userID uint, currency string) (*models.Wallet, error) {
 	var wallet models.Wallet
-	if err := r.DB.Where("user_id = ?", userID).First(&wallet).Error; err != nil {
+	if err := r.DB.WithContext(ctx).Where("user_id = ? AND currency = ?", userID, currency).Order("is_default DESC, id").First(&wallet).Error; err != nil {
 		return nil, err
 	}
 	return &wallet, nil
 }
 
+// FindAllByUserID finds all wallets belonging to a user.
+func (r *WalletRepository) FindAllByUserID(ctx context.Context, userID uint) ([]models.Wallet, error) {
+	var wallets []models.Wallet
+	if err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&wallets).Error; err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
+
+// CountByUserID counts the wallets belonging to a user.
+func (r *WalletRepository) CountByUserID(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&models.Wallet{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
 // Update updates a wallet's details in the database.
-func (r *WalletRepository) Update(wallet *models.Wallet) error {
-	return r.DB.Save(wallet).Error
+// The balance is never written here; use AdjustBalance to change it.
+func (r *WalletRepository) Update(ctx context.Context, wallet *models.Wallet) error {
+	return r.DB.WithContext(ctx).Omit("balance").Save(wallet).Error
+}
+
+// Rename changes a wallet's name.
+func (r *WalletRepository) Rename(ctx context.Context, walletID uint, name string) error {
+	return r.DB.WithContext(ctx).Model(&models.Wallet{}).Where("id = ?", walletID).Update("name", name).Error
+}
+
+// SetDefault makes walletID the user's only default wallet.
+func (r *WalletRepository) SetDefault(ctx context.Context, userID, walletID uint) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Wallet{}).Where("user_id = ? AND is_default", userID).Update("is_default", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Wallet{}).Where("id = ? AND user_id = ?", walletID, userID).Update("is_default", true).Error
+	})
+}
+
+// AdjustBalance is the only way to change a wallet's balance. It locks the wallet row
+// with SELECT ... FOR UPDATE, adds delta and returns the updated wallet together with
+// its previous balance. The lock is held until the surrounding transaction ends; when
+// called outside a transaction, AdjustBalance opens its own.
+func (r *WalletRepository) AdjustBalance(ctx context.Context, walletID uint, delta money.Money) (*models.Wallet, money.Money, error) {
+	var (
+		wallet   models.Wallet
+		previous money.Money
+	)
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&wallet, walletID).Error; err != nil {
+			return err
+		}
+
+		balance, err := wallet.Balance.Add(delta)
+		if err != nil {
+			return err
+		}
+		if balance.IsNegative() {
+			return ErrNegativeBalance
+		}
+
+		if err := tx.Model(&wallet).UpdateColumn("balance", balance).Error; err != nil {
+			return err
+		}
+		previous, wallet.Balance = wallet.Balance, balance
+		return nil
+	})
+	if err != nil {
+		return nil, money.Money{}, err
+	}
+	return &wallet, previous, nil
 }
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/pkg/mailer"
+	"wallet-service/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidUserToken is returned when a password reset or verification token is unknown, expired or already used.
+	ErrInvalidUserToken = errors.New("invalid or expired token")
+	// ErrEmailAlreadyVerified is returned when requesting verification for an address that is already verified.
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+)
+
+// AccountService handles password resets and email verification through
+// single-use tokens delivered by email.
+type AccountService struct {
+	txManager     repository.TxManager
+	userRepo      repository.UserStore
+	userTokenRepo repository.UserTokenStore
+	tokenVersions *TokenVersionService
+	mailer        mailer.Mailer
+	cfg           *config.Config
+}
+
+// NewAccountService creates a new AccountService.
+func NewAccountService(txManager repository.TxManager, userRepo repository.UserStore, userTokenRepo repository.UserTokenStore, tokenVersions *TokenVersionService, mailer mailer.Mailer, cfg *config.Config) *AccountService {
+	return &AccountService{txManager: txManager, userRepo: userRepo, userTokenRepo: userTokenRepo, tokenVersions: tokenVersions, mailer: mailer, cfg: cfg}
+}
+
+// RequestPasswordReset emails a password reset link to the user with the given
+// address. Unknown addresses are silently ignored so that the response does not
+// reveal which emails are registered.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	token, err := s.issueToken(ctx, user.ID, models.UserTokenPasswordReset, s.cfg.Account.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+	s.send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s.\n\n%s\n\nIf you did not ask to reset your password, you can ignore this email.\n",
+			user.Name, s.cfg.Account.PasswordResetTTL, s.link("/reset-password", token)),
+	})
+	return nil
+}
+
+// ResetPassword sets a new password using a token from RequestPasswordReset and
+// ends every existing session. Following the emailed link also proves ownership
+// of the address, so it is marked verified.
+func (s *AccountService) ResetPassword(ctx context.Context, token, password string) error {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	var userID uint
+	err = s.txManager.WithinTx(ctx, func(repos repository.Repositories) error {
+		now := time.Now()
+		consumed, err := repos.UserTokens.Consume(ctx, models.UserTokenPasswordReset, utils.HashToken(token), now)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidUserToken
+			}
+			return err
+		}
+
+		users := repos.Users
+		if err := users.UpdatePassword(ctx, consumed.UserID, hash); err != nil {
+			return err
+		}
+		if err := users.MarkEmailVerified(ctx, consumed.UserID, now); err != nil {
+			return err
+		}
+		userID = consumed.UserID
+		return repos.RefreshTokens.RevokeAllForUser(ctx, consumed.UserID, now)
+	})
+	if err != nil {
+		return err
+	}
+	return s.tokenVersions.Revoke(ctx, userID)
+}
+
+// SendVerificationEmail emails an email verification link to the user.
+func (s *AccountService) SendVerificationEmail(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueToken(ctx, user.ID, models.UserTokenEmailVerification, s.cfg.Account.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	s.send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address to start using your wallet. The link expires in %s.\n\n%s\n",
+			user.Name, s.cfg.Account.EmailVerificationTTL, s.link("/verify-email", token)),
+	})
+	return nil
+}
+
+// VerifyEmail marks the user's email address as verified using a token from SendVerificationEmail.
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	return s.txManager.WithinTx(ctx, func(repos repository.Repositories) error {
+		now := time.Now()
+		consumed, err := repos.UserTokens.Consume(ctx, models.UserTokenEmailVerification, utils.HashToken(token), now)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidUserToken
+			}
+			return err
+		}
+		return repos.Users.MarkEmailVerified(ctx, consumed.UserID, now)
+	})
+}
+
+// PurgeExpiredTokens deletes used and expired tokens and returns how many were removed.
+func (s *AccountService) PurgeExpiredTokens(ctx context.Context) (int64, error) {
+	return s.userTokenRepo.DeleteExpired(ctx, time.Now())
+}
+
+// issueToken stores a new token for purpose, invalidating the user's earlier ones,
+// and returns the plaintext token to email.
+func (s *AccountService) issueToken(ctx context.Context, userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, err := utils.GenerateToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.txManager.WithinTx(ctx, func(repos repository.Repositories) error {
+		tokens := repos.UserTokens
+		now := time.Now()
+		if err := tokens.InvalidateForUser(ctx, userID, purpose, now); err != nil {
+			return err
+		}
+		return tokens.Create(ctx, &models.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: now.Add(ttl),
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// link builds the front-end link for path carrying token.
+func (s *AccountService) link(path, token string) string {
+	return s.cfg.Account.LinkBaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// send delivers msg in the background, so that request latency does not depend
+// on the mail server or reveal whether an address is registered.
+func (s *AccountService) send(msg mailer.Message) {
+	go func() {
+		if err := s.mailer.Send(msg); err != nil {
+			log.Printf("Failed to send %q email to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
@@ -0,0 +1,173 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+	"wallet-service/pkg/mailer"
+	"wallet-service/pkg/utils"
+)
+
+// outbox is a mailer.Mailer that hands sent messages to the test.
+type outbox chan mailer.Message
+
+func (o outbox) Send(msg mailer.Message) error {
+	o <- msg
+	return nil
+}
+
+// token waits for the next message and returns the token carried by its link.
+func (o outbox) token(t *testing.T) string {
+	t.Helper()
+	select {
+	case msg := <-o:
+		for _, field := range strings.Fields(msg.Body) {
+			if link, err := url.Parse(field); err == nil && link.Query().Get("token") != "" {
+				return link.Query().Get("token")
+			}
+		}
+		t.Fatalf("message %q carries no link with a token", msg.Subject)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message was sent")
+	}
+	return ""
+}
+
+// memoryAccounts returns an AccountService running on in-memory stores with a
+// registered user, together with the stores it uses and the user's mail.
+func memoryAccounts(t *testing.T) (*service.AccountService, repository.Repositories, *models.User, outbox) {
+	t.Helper()
+	wallets := repository.NewMemoryWalletStore()
+	repos := repository.Repositories{
+		Users:         repository.NewMemoryUserStore(),
+		Wallets:       wallets,
+		Transactions:  repository.NewMemoryTransactionStore(),
+		Ledger:        repository.NewMemoryLedgerStore(wallets),
+		UserTokens:    repository.NewMemoryUserTokenStore(),
+		RefreshTokens: repository.NewMemoryRefreshTokenStore(),
+	}
+	user := &models.User{Name: "Alice", Email: "alice@example.com", Password: "old-password"}
+	if err := repos.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+
+	cfg := &config.Config{Account: config.AccountConfig{
+		LinkBaseURL:          "http://localhost:3000",
+		PasswordResetTTL:     time.Hour,
+		EmailVerificationTTL: time.Hour,
+	}}
+	mail := make(outbox, 10)
+	accounts := service.NewAccountService(
+		repository.NewMemoryTxManager(repos),
+		repos.Users,
+		repos.UserTokens,
+		service.NewTokenVersionService(repos.Users, time.Minute),
+		mail,
+		cfg,
+	)
+	return accounts, repos, user, mail
+}
+
+func TestAccountService_ResetPassword(t *testing.T) {
+	accounts, repos, user, mail := memoryAccounts(t)
+	ctx := context.Background()
+	session := &models.RefreshToken{ID: "session", UserID: user.ID, FamilyID: "family", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repos.RefreshTokens.Create(ctx, session); err != nil {
+		t.Fatalf("Create refresh token: %v", err)
+	}
+
+	// Requesting a second link invalidates the first.
+	if err := accounts.RequestPasswordReset(ctx, user.Email); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	stale := mail.token(t)
+	if err := accounts.RequestPasswordReset(ctx, user.Email); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	token := mail.token(t)
+	if err := accounts.ResetPassword(ctx, stale, "new-password"); !errors.Is(err, service.ErrInvalidUserToken) {
+		t.Fatalf("ResetPassword with a replaced token: err = %v, want ErrInvalidUserToken", err)
+	}
+
+	if err := accounts.ResetPassword(ctx, token, "new-password"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	updated, err := repos.Users.FindByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if !utils.CheckPasswordHash("new-password", updated.Password) {
+		t.Error("the password was not changed")
+	}
+	if !updated.EmailVerified() {
+		t.Error("resetting the password did not verify the email address")
+	}
+	if updated.TokenVersion != user.TokenVersion+1 {
+		t.Errorf("TokenVersion = %d, want %d", updated.TokenVersion, user.TokenVersion+1)
+	}
+	revoked, err := repos.RefreshTokens.FindByIDForUpdate(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("FindByIDForUpdate: %v", err)
+	}
+	if !revoked.Revoked() {
+		t.Error("resetting the password did not revoke the user's refresh tokens")
+	}
+
+	if err := accounts.ResetPassword(ctx, token, "another-password"); !errors.Is(err, service.ErrInvalidUserToken) {
+		t.Fatalf("reusing a token: err = %v, want ErrInvalidUserToken", err)
+	}
+}
+
+func TestAccountService_RequestPasswordResetForUnknownEmail(t *testing.T) {
+	accounts, _, _, mail := memoryAccounts(t)
+	if err := accounts.RequestPasswordReset(context.Background(), "nobody@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	select {
+	case msg := <-mail:
+		t.Fatalf("sent %q to an unknown address", msg.Subject)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestAccountService_VerifyEmail(t *testing.T) {
+	accounts, repos, user, mail := memoryAccounts(t)
+	ctx := context.Background()
+
+	if err := accounts.SendVerificationEmail(ctx, user.ID); err != nil {
+		t.Fatalf("SendVerificationEmail: %v", err)
+	}
+	token := mail.token(t)
+	if err := accounts.VerifyEmail(ctx, "not-"+token); !errors.Is(err, service.ErrInvalidUserToken) {
+		t.Fatalf("VerifyEmail with an unknown token: err = %v, want ErrInvalidUserToken", err)
+	}
+	if err := accounts.VerifyEmail(ctx, token); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	verified, err := repos.Users.FindByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if !verified.EmailVerified() {
+		t.Fatal("VerifyEmail did not verify the email address")
+	}
+	if err := accounts.SendVerificationEmail(ctx, user.ID); !errors.Is(err, service.ErrEmailAlreadyVerified) {
+		t.Fatalf("SendVerificationEmail after verifying: err = %v, want ErrEmailAlreadyVerified", err)
+	}
+
+	// Used tokens are purged.
+	deleted, err := accounts.PurgeExpiredTokens(ctx)
+	if err != nil {
+		t.Fatalf("PurgeExpiredTokens: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("PurgeExpiredTokens removed %d tokens, want 1", deleted)
+	}
+}
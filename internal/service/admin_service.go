@@ -1,46 +1,90 @@
 package service
 
 import (
+	"context"
 	"wallet-service/internal/models"
 	"wallet-service/internal/repository"
 )
 
 // AdminService provides admin-related services.
 type AdminService struct {
-	userRepo *repository.UserRepository
+	txManager       repository.TxManager
+	userRepo        repository.UserStore
+	ledger          *LedgerService
+	loginProtection *LoginProtectionService
+	roles           *RoleService
+	tokenVersions   *TokenVersionService
 }
 
 // NewAdminService creates a new AdminService.
-func NewAdminService(userRepo *repository.UserRepository) *AdminService {
-	return &AdminService{userRepo: userRepo}
+func NewAdminService(txManager repository.TxManager, userRepo repository.UserStore, ledger *LedgerService, loginProtection *LoginProtectionService, roles *RoleService, tokenVersions *TokenVersionService) *AdminService {
+	return &AdminService{txManager: txManager, userRepo: userRepo, ledger: ledger, loginProtection: loginProtection, roles: roles, tokenVersions: tokenVersions}
 }
 
 // GetAllUsers retrieves all users from the repository.
-func (s *AdminService) GetAllUsers() ([]models.User, error) {
-	return s.userRepo.GetAll()
+func (s *AdminService) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	return s.userRepo.GetAll(ctx)
 }
 
-// UpdateUserRole updates the role of a specific user.
-func (s *AdminService) UpdateUserRole(userID uint, newRole string) (*models.User, error) {
-	user, err := s.userRepo.FindByID(userID)
-	if err != nil {
+// UpdateUserRole updates the role of a specific user and revokes their access
+// tokens, whose role and permission claims are now out of date. Only the role
+// column is written, in the same transaction as the token version bump, so a
+// concurrent change to the user's password or two-factor settings is kept. It
+// returns ErrRoleNotFound if the role does not exist.
+func (s *AdminService) UpdateUserRole(ctx context.Context, userID uint, newRole string) (*models.User, error) {
+	if _, err := s.roles.GetRole(ctx, newRole); err != nil {
 		return nil, err
 	}
 
-	user.Role = newRole
-	if err := s.userRepo.Update(user); err != nil {
+	var user *models.User
+	err := s.txManager.WithinTx(ctx, func(repos repository.Repositories) error {
+		users := repos.Users
+		if _, err := users.FindByID(ctx, userID); err != nil {
+			return err
+		}
+		if err := users.UpdateRole(ctx, userID, newRole); err != nil {
+			return err
+		}
+		if err := users.BumpTokenVersion(ctx, userID); err != nil {
+			return err
+		}
+		var err error
+		user, err = users.FindByID(ctx, userID)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
+	s.tokenVersions.Forget(userID)
 
 	return user, nil
 }
 
 // DeleteUser deletes a specific user by ID.
-func (s *AdminService) DeleteUser(userID uint) error {
-	return s.userRepo.Delete(userID)
+func (s *AdminService) DeleteUser(ctx context.Context, userID uint) error {
+	return s.userRepo.Delete(ctx, userID)
 }
 
 // DeleteAllUsers deletes all users from the system.
-func (s *AdminService) DeleteAllUsers() error {
-	return s.userRepo.DeleteAll()
+func (s *AdminService) DeleteAllUsers(ctx context.Context) error {
+	return s.userRepo.DeleteAll(ctx)
+}
+
+// ReconcileLedger lists wallets whose cached balance disagrees with the ledger.
+func (s *AdminService) ReconcileLedger(ctx context.Context) ([]models.LedgerDiscrepancy, error) {
+	return s.ledger.Reconcile(ctx)
+}
+
+// UnlockUser lifts a failed-login lockout of a user's account on behalf of an admin.
+func (s *AdminService) UnlockUser(ctx context.Context, userID, adminID uint) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.loginProtection.Unlock(ctx, user, adminID)
+}
+
+// SecurityEvents returns the most recent security events, optionally only those of one user.
+func (s *AdminService) SecurityEvents(ctx context.Context, userID *uint, limit int) ([]models.SecurityEvent, error) {
+	return s.loginProtection.SecurityEvents(ctx, userID, limit)
 }
@@ -0,0 +1,56 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wallet-service/internal/db/dbtest"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+)
+
+func TestAdminService_UpdateUserRoleOnlyChangesTheRole(t *testing.T) {
+	gormDB := dbtest.Open(t)
+	ctx := context.Background()
+	users := repository.NewUserRepository(gormDB)
+	tokenVersions := service.NewTokenVersionService(users, time.Minute)
+	admin := service.NewAdminService(
+		repository.NewTxManager(gormDB),
+		users,
+		nil,
+		nil,
+		service.NewRoleService(repository.NewRoleRepository(gormDB), tokenVersions),
+		tokenVersions,
+	)
+	user := dbtest.CreateUser(t, gormDB)
+
+	// A stale copy of the user, as loaded before these changes, must not be
+	// written back over them.
+	if err := users.UpdatePassword(ctx, user.ID, "new-hash"); err != nil {
+		t.Fatalf("UpdatePassword: %v", err)
+	}
+	if err := users.UpdateTOTP(ctx, user.ID, "sealed-secret", true); err != nil {
+		t.Fatalf("UpdateTOTP: %v", err)
+	}
+	before, err := tokenVersions.Current(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Current: %v", err)
+	}
+
+	updated, err := admin.UpdateUserRole(ctx, user.ID, "support_agent")
+	if err != nil {
+		t.Fatalf("UpdateUserRole: %v", err)
+	}
+	if updated.Role != "support_agent" || updated.Password != "new-hash" || !updated.TOTPEnabled || updated.TOTPSecret != "sealed-secret" {
+		t.Errorf("UpdateUserRole = %+v, want only the role changed", updated)
+	}
+	// The cached version was dropped, so the bump is seen at once.
+	if after, err := tokenVersions.Current(ctx, user.ID); err != nil || after != before+1 {
+		t.Errorf("token version = %d, %v; want %d", after, err, before+1)
+	}
+
+	if _, err := admin.UpdateUserRole(ctx, user.ID, "no-such-role"); !errors.Is(err, service.ErrRoleNotFound) {
+		t.Errorf("unknown role: err = %v, want ErrRoleNotFound", err)
+	}
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	// apiKeyPrefix starts every API key, so leaked keys are easy to recognize and scan for.
+	apiKeyPrefix = "wsk_"
+	// apiKeyIDLength is the length of the hex identifier that follows apiKeyPrefix.
+	apiKeyIDLength = 12
+	// apiKeyTouchInterval limits how often a key's last-used time is written.
+	apiKeyTouchInterval = time.Minute
+)
+
+// TokenTypeAPIKey is the "typ" of the claims built for a request authenticated with an API key.
+const TokenTypeAPIKey = "api_key"
+
+var (
+	// ErrInvalidAPIKey is returned when an API key is malformed, unknown, expired or revoked.
+	ErrInvalidAPIKey = errors.New("invalid or expired API key")
+	// ErrAPIKeyNotFound is returned when revoking a key the user does not have.
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrAPIKeyPermissionNotHeld is returned when a key asks for a permission its owner's role does not grant.
+	ErrAPIKeyPermissionNotHeld = errors.New("API key permissions must be granted by your role")
+	// ErrInvalidAPIKeyExpiry is returned when a key's expiry is not in the future.
+	ErrInvalidAPIKeyExpiry = errors.New("API key expiry must be in the future")
+	// ErrAPIKeyReauthenticationRequired is returned when a key is requested without
+	// a recent second factor, or the current password from users without two-factor.
+	ErrAPIKeyReauthenticationRequired = errors.New("creating an API key requires a recent two-factor step-up, or your current password if two-factor is not enabled")
+)
+
+// APIKeyService manages API keys and authenticates requests that present one.
+type APIKeyService struct {
+	apiKeyRepo *repository.APIKeyRepository
+	userRepo   repository.UserStore
+	roles      *RoleService
+	cfg        *config.Config
+}
+
+// NewAPIKeyService creates a new APIKeyService.
+func NewAPIKeyService(apiKeyRepo *repository.APIKeyRepository, userRepo repository.UserStore, roles *RoleService, cfg *config.Config) *APIKeyService {
+	return &APIKeyService{apiKeyRepo: apiKeyRepo, userRepo: userRepo, roles: roles, cfg: cfg}
+}
+
+// Create issues a new API key for the user. The key may only carry permissions
+// the user's role grants. Since a key is not subject to step-up when it is used,
+// the user must prove who they are when creating it: with a second factor
+// presented at mfaAt, no longer than mfa.step_up_max_age ago, if they have
+// two-factor enabled; with a login at authAt as recent as that if they have no
+// password, such as users who only sign in through an identity provider; or
+// else with their current password. The returned secret is not stored and
+// cannot be shown again.
+func (s *APIKeyService) Create(ctx context.Context, userID uint, authAt, mfaAt time.Time, req *models.CreateAPIKeyRequest) (*models.APIKeyCreated, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidAPIKeyExpiry
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	recent := func(at time.Time) bool {
+		return !at.IsZero() && time.Since(at) <= s.cfg.MFA.StepUpMaxAge
+	}
+	switch {
+	case user.TOTPEnabled:
+		if !recent(mfaAt) {
+			return nil, ErrAPIKeyReauthenticationRequired
+		}
+	case user.Password == "":
+		if !recent(authAt) {
+			return nil, ErrAPIKeyReauthenticationRequired
+		}
+	case !utils.CheckPasswordHash(req.Password, user.Password):
+		return nil, ErrAPIKeyReauthenticationRequired
+	}
+	granted, err := s.roles.RolePermissions(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	permissions := intersectPermissions(req.Permissions, granted)
+	if len(permissions) != len(uniquePermissions(req.Permissions)) {
+		return nil, ErrAPIKeyPermissionNotHeld
+	}
+
+	id := make([]byte, apiKeyIDLength/2)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	secret, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.APIKey{
+		UserID:      user.ID,
+		Name:        req.Name,
+		Prefix:      hex.EncodeToString(id),
+		SecretHash:  utils.HashToken(secret),
+		Permissions: permissions,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	return &models.APIKeyCreated{APIKey: key, Key: apiKeyPrefix + key.Prefix + "_" + secret}, nil
+}
+
+// List returns the user's API keys, including revoked and expired ones.
+func (s *APIKeyService) List(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	return s.apiKeyRepo.ListByUserID(ctx, userID)
+}
+
+// Revoke revokes one of the user's API keys.
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID uint) error {
+	revoked, err := s.apiKeyRepo.Revoke(ctx, keyID, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate checks an API key and returns claims shaped like those of an
+// access token, so that middleware treats both alike. The "perms" claim holds
+// the key's permissions that the owner's role still grants.
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey string) (jwt.MapClaims, error) {
+	prefix, secret, ok := parseAPIKey(rawKey)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	key, err := s.apiKeyRepo.FindByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(secret)), []byte(key.SecretHash)) != 1 || !key.Usable(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.FindByID(ctx, key.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	granted, err := s.roles.RolePermissions(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, key.ID, now, apiKeyTouchInterval); err != nil {
+		log.Printf("Failed to record use of API key %d: %v", key.ID, err)
+	}
+
+	permissions := intersectPermissions(key.Permissions, granted)
+	perms := make([]interface{}, len(permissions))
+	for i, permission := range permissions {
+		perms[i] = permission
+	}
+	// Numbers are float64, as they are in parsed JWT claims.
+	return jwt.MapClaims{
+		"user_id":    float64(user.ID),
+		"email":      user.Email,
+		"role":       user.Role,
+		"perms":      perms,
+		"typ":        TokenTypeAPIKey,
+		"api_key_id": float64(key.ID),
+		"mfa":        user.TOTPEnabled,
+	}, nil
+}
+
+// parseAPIKey splits a key of the form wsk_<prefix>_<secret>.
+func parseAPIKey(rawKey string) (prefix, secret string, ok bool) {
+	rest, found := strings.CutPrefix(strings.TrimSpace(rawKey), apiKeyPrefix)
+	if !found || len(rest) < apiKeyIDLength+2 || rest[apiKeyIDLength] != '_' {
+		return "", "", false
+	}
+	return rest[:apiKeyIDLength], rest[apiKeyIDLength+1:], true
+}
+
+// intersectPermissions returns the distinct permissions in requested that are also in granted.
+func intersectPermissions(requested, granted []string) []string {
+	held := make(map[string]bool, len(granted))
+	for _, permission := range granted {
+		held[permission] = true
+	}
+	result := []string{}
+	for _, permission := range uniquePermissions(requested) {
+		if held[permission] {
+			result = append(result, permission)
+		}
+	}
+	return result
+}
+
+// uniquePermissions returns permissions without duplicates, in their original order.
+func uniquePermissions(permissions []string) []string {
+	seen := make(map[string]bool, len(permissions))
+	result := []string{}
+	for _, permission := range permissions {
+		if !seen[permission] {
+			seen[permission] = true
+			result = append(result, permission)
+		}
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/db/dbtest"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+	"wallet-service/pkg/utils"
+)
+
+func TestAPIKeyService_CreateRequiresReauthentication(t *testing.T) {
+	gormDB := dbtest.Open(t)
+	ctx := context.Background()
+	users := repository.NewUserRepository(gormDB)
+	tokenVersions := service.NewTokenVersionService(users, time.Minute)
+	cfg := &config.Config{MFA: config.MFAConfig{StepUpMaxAge: 5 * time.Minute}}
+	apiKeys := service.NewAPIKeyService(
+		repository.NewAPIKeyRepository(gormDB),
+		users,
+		service.NewRoleService(repository.NewRoleRepository(gormDB), tokenVersions),
+		cfg,
+	)
+	req := func(password string) *models.CreateAPIKeyRequest {
+		return &models.CreateAPIKeyRequest{Name: "Nightly job", Permissions: []string{models.PermissionWalletsWrite}, Password: password}
+	}
+	now, stale := time.Now(), time.Now().Add(-time.Hour)
+
+	// Users who only sign in through an identity provider have no password.
+	passwordless := dbtest.CreateUser(t, gormDB)
+	for name, authAt := range map[string]time.Time{"refreshed token": {}, "stale login": stale} {
+		if _, err := apiKeys.Create(ctx, passwordless.ID, authAt, time.Time{}, req("")); !errors.Is(err, service.ErrAPIKeyReauthenticationRequired) {
+			t.Errorf("passwordless user, %s: err = %v, want ErrAPIKeyReauthenticationRequired", name, err)
+		}
+	}
+	if _, err := apiKeys.Create(ctx, passwordless.ID, now, time.Time{}, req("")); err != nil {
+		t.Errorf("passwordless user after a recent login: %v", err)
+	}
+
+	withPassword := dbtest.CreateUser(t, gormDB)
+	hash, err := utils.HashPassword("password123")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if err := users.UpdatePassword(ctx, withPassword.ID, hash); err != nil {
+		t.Fatalf("UpdatePassword: %v", err)
+	}
+	if _, err := apiKeys.Create(ctx, withPassword.ID, now, time.Time{}, req("")); !errors.Is(err, service.ErrAPIKeyReauthenticationRequired) {
+		t.Errorf("user with a password, recent login only: err = %v, want ErrAPIKeyReauthenticationRequired", err)
+	}
+	if _, err := apiKeys.Create(ctx, withPassword.ID, time.Time{}, time.Time{}, req("password123")); err != nil {
+		t.Errorf("user with a password who sent it: %v", err)
+	}
+
+	if err := users.UpdateTOTP(ctx, passwordless.ID, "sealed-secret", true); err != nil {
+		t.Fatalf("UpdateTOTP: %v", err)
+	}
+	if _, err := apiKeys.Create(ctx, passwordless.ID, now, stale, req("")); !errors.Is(err, service.ErrAPIKeyReauthenticationRequired) {
+		t.Errorf("two-factor user without a recent step-up: err = %v, want ErrAPIKeyReauthenticationRequired", err)
+	}
+	if _, err := apiKeys.Create(ctx, passwordless.ID, time.Time{}, now, req("")); err != nil {
+		t.Errorf("two-factor user after a step-up: %v", err)
+	}
+}
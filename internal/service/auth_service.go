@@ -1,34 +1,83 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"log"
 	"time"
 	"wallet-service/internal/config"
 	"wallet-service/internal/models"
 	"wallet-service/internal/repository"
+	"wallet-service/pkg/jwtkeys"
+	"wallet-service/pkg/money"
 	"wallet-service/pkg/utils"
 
 	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 )
 
+// Token types, carried in the "typ" claim so that one kind of token cannot be used as another.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	TokenTypeMFA     = "mfa" // short-lived challenge token from a login awaiting its second factor
+)
+
+var (
+	// ErrInvalidRefreshToken is returned when a refresh token is malformed, expired, unknown or revoked.
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when an already-rotated refresh token is presented again.
+	// The token's whole family is revoked, since it has most likely been stolen.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected; please log in again")
+	// ErrInvalidMFAToken is returned when an MFA challenge token is malformed, expired or already used.
+	ErrInvalidMFAToken = errors.New("invalid or expired MFA token")
+	// ErrTokenRevoked is returned when an access token predates its user's current token version.
+	ErrTokenRevoked = errors.New("token has been revoked")
+)
+
 // AuthService provides authentication-related services.
 type AuthService struct {
-	userRepo      repository.UserRepository
-	walletService *WalletService
-	cfg           *config.Config
+	userRepo         repository.UserStore
+	refreshTokenRepo repository.RefreshTokenStore
+	userTokenRepo    repository.UserTokenStore
+	txManager        repository.TxManager
+	walletService    *WalletService
+	twoFactor        *TwoFactorService
+	accounts         *AccountService
+	loginProtection  *LoginProtectionService
+	roles            *RoleService
+	tokenVersions    *TokenVersionService
+	apiKeys          *APIKeyService
+	oauth            *OAuthService
+	keys             *jwtkeys.Manager
+	cfg              *config.Config
 }
 
-// NewAuthService creates a new AuthService.
-func NewAuthService(userRepo repository.UserRepository, walletService *WalletService, cfg *config.Config) *AuthService {
-	return &AuthService{userRepo: userRepo, walletService: walletService, cfg: cfg}
+// NewAuthService creates a new AuthService. Tokens are signed and verified with keys.
+func NewAuthService(userRepo repository.UserStore, refreshTokenRepo repository.RefreshTokenStore, userTokenRepo repository.UserTokenStore, txManager repository.TxManager, walletService *WalletService, twoFactor *TwoFactorService, accounts *AccountService, loginProtection *LoginProtectionService, roles *RoleService, tokenVersions *TokenVersionService, apiKeys *APIKeyService, oauth *OAuthService, keys *jwtkeys.Manager, cfg *config.Config) *AuthService {
+	return &AuthService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		userTokenRepo:    userTokenRepo,
+		txManager:        txManager,
+		walletService:    walletService,
+		twoFactor:        twoFactor,
+		accounts:         accounts,
+		loginProtection:  loginProtection,
+		roles:            roles,
+		tokenVersions:    tokenVersions,
+		apiKeys:          apiKeys,
+		oauth:            oauth,
+		keys:             keys,
+		cfg:              cfg,
+	}
 }
 
 // Register creates a new user, hashes their password, and saves them to the database.
-func (s *AuthService) Register(req *models.RegisterRequest) (*models.User, error) {
+// A verification link is emailed to the new user.
+func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error) {
 	// Check if user already exists
-	_, err := s.userRepo.FindByEmail(req.Email)
+	_, err := s.userRepo.FindByEmail(ctx, req.Email)
 	if err == nil {
 		return nil, errors.New("user with this email already exists")
 	}
@@ -42,111 +91,415 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.User, error
 		Password: req.Password, // The password will be hashed by the BeforeCreate hook
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	if err := s.createAccount(ctx, user); err != nil {
 		return nil, err
 	}
 
+	// Do not return the password hash
+	user.Password = ""
+	return user, nil
+}
+
+// RegisterExternal creates a user who signed in through an external identity
+// provider, with a primary wallet as Register does. The user has no password;
+// they can set one through a password reset. Unless the provider vouched for
+// the email address, a verification link is emailed as well.
+func (s *AuthService) RegisterExternal(ctx context.Context, name, email string, emailVerified bool) (*models.User, error) {
+	user := &models.User{Name: name, Email: email}
+	if emailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := s.createAccount(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// createAccount saves a new user and sets up their account: a primary wallet and,
+// for an unverified address, a verification email.
+func (s *AuthService) createAccount(ctx context.Context, user *models.User) error {
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return err
+	}
+
 	// Create a default wallet for the new user
-	if _, err := s.walletService.CreateWallet(user.ID, "Primary Wallet"); err != nil {
+	if _, err := s.walletService.CreateWallet(ctx, user.ID, "Primary Wallet", money.DefaultCurrency); err != nil {
 		// Log the error, but don't fail the registration
 		log.Printf("Failed to create wallet for user %d: %v", user.ID, err)
 	}
 
-	// Do not return the password hash
-	user.Password = ""
-	return user, nil
+	// The user can ask for a new link, so a failure here does not fail the registration either
+	if !user.EmailVerified() {
+		if err := s.accounts.SendVerificationEmail(ctx, user.ID); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+		}
+	}
+	return nil
 }
 
 // Login authenticates a user and returns an authentication response with JWT tokens.
-func (s *AuthService) Login(req *models.LoginRequest) (*models.AuthResponse, error) {
-	user, err := s.userRepo.FindByEmail(req.Email)
+// Users with two-factor enabled instead receive a short-lived MFA token to exchange,
+// together with a code, through VerifyMFA. Repeated failures for an account or
+// from clientIP are throttled and then locked out, returning a *LoginThrottledError.
+func (s *AuthService) Login(ctx context.Context, req *models.LoginRequest, clientIP string) (*models.AuthResponse, error) {
+	if err := s.loginProtection.Check(ctx, req.Email, clientIP); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("invalid credentials")
+			return nil, s.loginFailed(ctx, req.Email, clientIP, nil)
 		}
 		return nil, err
 	}
 
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
-		return nil, errors.New("invalid credentials")
+		return nil, s.loginFailed(ctx, req.Email, clientIP, user)
+	}
+	if err := s.loginProtection.RecordSuccess(ctx, req.Email); err != nil {
+		log.Printf("Failed to reset failed login counter for user %d: %v", user.ID, err)
+	}
+	return s.completeLogin(ctx, user)
+}
+
+// LoginExternal logs in a user authenticated by an external identity provider.
+// As with Login, users with two-factor enabled receive an MFA token instead.
+func (s *AuthService) LoginExternal(ctx context.Context, user *models.User) (*models.AuthResponse, error) {
+	return s.completeLogin(ctx, user)
+}
+
+// completeLogin starts a session for an authenticated user, or returns an MFA
+// challenge if they have two-factor enabled. The challenge's "jti" is stored
+// so that it can complete only one login.
+func (s *AuthService) completeLogin(ctx context.Context, user *models.User) (*models.AuthResponse, error) {
+	if user.TOTPEnabled {
+		now := time.Now()
+		challengeID, err := utils.GenerateToken(16)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.userTokenRepo.Create(ctx, &models.UserToken{
+			UserID:    user.ID,
+			Purpose:   models.UserTokenMFAChallenge,
+			TokenHash: utils.HashToken(challengeID),
+			ExpiresAt: now.Add(s.cfg.MFA.ChallengeTTL),
+		}); err != nil {
+			return nil, err
+		}
+		mfaToken, err := s.signToken(jwt.MapClaims{
+			"user_id": user.ID,
+			"typ":     TokenTypeMFA,
+			"jti":     challengeID,
+			"exp":     now.Add(s.cfg.MFA.ChallengeTTL).Unix(),
+			"iat":     now.Unix(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &models.AuthResponse{MFARequired: true, MFAToken: mfaToken}, nil
 	}
 
-	accessToken, err := s.generateToken(user, s.cfg.JWT.Expiration)
+	return s.startSession(ctx, user, time.Now(), time.Time{})
+}
+
+// loginFailed counts a failed login and returns the error reported to the client.
+func (s *AuthService) loginFailed(ctx context.Context, email, clientIP string, user *models.User) error {
+	if err := s.loginProtection.RecordFailure(ctx, email, clientIP, user); err != nil {
+		log.Printf("Failed to record failed login: %v", err)
+	}
+	return errors.New("invalid credentials")
+}
+
+// VerifyMFA completes a two-factor login by checking a TOTP or recovery code
+// against the user named by an MFA token from Login. Each MFA token completes
+// at most one login.
+func (s *AuthService) VerifyMFA(ctx context.Context, mfaToken, code string) (*models.AuthResponse, error) {
+	claims, err := s.parseToken(mfaToken, TokenTypeMFA)
 	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	challengeID, _ := claims["jti"].(string)
+	if challengeID == "" {
+		return nil, ErrInvalidMFAToken
+	}
+	user, err := s.userRepo.FindByID(ctx, uint(claims["user_id"].(float64)))
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if err := s.twoFactor.Verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+	if _, err := s.userTokenRepo.Consume(ctx, models.UserTokenMFAChallenge, utils.HashToken(challengeID), time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
 		return nil, err
 	}
+	now := time.Now()
+	return s.startSession(ctx, user, now, now)
+}
 
-	refreshToken, err := s.generateToken(user, s.cfg.JWT.RefreshExpiration)
+// StepUp verifies a fresh second factor and returns an access token recording it,
+// as required by endpoints protected with middleware.StepUpMiddleware.
+func (s *AuthService) StepUp(ctx context.Context, userID uint, code string) (*models.StepUpResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.twoFactor.Verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	accessToken, err := s.accessToken(ctx, user, now, now)
+	if err != nil {
+		return nil, err
+	}
+	return &models.StepUpResponse{AccessToken: accessToken}, nil
+}
 
-	// Do not return the password hash
-	user.Password = ""
+// startSession issues tokens in a new refresh token family to a user who
+// authenticated at authAt.
+func (s *AuthService) startSession(ctx context.Context, user *models.User, authAt, mfaAt time.Time) (*models.AuthResponse, error) {
+	familyID, err := utils.GenerateToken(16)
+	if err != nil {
+		return nil, err
+	}
+	resp, _, err := s.issueTokens(ctx, s.refreshTokenRepo, user, familyID, authAt, mfaAt)
+	return resp, err
+}
 
-	return &models.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         user,
-	}, nil
+// RefreshToken rotates a refresh token: it is revoked and replaced by a new refresh
+// token in the same family, alongside a new access token. Presenting a token that
+// has already been rotated revokes its whole family and returns ErrRefreshTokenReused.
+func (s *AuthService) RefreshToken(ctx context.Context, refreshTokenString string) (*models.AuthResponse, error) {
+	claims, err := s.parseToken(refreshTokenString, TokenTypeRefresh)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	jti, _ := claims["jti"].(string)
+
+	var (
+		resp   *models.AuthResponse
+		reused bool
+	)
+	err = s.txManager.WithinTx(ctx, func(repos repository.Repositories) error {
+		tokens := repos.RefreshTokens
+		now := time.Now()
+		reused = false
+
+		stored, err := tokens.FindByIDForUpdate(ctx, jti)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if stored.Revoked() {
+			// Commit the family revocation; the error is reported after the transaction.
+			log.Printf("Refresh token reuse detected for user %d; revoking token family", stored.UserID)
+			reused = true
+			return tokens.RevokeFamily(ctx, stored.FamilyID, now)
+		}
+		if now.After(stored.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+
+		user, err := repos.Users.FindByID(ctx, stored.UserID)
+		if err != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		var replacement *models.RefreshToken
+		resp, replacement, err = s.issueTokens(ctx, tokens, user, stored.FamilyID, time.Time{}, time.Time{})
+		if err != nil {
+			return err
+		}
+		return tokens.Rotate(ctx, stored.ID, replacement.ID, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrRefreshTokenReused
+	}
+	return resp, nil
 }
 
-// RefreshToken generates a new access token from a valid refresh token.
-func (s *AuthService) RefreshToken(refreshTokenString string) (*models.AuthResponse, error) {
-	claims, err := s.ValidateToken(refreshTokenString)
+// Logout revokes the refresh token family that refreshTokenString belongs to,
+// ending that session. Unknown or already-revoked tokens are not an error.
+func (s *AuthService) Logout(ctx context.Context, refreshTokenString string) error {
+	claims, err := s.parseToken(refreshTokenString, TokenTypeRefresh)
 	if err != nil {
-		return nil, errors.New("invalid or expired refresh token")
+		return ErrInvalidRefreshToken
 	}
+	familyID, _ := claims["fid"].(string)
+	return s.refreshTokenRepo.RevokeFamily(ctx, familyID, time.Now())
+}
 
-	userID := uint(claims["user_id"].(float64))
-	user, err := s.userRepo.FindByID(userID)
+// LogoutAll revokes every refresh token and access token belonging to a user,
+// ending all of their sessions.
+func (s *AuthService) LogoutAll(ctx context.Context, userID uint) error {
+	if err := s.refreshTokenRepo.RevokeAllForUser(ctx, userID, time.Now()); err != nil {
+		return err
+	}
+	return s.tokenVersions.Revoke(ctx, userID)
+}
+
+// PurgeExpiredRefreshTokens deletes refresh tokens that can no longer be used and returns how many were removed.
+func (s *AuthService) PurgeExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	return s.refreshTokenRepo.DeleteExpired(ctx, time.Now())
+}
+
+// ValidateToken parses and validates an access token string, rejecting tokens
+// issued before the user's token version was last bumped and OAuth access tokens
+// that have been revoked.
+func (s *AuthService) ValidateToken(ctx context.Context, tokenString string) (jwt.MapClaims, error) {
+	claims, err := s.parseToken(tokenString, TokenTypeAccess)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, err
 	}
 
-	accessToken, err := s.generateToken(user, s.cfg.JWT.Expiration)
+	userID, _ := claims["user_id"].(float64)
+	tokenVersion, _ := claims["tv"].(float64)
+	current, err := s.tokenVersions.Current(ctx, uint(userID))
 	if err != nil {
 		return nil, err
 	}
+	if int(tokenVersion) != current {
+		return nil, ErrTokenRevoked
+	}
 
-	// Do not return the password hash
-	user.Password = ""
+	if _, ok := claims["client_id"]; ok {
+		jti, _ := claims["jti"].(string)
+		active, err := s.oauth.TokenActive(ctx, jti)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, ErrTokenRevoked
+		}
+	}
+	return claims, nil
+}
 
-	return &models.AuthResponse{
-		AccessToken: accessToken,
-		User:        user,
-	}, nil
+// ValidateAPIKey checks an API key and returns claims equivalent to an access token's.
+func (s *AuthService) ValidateAPIKey(ctx context.Context, key string) (jwt.MapClaims, error) {
+	return s.apiKeys.Authenticate(ctx, key)
 }
 
-// ValidateToken parses and validates a JWT token string.
-func (s *AuthService) ValidateToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(s.cfg.JWT.Secret), nil
-	})
+// parseToken parses and validates a JWT token string of the given type.
+func (s *AuthService) parseToken(tokenString, tokenType string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, s.keys.Keyfunc,
+		jwt.WithValidMethods([]string{jwtkeys.AlgorithmRS256, jwtkeys.AlgorithmEdDSA}))
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if typ, _ := claims["typ"].(string); typ != tokenType {
+		return nil, errors.New("invalid token type")
 	}
 
-	return nil, errors.New("invalid token")
+	return claims, nil
 }
 
-// generateToken creates a new JWT token for a user.
-func (s *AuthService) generateToken(user *models.User, expiration time.Duration) (string, error) {
+// accessToken signs an access token for user. The "role" and "perms" claims let
+// middleware authorize requests without reading the database, and "tv" records
+// the user's token version so the token can be revoked. The "mfa" claim records
+// whether the user has two-factor enabled, and "mfa_at", when mfaAt is set, when
+// they last presented a second factor. "auth_time", when authAt is set, records
+// when the user logged in with a password, an identity provider or a second
+// factor; tokens from a refresh carry neither.
+func (s *AuthService) accessToken(ctx context.Context, user *models.User, authAt, mfaAt time.Time) (string, error) {
+	permissions, err := s.roles.RolePermissions(ctx, user.Role)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
-		"exp":     time.Now().Add(expiration).Unix(),
-		"iat":     time.Now().Unix(),
+		"role":    user.Role,
+		"perms":   permissions,
+		"tv":      user.TokenVersion,
+		"typ":     TokenTypeAccess,
+		"mfa":     user.TOTPEnabled,
+		"exp":     now.Add(s.cfg.JWT.Expiration).Unix(),
+		"iat":     now.Unix(),
+	}
+	if !authAt.IsZero() {
+		claims["auth_time"] = authAt.Unix()
+	}
+	if !mfaAt.IsZero() {
+		claims["mfa_at"] = mfaAt.Unix()
+	}
+	return s.signToken(claims)
+}
+
+// issueTokens creates an access token and a refresh token in the given family,
+// storing the refresh token through tokens.
+func (s *AuthService) issueTokens(ctx context.Context, tokens repository.RefreshTokenStore, user *models.User, familyID string, authAt, mfaAt time.Time) (*models.AuthResponse, *models.RefreshToken, error) {
+	now := time.Now()
+
+	accessToken, err := s.accessToken(ctx, user, authAt, mfaAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	jti, err := utils.GenerateToken(16)
+	if err != nil {
+		return nil, nil, err
+	}
+	stored := &models.RefreshToken{
+		ID:        jti,
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		ExpiresAt: now.Add(s.cfg.JWT.RefreshExpiration),
+	}
+	refreshToken, err := s.signToken(jwt.MapClaims{
+		"user_id": user.ID,
+		"typ":     TokenTypeRefresh,
+		"jti":     jti,
+		"fid":     familyID,
+		"exp":     stored.ExpiresAt.Unix(),
+		"iat":     now.Unix(),
+	})
+	if err != nil {
+		return nil, nil, err
 	}
+	if err := tokens.Create(ctx, stored); err != nil {
+		return nil, nil, err
+	}
+
+	// Do not return the password hash
+	user.Password = ""
+
+	return &models.AuthResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		User:         user,
+	}, stored, nil
+}
+
+// signToken signs a set of claims with the current signing key.
+func (s *AuthService) signToken(claims jwt.MapClaims) (string, error) {
+	return s.keys.Sign(claims)
+}
+
+// JWKS returns the public keys that verify tokens issued by this service.
+func (s *AuthService) JWKS() jwtkeys.JWKS {
+	return s.keys.JWKS()
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.cfg.JWT.Secret))
+// RotateSigningKeys picks up keys and the active kid changed by operators or
+// other instances, rotates the signing key when it is due and drops expired keys.
+func (s *AuthService) RotateSigningKeys(ctx context.Context) error {
+	return s.keys.Rotate(ctx, time.Now())
 }
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// healthCheckTimeout bounds each dependency check, so that a hung database
+// fails the probe instead of stalling it.
+const healthCheckTimeout = 2 * time.Second
+
+// MigrationChecker reports how many schema migrations have not been applied.
+// *db.Migrator implements it.
+type MigrationChecker interface {
+	Pending(ctx context.Context) (int, error)
+}
+
+// HealthService checks whether the service can handle requests: the database
+// answers, the schema is up to date and the configuration is valid.
+type HealthService struct {
+	db           *gorm.DB
+	migrations   MigrationChecker
+	cfg          *config.Config
+	startedAt    time.Time
+	shuttingDown atomic.Bool
+}
+
+// NewHealthService creates a new HealthService.
+func NewHealthService(db *gorm.DB, migrations MigrationChecker, cfg *config.Config) *HealthService {
+	return &HealthService{db: db, migrations: migrations, cfg: cfg, startedAt: time.Now()}
+}
+
+// BeginShutdown marks the server as shutting down. From then on it reports itself
+// not ready, so that load balancers stop sending it new requests.
+func (s *HealthService) BeginShutdown() {
+	s.shuttingDown.Store(true)
+}
+
+// Ready runs the readiness checks. Failures are logged rather than returned, as
+// the report is served without authentication.
+func (s *HealthService) Ready(ctx context.Context) *models.HealthReport {
+	report := s.check(ctx)
+	for i, check := range report.Checks {
+		if check.Error != "" {
+			log.Printf("Readiness check %s failed: %s", check.Name, check.Error)
+			report.Checks[i].Error = ""
+		}
+	}
+	return report
+}
+
+// Report runs the readiness checks and adds their errors, the uptime and the
+// database connection pool statistics.
+func (s *HealthService) Report(ctx context.Context) *models.HealthReport {
+	report := s.check(ctx)
+	report.Uptime = time.Since(s.startedAt).Round(time.Second).String()
+	if sqlDB, err := s.db.DB(); err == nil {
+		stats := sqlDB.Stats()
+		report.DatabasePool = &models.DatabasePoolStats{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDuration:       stats.WaitDuration.String(),
+		}
+	}
+	return report
+}
+
+// check runs every readiness check, timing each one.
+func (s *HealthService) check(ctx context.Context) *models.HealthReport {
+	report := &models.HealthReport{Status: models.HealthStatusOK}
+	run := func(name string, check func(ctx context.Context) error) {
+		ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+		start := time.Now()
+		err := check(ctx)
+		result := models.HealthCheck{
+			Name:      name,
+			Status:    models.HealthStatusOK,
+			LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if err != nil {
+			result.Status, result.Error = models.HealthStatusFailed, err.Error()
+			report.Status = models.HealthStatusUnavailable
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	run("database", s.pingDatabase)
+	run("migrations", s.checkMigrations)
+	run("config", func(context.Context) error { return s.cfg.Validate() })
+
+	if s.shuttingDown.Load() {
+		report.Status = models.HealthStatusShuttingDown
+	}
+	return report
+}
+
+// pingDatabase checks that a database connection can be used.
+func (s *HealthService) pingDatabase(ctx context.Context) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// checkMigrations checks that every migration in this build has been applied.
+func (s *HealthService) checkMigrations(ctx context.Context) error {
+	pending, err := s.migrations.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d pending migration(s)", pending)
+	}
+	return nil
+}
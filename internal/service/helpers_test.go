@@ -0,0 +1,37 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"wallet-service/internal/models"
+	"wallet-service/internal/service"
+	"wallet-service/pkg/money"
+)
+
+// usd parses a decimal amount in US dollars.
+func usd(t testing.TB, amount string) money.Money {
+	t.Helper()
+	m, err := money.Parse(amount, "USD")
+	if err != nil {
+		t.Fatalf("parse %q: %v", amount, err)
+	}
+	return m
+}
+
+// createWallet opens a wallet in currency for a user.
+func createWallet(t testing.TB, wallets *service.WalletService, userID uint, currency string) *models.Wallet {
+	t.Helper()
+	wallet, err := wallets.CreateWallet(context.Background(), userID, "Test wallet", currency)
+	if err != nil {
+		t.Fatalf("CreateWallet: %v", err)
+	}
+	return wallet
+}
+
+// fund credits one of a user's wallets.
+func fund(t testing.TB, wallets *service.WalletService, userID, walletID uint, amount money.Money) {
+	t.Helper()
+	if _, err := wallets.FundWallet(context.Background(), userID, walletID, amount); err != nil {
+		t.Fatalf("FundWallet: %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultIdempotencyTTL is how long idempotency keys are kept when no TTL is configured.
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// DefaultIdempotencyLease is how long a key stays in flight when no lease is configured.
+	DefaultIdempotencyLease = time.Minute
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key is replayed with a different request payload.
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+	// ErrIdempotencyKeyInFlight is returned when the original request for a key has not finished yet.
+	ErrIdempotencyKeyInFlight = errors.New("a request with this idempotency key is still being processed")
+	// ErrIdempotencyOutcomeUnknown is returned when the original request for a key stopped
+	// without recording a response, so it may or may not have taken effect.
+	ErrIdempotencyOutcomeUnknown = errors.New("the outcome of the original request with this idempotency key is unknown; check the transaction history before retrying with a new key")
+)
+
+// IdempotencyService reserves idempotency keys and stores the responses they produced.
+type IdempotencyService struct {
+	idempotencyRepo *repository.IdempotencyRepository
+	ttl             time.Duration
+	lease           time.Duration
+}
+
+// NewIdempotencyService creates a new IdempotencyService.
+func NewIdempotencyService(idempotencyRepo *repository.IdempotencyRepository, cfg *config.Config) *IdempotencyService {
+	ttl := cfg.Idempotency.TTL
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	lease := cfg.Idempotency.Lease
+	if lease <= 0 {
+		lease = DefaultIdempotencyLease
+	}
+	return &IdempotencyService{idempotencyRepo: idempotencyRepo, ttl: ttl, lease: lease}
+}
+
+// Begin reserves key for a user's request identified by fingerprint.
+// When the key was already used for the same request and that request has completed,
+// Begin returns the stored record and true so the caller can replay its response.
+// A key left in flight for longer than the lease, because the process handling it
+// died or failed to store the response, is never taken over: the original request
+// may already have moved money, so Begin returns ErrIdempotencyOutcomeUnknown.
+func (s *IdempotencyService) Begin(ctx context.Context, userID uint, key, fingerprint string) (*models.IdempotencyKey, bool, error) {
+	for {
+		record := &models.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: fingerprint,
+			ExpiresAt:   time.Now().Add(s.ttl),
+		}
+		reserved, err := s.idempotencyRepo.Reserve(ctx, record)
+		if err != nil {
+			return nil, false, err
+		}
+		if reserved {
+			return record, false, nil
+		}
+
+		existing, err := s.idempotencyRepo.FindByUserAndKey(ctx, userID, key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue // released or purged in the meantime
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		if time.Now().After(existing.ExpiresAt) {
+			if err := s.idempotencyRepo.Delete(ctx, existing.ID); err != nil {
+				return nil, false, err
+			}
+			continue
+		}
+		if existing.Fingerprint != fingerprint {
+			return nil, false, ErrIdempotencyKeyReused
+		}
+		if existing.StatusCode == 0 {
+			if existing.UpdatedAt.After(time.Now().Add(-s.lease)) {
+				return nil, false, ErrIdempotencyKeyInFlight
+			}
+			return nil, false, ErrIdempotencyOutcomeUnknown
+		}
+		return existing, true, nil
+	}
+}
+
+// Lease is how long a reserved key stays in flight without being renewed.
+func (s *IdempotencyService) Lease() time.Duration {
+	return s.lease
+}
+
+// Renew extends the lease of a reserved key while its request is still running.
+func (s *IdempotencyService) Renew(ctx context.Context, record *models.IdempotencyKey) error {
+	now := time.Now()
+	renewed, err := s.idempotencyRepo.Renew(ctx, record.ID, now)
+	if err != nil {
+		return err
+	}
+	if !renewed {
+		return gorm.ErrRecordNotFound
+	}
+	record.UpdatedAt = now
+	return nil
+}
+
+// Complete stores the response produced for a reserved key.
+func (s *IdempotencyService) Complete(ctx context.Context, record *models.IdempotencyKey, statusCode int, contentType string, body []byte) error {
+	record.StatusCode = statusCode
+	record.ContentType = contentType
+	record.Body = body
+	return s.idempotencyRepo.Update(ctx, record)
+}
+
+// Release frees a reserved key without storing a response, so the request may be retried.
+func (s *IdempotencyService) Release(ctx context.Context, record *models.IdempotencyKey) error {
+	return s.idempotencyRepo.Delete(ctx, record.ID)
+}
+
+// PurgeExpired deletes every expired key and returns how many were removed.
+func (s *IdempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.idempotencyRepo.DeleteExpired(ctx, time.Now())
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/pkg/money"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnbalancedEntry is returned when a journal entry's postings do not sum to zero.
+var ErrUnbalancedEntry = errors.New("journal entry postings must sum to zero")
+
+// LedgerService records balanced journal entries in the double-entry ledger.
+// Wallet balances are a cache of their ledger accounts and must only change
+// alongside a posted entry in the same database transaction.
+type LedgerService struct {
+	ledgerRepo repository.LedgerStore
+}
+
+// NewLedgerService creates a new LedgerService.
+func NewLedgerService(ledgerRepo repository.LedgerStore) *LedgerService {
+	return &LedgerService{ledgerRepo: ledgerRepo}
+}
+
+// WalletAccount returns the liability account backing a wallet, creating it if necessary.
+func (s *LedgerService) WalletAccount(ctx context.Context, wallet *models.Wallet) (*models.LedgerAccount, error) {
+	account := &models.LedgerAccount{
+		Code:     fmt.Sprintf("wallet:%d", wallet.ID),
+		Type:     models.AccountTypeLiability,
+		Currency: wallet.Currency,
+		WalletID: &wallet.ID,
+	}
+	if err := s.ledgerRepo.FindOrCreateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// SettlementAccount returns the asset account through which money in currency
+// enters and leaves the system, creating it if necessary.
+func (s *LedgerService) SettlementAccount(ctx context.Context, currency string) (*models.LedgerAccount, error) {
+	account := &models.LedgerAccount{
+		Code:     "settlement:" + strings.ToUpper(currency),
+		Type:     models.AccountTypeAsset,
+		Currency: currency,
+	}
+	if err := s.ledgerRepo.FindOrCreateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// Record posts a two-legged entry that debits one account and credits another by amount.
+func (s *LedgerService) Record(ctx context.Context, kind, reference, description string, debit, credit *models.LedgerAccount, amount money.Money) (*models.JournalEntry, error) {
+	entry := &models.JournalEntry{
+		Reference:   reference,
+		Kind:        kind,
+		Description: description,
+		Postings: []models.Posting{
+			{AccountID: debit.ID, Amount: amount, Currency: amount.Currency},
+			{AccountID: credit.ID, Amount: amount.Neg(), Currency: amount.Currency},
+		},
+	}
+	if err := s.Post(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Post validates that an entry is balanced in a single currency and persists it.
+func (s *LedgerService) Post(ctx context.Context, entry *models.JournalEntry) error {
+	if len(entry.Postings) < 2 {
+		return fmt.Errorf("%w: at least two postings are required", ErrUnbalancedEntry)
+	}
+
+	sum := money.Zero(entry.Postings[0].Currency)
+	for _, posting := range entry.Postings {
+		if posting.Amount.IsZero() {
+			return fmt.Errorf("%w: postings must be non-zero", ErrUnbalancedEntry)
+		}
+		var err error
+		if sum, err = sum.Add(posting.Amount); err != nil {
+			return fmt.Errorf("%w: %v", ErrUnbalancedEntry, err)
+		}
+	}
+	if !sum.IsZero() {
+		return ErrUnbalancedEntry
+	}
+
+	return s.ledgerRepo.CreateEntry(ctx, entry)
+}
+
+// WalletBalance derives a wallet's balance from the postings to its ledger account.
+func (s *LedgerService) WalletBalance(ctx context.Context, wallet *models.Wallet) (money.Money, error) {
+	account, err := s.ledgerRepo.FindAccountByWalletID(ctx, wallet.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return money.Zero(wallet.Currency), nil
+	}
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	sum, err := s.ledgerRepo.AccountBalance(ctx, account.ID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	// Wallet accounts are liabilities, so a positive balance is a net credit.
+	return money.New(-sum, wallet.Currency), nil
+}
+
+// Reconcile lists every wallet whose cached balance disagrees with the ledger.
+func (s *LedgerService) Reconcile(ctx context.Context) ([]models.LedgerDiscrepancy, error) {
+	return s.ledgerRepo.FindDiscrepancies(ctx)
+}
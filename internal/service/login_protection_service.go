@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrAccountLocked is returned when an account is locked after too many failed logins.
+	ErrAccountLocked = errors.New("account temporarily locked due to too many failed login attempts")
+	// ErrTooManyLoginAttempts is returned when a login comes too soon after a failure,
+	// or from an IP address that is locked out.
+	ErrTooManyLoginAttempts = errors.New("too many failed login attempts; try again later")
+	// ErrTwoFactorLocked is returned when a user's second factor is locked after too many wrong codes.
+	ErrTwoFactorLocked = errors.New("too many invalid two-factor codes; try again later")
+)
+
+// LoginThrottledError is returned when a login or two-factor code is refused
+// before it is checked. It wraps ErrAccountLocked, ErrTooManyLoginAttempts or
+// ErrTwoFactorLocked.
+type LoginThrottledError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string { return e.Err.Error() }
+
+func (e *LoginThrottledError) Unwrap() error { return e.Err }
+
+// LoginProtectionService defends logins against password guessing. Failed logins
+// are counted per account and per client IP; each failure makes the next attempt
+// wait longer, and too many failures within the window lock the account or IP out.
+type LoginProtectionService struct {
+	store  repository.LoginAttemptStore
+	events *repository.SecurityEventRepository
+	cfg    *config.Config
+}
+
+// NewLoginProtectionService creates a new LoginProtectionService.
+func NewLoginProtectionService(store repository.LoginAttemptStore, events *repository.SecurityEventRepository, cfg *config.Config) *LoginProtectionService {
+	return &LoginProtectionService{store: store, events: events, cfg: cfg}
+}
+
+// Check returns a *LoginThrottledError if a login for email from clientIP must be
+// refused without checking the password.
+func (s *LoginProtectionService) Check(ctx context.Context, email, clientIP string) error {
+	if err := s.check(ctx, models.LoginAttemptScopeAccount, normalizeEmail(email), ErrAccountLocked); err != nil {
+		return err
+	}
+	return s.check(ctx, models.LoginAttemptScopeIP, clientIP, ErrTooManyLoginAttempts)
+}
+
+// RecordFailure counts a failed login for email from clientIP, locking the account
+// or IP out once it reaches its limit. user is nil when no account has that email.
+func (s *LoginProtectionService) RecordFailure(ctx context.Context, email, clientIP string, user *models.User) error {
+	var userID *uint
+	if user != nil {
+		userID = &user.ID
+	}
+
+	if err := s.recordFailure(ctx, models.LoginAttemptScopeAccount, normalizeEmail(email), s.cfg.Login.MaxAccountFailures,
+		&models.SecurityEvent{UserID: userID, Type: models.SecurityEventAccountLocked, IPAddress: clientIP}); err != nil {
+		return err
+	}
+	return s.recordFailure(ctx, models.LoginAttemptScopeIP, clientIP, s.cfg.Login.MaxIPFailures,
+		&models.SecurityEvent{Type: models.SecurityEventIPLocked, IPAddress: clientIP})
+}
+
+// RecordSuccess clears the failed login counter of the account with email.
+// The IP counter is left to expire, so one valid account cannot be used to
+// reset an address that is guessing at others.
+func (s *LoginProtectionService) RecordSuccess(ctx context.Context, email string) error {
+	return s.store.Reset(ctx, models.LoginAttemptScopeAccount, normalizeEmail(email))
+}
+
+// CheckTwoFactor returns a *LoginThrottledError if a two-factor code from the user
+// must be refused without checking it.
+func (s *LoginProtectionService) CheckTwoFactor(ctx context.Context, userID uint) error {
+	return s.check(ctx, models.LoginAttemptScopeTwoFactor, twoFactorSubject(userID), ErrTwoFactorLocked)
+}
+
+// RecordTwoFactorFailure counts a wrong two-factor code from user, locking their
+// second factor once it reaches its limit.
+func (s *LoginProtectionService) RecordTwoFactorFailure(ctx context.Context, user *models.User) error {
+	return s.recordFailure(ctx, models.LoginAttemptScopeTwoFactor, twoFactorSubject(user.ID), s.cfg.Login.MaxTwoFactorFailures,
+		&models.SecurityEvent{UserID: &user.ID, Type: models.SecurityEventTwoFactorLocked})
+}
+
+// RecordTwoFactorSuccess clears the wrong two-factor code counter of the user.
+func (s *LoginProtectionService) RecordTwoFactorSuccess(ctx context.Context, userID uint) error {
+	return s.store.Reset(ctx, models.LoginAttemptScopeTwoFactor, twoFactorSubject(userID))
+}
+
+// Unlock lifts a lockout of user's account and second factor and records who lifted it.
+func (s *LoginProtectionService) Unlock(ctx context.Context, user *models.User, adminID uint) error {
+	if err := s.store.Reset(ctx, models.LoginAttemptScopeAccount, normalizeEmail(user.Email)); err != nil {
+		return err
+	}
+	if err := s.store.Reset(ctx, models.LoginAttemptScopeTwoFactor, twoFactorSubject(user.ID)); err != nil {
+		return err
+	}
+	return s.events.Create(ctx, &models.SecurityEvent{
+		UserID: &user.ID,
+		Type:   models.SecurityEventAccountUnlocked,
+		Detail: fmt.Sprintf("Unlocked by admin %d", adminID),
+	})
+}
+
+// SecurityEvents returns the most recent security events, optionally only those of one user.
+func (s *LoginProtectionService) SecurityEvents(ctx context.Context, userID *uint, limit int) ([]models.SecurityEvent, error) {
+	return s.events.List(ctx, userID, limit)
+}
+
+// PurgeStale deletes failed login counters that no longer affect logins and returns how many were removed.
+func (s *LoginProtectionService) PurgeStale(ctx context.Context) (int64, error) {
+	return s.store.DeleteStale(ctx, time.Now(), s.cfg.Login.FailureWindow)
+}
+
+// check returns a *LoginThrottledError wrapping locked if subject is locked out in
+// scope, or one wrapping ErrTooManyLoginAttempts if its last failure was too recent.
+func (s *LoginProtectionService) check(ctx context.Context, scope, subject string, locked error) error {
+	attempt, err := s.store.Get(ctx, scope, subject)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if attempt.Locked(now) {
+		return &LoginThrottledError{Err: locked, RetryAfter: attempt.LockedUntil.Sub(now)}
+	}
+	if now.Sub(attempt.LastFailureAt) < s.cfg.Login.FailureWindow {
+		if wait := attempt.LastFailureAt.Add(s.delay(attempt.Failures)).Sub(now); wait > 0 {
+			return &LoginThrottledError{Err: ErrTooManyLoginAttempts, RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// recordFailure counts one failure for scope and subject and locks it once max is reached.
+func (s *LoginProtectionService) recordFailure(ctx context.Context, scope, subject string, max int, event *models.SecurityEvent) error {
+	now := time.Now()
+	attempt, err := s.store.RecordFailure(ctx, scope, subject, now, s.cfg.Login.FailureWindow)
+	if err != nil {
+		return err
+	}
+	if attempt.Failures < max || attempt.Locked(now) {
+		return nil
+	}
+
+	if err := s.store.Lock(ctx, scope, subject, now.Add(s.cfg.Login.LockoutDuration)); err != nil {
+		return err
+	}
+	event.Detail = fmt.Sprintf("Locked for %s after %d failed attempts", s.cfg.Login.LockoutDuration, attempt.Failures)
+	log.Printf("🔒 Login %s %q locked: %s", scope, subject, event.Detail)
+	return s.events.Create(ctx, event)
+}
+
+// delay is the wait enforced after the given number of consecutive failures.
+func (s *LoginProtectionService) delay(failures int) time.Duration {
+	delay := s.cfg.Login.BaseDelay
+	for i := 1; i < failures && delay < s.cfg.Login.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.cfg.Login.MaxDelay {
+		return s.cfg.Login.MaxDelay
+	}
+	return delay
+}
+
+// twoFactorSubject is the subject under which wrong two-factor codes of a user are counted.
+func twoFactorSubject(userID uint) string {
+	return strconv.FormatUint(uint64(userID), 10)
+}
+
+// normalizeEmail makes failed login counters insensitive to the case and spacing of an email address.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,581 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/pkg/jwtkeys"
+	"wallet-service/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// oauthClientIDLength is the number of random bytes in a client ID.
+const oauthClientIDLength = 8
+
+// pkcePattern matches PKCE code verifiers (RFC 7636) and S256 code challenges, which
+// are base64url-encoded SHA-256 digests and therefore always 43 characters long.
+var pkcePattern = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// OAuthError is an error reported to OAuth clients with one of the error codes
+// defined by RFC 6749, such as invalid_grant.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+var (
+	// ErrOAuthInvalidClient is returned when a client is unknown, revoked or fails to authenticate.
+	ErrOAuthInvalidClient = &OAuthError{"invalid_client", "client authentication failed"}
+	// ErrOAuthInvalidGrant is returned when an authorization code is invalid, expired, used, or
+	// was issued to another client or redirect URI, or its PKCE verifier does not match.
+	ErrOAuthInvalidGrant = &OAuthError{"invalid_grant", "authorization code is invalid or expired"}
+	// ErrOAuthInvalidScope is returned when a client asks for a scope it is not registered for,
+	// or for none that the user's role grants.
+	ErrOAuthInvalidScope = &OAuthError{"invalid_scope", "requested scope is invalid or not allowed for this client"}
+	// ErrOAuthUnauthorizedClient is returned when a client uses a grant type it is not registered for.
+	ErrOAuthUnauthorizedClient = &OAuthError{"unauthorized_client", "client is not allowed to use this grant type"}
+	// ErrOAuthUnsupportedGrantType is returned for grant types the server does not implement.
+	ErrOAuthUnsupportedGrantType = &OAuthError{"unsupported_grant_type", "grant type is not supported"}
+	// ErrOAuthInvalidRedirectURI is returned when a redirect URI is not registered for the client.
+	ErrOAuthInvalidRedirectURI = &OAuthError{"invalid_request", "redirect_uri is not registered for this client"}
+	// ErrOAuthInvalidCodeChallenge is returned when a PKCE code challenge is malformed.
+	ErrOAuthInvalidCodeChallenge = &OAuthError{"invalid_request", "code_challenge must be a base64url-encoded SHA-256 digest"}
+)
+
+var (
+	// ErrOAuthClientNotFound is returned when revoking a client that does not exist or is already revoked.
+	ErrOAuthClientNotFound = errors.New("OAuth client not found")
+	// ErrUnknownOAuthScope is returned when registering a client with a scope that is neither a wallet scope nor a permission.
+	ErrUnknownOAuthScope = errors.New("unknown scope")
+	// ErrOAuthRedirectURIRequired is returned when registering an authorization_code client without redirect URIs.
+	ErrOAuthRedirectURIRequired = errors.New("authorization_code clients need at least one redirect URI")
+	// ErrOAuthServiceAccountRequired is returned when registering a client_credentials client without a service account.
+	ErrOAuthServiceAccountRequired = errors.New("client_credentials clients need a service account user_id")
+	// ErrOAuthPublicClientCredentials is returned when registering a public client for the client_credentials grant.
+	ErrOAuthPublicClientCredentials = errors.New("public clients cannot use the client_credentials grant")
+)
+
+// OAuthService is an OAuth2 authorization server. Registered clients obtain access
+// tokens through the client_credentials grant, acting as their service account,
+// or through the authorization_code grant with PKCE, acting for a user who
+// approved them. Tokens are JWTs like those issued at login, restricted by their
+// "scope" claim, and are recorded so that they can be introspected and revoked.
+type OAuthService struct {
+	clientRepo    *repository.OAuthClientRepository
+	codeRepo      *repository.OAuthAuthorizationCodeRepository
+	tokenRepo     *repository.OAuthAccessTokenRepository
+	userRepo      repository.UserStore
+	roles         *RoleService
+	tokenVersions *TokenVersionService
+	keys          *jwtkeys.Manager
+	cfg           *config.Config
+}
+
+// NewOAuthService creates a new OAuthService. Tokens are signed with keys.
+func NewOAuthService(clientRepo *repository.OAuthClientRepository, codeRepo *repository.OAuthAuthorizationCodeRepository, tokenRepo *repository.OAuthAccessTokenRepository, userRepo repository.UserStore, roles *RoleService, tokenVersions *TokenVersionService, keys *jwtkeys.Manager, cfg *config.Config) *OAuthService {
+	return &OAuthService{
+		clientRepo:    clientRepo,
+		codeRepo:      codeRepo,
+		tokenRepo:     tokenRepo,
+		userRepo:      userRepo,
+		roles:         roles,
+		tokenVersions: tokenVersions,
+		keys:          keys,
+		cfg:           cfg,
+	}
+}
+
+// CreateClient registers a new OAuth client. Confidential clients receive a
+// secret, which is not stored and cannot be shown again.
+func (s *OAuthService) CreateClient(ctx context.Context, req *models.CreateOAuthClientRequest) (*models.OAuthClientCreated, error) {
+	grantTypes := uniquePermissions(req.GrantTypes)
+	client := &models.OAuthClient{
+		Name:         req.Name,
+		Confidential: req.Confidential,
+		RedirectURIs: uniquePermissions(req.RedirectURIs),
+		GrantTypes:   grantTypes,
+	}
+
+	if client.AllowsGrant(models.GrantTypeAuthorizationCode) && len(client.RedirectURIs) == 0 {
+		return nil, ErrOAuthRedirectURIRequired
+	}
+	if client.AllowsGrant(models.GrantTypeClientCredentials) {
+		if !client.Confidential {
+			return nil, ErrOAuthPublicClientCredentials
+		}
+		if req.UserID == nil {
+			return nil, ErrOAuthServiceAccountRequired
+		}
+		if _, err := s.userRepo.FindByID(ctx, *req.UserID); err != nil {
+			return nil, err
+		}
+		client.UserID = req.UserID
+	}
+
+	scopes, err := s.validateScopes(ctx, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	client.Scopes = scopes
+
+	id := make([]byte, oauthClientIDLength)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	client.ID = hex.EncodeToString(id)
+
+	var secret string
+	if client.Confidential {
+		if secret, err = utils.GenerateToken(32); err != nil {
+			return nil, err
+		}
+		client.SecretHash = utils.HashToken(secret)
+	}
+
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return nil, err
+	}
+	return &models.OAuthClientCreated{Client: client, ClientSecret: secret}, nil
+}
+
+// ListClients returns every registered OAuth client, including revoked ones.
+func (s *OAuthService) ListClients(ctx context.Context) ([]models.OAuthClient, error) {
+	return s.clientRepo.List(ctx)
+}
+
+// RevokeClient revokes an OAuth client along with every access token issued to it.
+func (s *OAuthService) RevokeClient(ctx context.Context, clientID string) error {
+	now := time.Now()
+	revoked, err := s.clientRepo.Revoke(ctx, clientID, now)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrOAuthClientNotFound
+	}
+	return s.tokenRepo.RevokeForClient(ctx, clientID, now)
+}
+
+// Authorize records that the user approved a client's authorization request and
+// returns the client's redirect URI carrying a single-use authorization code.
+// PKCE with the S256 method is required of every client.
+func (s *OAuthService) Authorize(ctx context.Context, userID uint, req *models.OAuthAuthorizeRequest) (*models.OAuthAuthorizeResponse, error) {
+	client, err := s.activeClient(ctx, req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if !client.AllowsRedirectURI(req.RedirectURI) {
+		return nil, ErrOAuthInvalidRedirectURI
+	}
+	if !client.AllowsGrant(models.GrantTypeAuthorizationCode) {
+		return nil, ErrOAuthUnauthorizedClient
+	}
+	if len(req.CodeChallenge) != 43 || !pkcePattern.MatchString(req.CodeChallenge) {
+		return nil, ErrOAuthInvalidCodeChallenge
+	}
+	scopes, err := resolveScopes(client, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.codeRepo.Create(ctx, &models.OAuthAuthorizationCode{
+		CodeHash:      utils.HashToken(code),
+		ClientID:      client.ID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(s.cfg.OAuth.CodeTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	redirect, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		return nil, ErrOAuthInvalidRedirectURI
+	}
+	query := redirect.Query()
+	query.Set("code", code)
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	redirect.RawQuery = query.Encode()
+	return &models.OAuthAuthorizeResponse{RedirectURI: redirect.String()}, nil
+}
+
+// Token handles a token request from a client authenticated with clientID and
+// clientSecret, which the caller takes from HTTP Basic auth or the request body.
+func (s *OAuthService) Token(ctx context.Context, req *models.OAuthTokenRequest, clientID, clientSecret string) (*models.OAuthTokenResponse, error) {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case models.GrantTypeClientCredentials:
+		if !client.Confidential || client.UserID == nil || !client.AllowsGrant(models.GrantTypeClientCredentials) {
+			return nil, ErrOAuthUnauthorizedClient
+		}
+		scopes, err := resolveScopes(client, req.Scope)
+		if err != nil {
+			return nil, err
+		}
+		user, err := s.userRepo.FindByID(ctx, *client.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return s.issueToken(ctx, client, user, scopes, "")
+
+	case models.GrantTypeAuthorizationCode:
+		if !client.AllowsGrant(models.GrantTypeAuthorizationCode) {
+			return nil, ErrOAuthUnauthorizedClient
+		}
+		return s.exchangeCode(ctx, client, req)
+	}
+	return nil, ErrOAuthUnsupportedGrantType
+}
+
+// exchangeCode redeems an authorization code for an access token. The code is
+// only consumed once it is known to belong to client, redirect URI and PKCE
+// verifier, so that a client holding a stolen code cannot burn it. A code
+// presented again by its client revokes the tokens issued for it (RFC 6749
+// section 4.1.2), since one of the two requests did not come from the client.
+func (s *OAuthService) exchangeCode(ctx context.Context, client *models.OAuthClient, req *models.OAuthTokenRequest) (*models.OAuthTokenResponse, error) {
+	hash := utils.HashToken(req.Code)
+	code, err := s.codeRepo.FindByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOAuthInvalidGrant
+		}
+		return nil, err
+	}
+	if code.ClientID != client.ID {
+		return nil, ErrOAuthInvalidGrant
+	}
+	now := time.Now()
+	if code.UsedAt != nil {
+		return nil, s.codeReplayed(ctx, hash, now)
+	}
+	if !now.Before(code.ExpiresAt) || code.RedirectURI != req.RedirectURI || !verifyPKCE(req.CodeVerifier, code.CodeChallenge) {
+		return nil, ErrOAuthInvalidGrant
+	}
+
+	if _, err := s.codeRepo.Consume(ctx, hash, now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, s.codeReplayed(ctx, hash, now) // redeemed concurrently
+		}
+		return nil, err
+	}
+	user, err := s.userRepo.FindByID(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOAuthInvalidGrant
+		}
+		return nil, err
+	}
+	return s.issueToken(ctx, client, user, strings.Fields(code.Scope), hash)
+}
+
+// codeReplayed revokes the tokens issued for a reused authorization code and
+// returns the error reported to the client.
+func (s *OAuthService) codeReplayed(ctx context.Context, codeHash string, now time.Time) error {
+	if err := s.tokenRepo.RevokeForCode(ctx, codeHash, now); err != nil {
+		return err
+	}
+	return ErrOAuthInvalidGrant
+}
+
+// Introspect reports whether an access token issued to the authenticated client
+// is active (RFC 7662). Only confidential clients may introspect tokens, and tokens
+// of other clients are reported as inactive.
+func (s *OAuthService) Introspect(ctx context.Context, token, clientID, clientSecret string) (*models.OAuthIntrospection, error) {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !client.Confidential {
+		return nil, ErrOAuthInvalidClient
+	}
+
+	claims, ok := s.parseToken(token)
+	if !ok || claims["client_id"] != client.ID {
+		return &models.OAuthIntrospection{Active: false}, nil
+	}
+	jti, _ := claims["jti"].(string)
+	active, err := s.TokenActive(ctx, jti)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		active, err = s.currentTokenVersion(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !active {
+		return &models.OAuthIntrospection{Active: false}, nil
+	}
+
+	userID, _ := claims["user_id"].(float64)
+	expiresAt, _ := claims["exp"].(float64)
+	issuedAt, _ := claims["iat"].(float64)
+	scope, _ := claims["scope"].(string)
+	return &models.OAuthIntrospection{
+		Active:    true,
+		Scope:     scope,
+		ClientID:  client.ID,
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		TokenType: "Bearer",
+		ExpiresAt: int64(expiresAt),
+		IssuedAt:  int64(issuedAt),
+	}, nil
+}
+
+// Revoke revokes an access token issued to the authenticated client (RFC 7009).
+// Invalid tokens and tokens of other clients are ignored rather than reported.
+func (s *OAuthService) Revoke(ctx context.Context, token, clientID, clientSecret string) error {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+	claims, ok := s.parseToken(token)
+	if !ok {
+		return nil
+	}
+	jti, _ := claims["jti"].(string)
+	return s.tokenRepo.Revoke(ctx, jti, client.ID, time.Now())
+}
+
+// TokenActive reports whether the OAuth access token with the given jti exists
+// and has been neither revoked nor outlived.
+func (s *OAuthService) TokenActive(ctx context.Context, jti string) (bool, error) {
+	token, err := s.tokenRepo.FindByID(ctx, jti)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return token.RevokedAt == nil && time.Now().Before(token.ExpiresAt), nil
+}
+
+// PurgeExpired deletes expired authorization codes and access tokens and returns how many were removed.
+func (s *OAuthService) PurgeExpired(ctx context.Context) (int64, error) {
+	now := time.Now()
+	codes, err := s.codeRepo.DeleteExpired(ctx, now)
+	if err != nil {
+		return codes, err
+	}
+	tokens, err := s.tokenRepo.DeleteExpired(ctx, now)
+	return codes + tokens, err
+}
+
+// issueToken signs and records an access token for the client acting as user.
+// Wallet scopes are always granted; permission scopes are granted only when the
+// user's role grants the permission, and become the token's "perms" claim. The
+// wallet:write scope also brings the models.WalletWritePermissions the role grants.
+// codeHash names the authorization code the token is issued for, if any.
+func (s *OAuthService) issueToken(ctx context.Context, client *models.OAuthClient, user *models.User, scopes []string, codeHash string) (*models.OAuthTokenResponse, error) {
+	rolePermissions, err := s.roles.RolePermissions(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[string]bool, len(rolePermissions))
+	for _, permission := range rolePermissions {
+		held[permission] = true
+	}
+	granted := []string{}
+	permissions := []string{}
+	for _, scope := range scopes {
+		switch {
+		case isWalletScope(scope):
+			granted = append(granted, scope)
+			if scope == models.ScopeWalletWrite {
+				permissions = append(permissions, intersectPermissions(models.WalletWritePermissions, rolePermissions)...)
+			}
+		case held[scope]:
+			granted = append(granted, scope)
+			permissions = append(permissions, scope)
+		}
+	}
+	if len(granted) == 0 {
+		return nil, ErrOAuthInvalidScope
+	}
+	permissions = uniquePermissions(permissions)
+
+	jti, err := utils.GenerateToken(16)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	stored := &models.OAuthAccessToken{
+		ID:        jti,
+		ClientID:  client.ID,
+		UserID:    user.ID,
+		Scope:     strings.Join(granted, " "),
+		CodeHash:  codeHash,
+		ExpiresAt: now.Add(s.cfg.OAuth.AccessTokenTTL),
+	}
+	accessToken, err := s.keys.Sign(jwt.MapClaims{
+		"user_id":   user.ID,
+		"email":     user.Email,
+		"role":      user.Role,
+		"perms":     permissions,
+		"tv":        user.TokenVersion,
+		"typ":       TokenTypeAccess,
+		"mfa":       user.TOTPEnabled,
+		"scope":     stored.Scope,
+		"client_id": client.ID,
+		"jti":       jti,
+		"exp":       stored.ExpiresAt.Unix(),
+		"iat":       now.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokenRepo.Create(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	return &models.OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.cfg.OAuth.AccessTokenTTL.Seconds()),
+		Scope:       stored.Scope,
+	}, nil
+}
+
+// activeClient finds a client that has not been revoked.
+func (s *OAuthService) activeClient(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	client, err := s.clientRepo.FindByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOAuthInvalidClient
+		}
+		return nil, err
+	}
+	if client.RevokedAt != nil {
+		return nil, ErrOAuthInvalidClient
+	}
+	return client, nil
+}
+
+// authenticateClient finds an active client and checks its secret. Public clients
+// have no secret and are identified by their client ID alone.
+func (s *OAuthService) authenticateClient(ctx context.Context, clientID, clientSecret string) (*models.OAuthClient, error) {
+	client, err := s.activeClient(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if !client.Confidential {
+		if clientSecret != "" {
+			return nil, ErrOAuthInvalidClient
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, ErrOAuthInvalidClient
+	}
+	return client, nil
+}
+
+// parseToken verifies an access token issued by this server, reporting false for
+// any other token.
+func (s *OAuthService) parseToken(tokenString string) (jwt.MapClaims, bool) {
+	token, err := jwt.Parse(tokenString, s.keys.Keyfunc,
+		jwt.WithValidMethods([]string{jwtkeys.AlgorithmRS256, jwtkeys.AlgorithmEdDSA}))
+	if err != nil || !token.Valid {
+		return nil, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != TokenTypeAccess {
+		return nil, false
+	}
+	if _, ok := claims["client_id"].(string); !ok {
+		return nil, false
+	}
+	return claims, true
+}
+
+// currentTokenVersion reports whether the token's "tv" claim matches its user's
+// token version, which is bumped when the user logs out everywhere or changes role.
+func (s *OAuthService) currentTokenVersion(ctx context.Context, claims jwt.MapClaims) (bool, error) {
+	userID, _ := claims["user_id"].(float64)
+	tokenVersion, _ := claims["tv"].(float64)
+	current, err := s.tokenVersions.Current(ctx, uint(userID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return int(tokenVersion) == current, nil
+}
+
+// validateScopes deduplicates scopes and checks that each is a wallet scope or a permission.
+func (s *OAuthService) validateScopes(ctx context.Context, scopes []string) ([]string, error) {
+	result := uniquePermissions(scopes)
+	permissions := []string{}
+	for _, scope := range result {
+		if !isWalletScope(scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+	if _, err := s.roles.validatePermissions(ctx, permissions); err != nil {
+		if errors.Is(err, ErrUnknownPermission) {
+			return nil, ErrUnknownOAuthScope
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// resolveScopes parses a space-separated scope parameter, defaulting to every
+// scope the client is registered for, and checks the client may request each one.
+func resolveScopes(client *models.OAuthClient, scope string) ([]string, error) {
+	requested := uniquePermissions(strings.Fields(scope))
+	if len(requested) == 0 {
+		return client.Scopes, nil
+	}
+	if len(intersectPermissions(requested, client.Scopes)) != len(requested) {
+		return nil, ErrOAuthInvalidScope
+	}
+	return requested, nil
+}
+
+// isWalletScope reports whether scope grants access to the user's own wallets.
+func isWalletScope(scope string) bool {
+	return scope == models.ScopeWalletRead || scope == models.ScopeWalletWrite
+}
+
+// verifyPKCE checks a PKCE code verifier against an S256 code challenge.
+func verifyPKCE(verifier, challenge string) bool {
+	if !pkcePattern.MatchString(verifier) {
+		return false
+	}
+	digest := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(digest[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/db/dbtest"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+	"wallet-service/pkg/jwtkeys"
+)
+
+func TestOAuthService_AuthorizationCodeExchange(t *testing.T) {
+	gormDB := dbtest.Open(t)
+	ctx := context.Background()
+	users := repository.NewUserRepository(gormDB)
+	tokenVersions := service.NewTokenVersionService(users, time.Minute)
+	keys, err := jwtkeys.NewManager(ctx, t.TempDir(), jwtkeys.AlgorithmEdDSA, time.Hour, time.Hour, repository.NewSigningKeyRepository(gormDB))
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	cfg := &config.Config{OAuth: config.OAuthConfig{AccessTokenTTL: time.Hour, CodeTTL: time.Minute}}
+	oauth := service.NewOAuthService(
+		repository.NewOAuthClientRepository(gormDB),
+		repository.NewOAuthAuthorizationCodeRepository(gormDB),
+		repository.NewOAuthAccessTokenRepository(gormDB),
+		users,
+		service.NewRoleService(repository.NewRoleRepository(gormDB), tokenVersions),
+		tokenVersions,
+		keys,
+		cfg,
+	)
+
+	const redirectURI = "https://app.example.com/callback"
+	newClient := func() *models.OAuthClientCreated {
+		created, err := oauth.CreateClient(ctx, &models.CreateOAuthClientRequest{
+			Name:         "Budgeting App",
+			Confidential: true,
+			RedirectURIs: []string{redirectURI},
+			GrantTypes:   []string{models.GrantTypeAuthorizationCode},
+			Scopes:       []string{"wallet:read"},
+		})
+		if err != nil {
+			t.Fatalf("CreateClient: %v", err)
+		}
+		return created
+	}
+	client, other := newClient(), newClient()
+	user := dbtest.CreateUser(t, gormDB)
+
+	const verifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := sha256.Sum256([]byte(verifier))
+	authorized, err := oauth.Authorize(ctx, user.ID, &models.OAuthAuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            client.Client.ID,
+		RedirectURI:         redirectURI,
+		Scope:               "wallet:read",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(challenge[:]),
+		CodeChallengeMethod: "S256",
+	})
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	redirect, err := url.Parse(authorized.RedirectURI)
+	if err != nil {
+		t.Fatalf("parse redirect URI: %v", err)
+	}
+	code := redirect.Query().Get("code")
+
+	exchange := func(created *models.OAuthClientCreated, redirectURI, verifier string) (*models.OAuthTokenResponse, error) {
+		return oauth.Token(ctx, &models.OAuthTokenRequest{
+			GrantType:    models.GrantTypeAuthorizationCode,
+			Code:         code,
+			RedirectURI:  redirectURI,
+			CodeVerifier: verifier,
+		}, created.Client.ID, created.ClientSecret)
+	}
+
+	// None of these may burn the code for the client it was issued to.
+	rejected := map[string]func() (*models.OAuthTokenResponse, error){
+		"PKCE mismatch":      func() (*models.OAuthTokenResponse, error) { return exchange(client, redirectURI, verifier+"x") },
+		"wrong client":       func() (*models.OAuthTokenResponse, error) { return exchange(other, redirectURI, verifier) },
+		"wrong redirect URI": func() (*models.OAuthTokenResponse, error) { return exchange(client, redirectURI+"/other", verifier) },
+	}
+	for name, try := range rejected {
+		if _, err := try(); !errors.Is(err, service.ErrOAuthInvalidGrant) {
+			t.Errorf("%s: err = %v, want ErrOAuthInvalidGrant", name, err)
+		}
+	}
+
+	token, err := exchange(client, redirectURI, verifier)
+	if err != nil {
+		t.Fatalf("exchange after rejected attempts: %v", err)
+	}
+	active := func() bool {
+		introspection, err := oauth.Introspect(ctx, token.AccessToken, client.Client.ID, client.ClientSecret)
+		if err != nil {
+			t.Fatalf("Introspect: %v", err)
+		}
+		return introspection.Active
+	}
+	if !active() {
+		t.Fatal("token issued for the code is not active")
+	}
+
+	if _, err := exchange(client, redirectURI, verifier); !errors.Is(err, service.ErrOAuthInvalidGrant) {
+		t.Errorf("replay: err = %v, want ErrOAuthInvalidGrant", err)
+	}
+	if active() {
+		t.Error("token issued for a replayed code is still active")
+	}
+}
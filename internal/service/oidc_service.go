@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/pkg/oidc"
+	"wallet-service/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrOIDCDisabled is returned when no external identity provider is configured.
+	ErrOIDCDisabled = errors.New("external login is not configured")
+	// ErrInvalidOIDCState is returned when a callback's state is unknown, expired or already used.
+	ErrInvalidOIDCState = errors.New("invalid or expired login state")
+	// ErrOIDCLoginFailed is returned when the provider rejects the code or returns an invalid ID token.
+	ErrOIDCLoginFailed = errors.New("external login failed")
+	// ErrOIDCEmailRequired is returned when the provider does not share the user's email address.
+	ErrOIDCEmailRequired = errors.New("the identity provider did not share an email address")
+	// ErrOIDCAccountExists is returned when the email address belongs to an existing account
+	// that cannot be linked automatically, because the provider or the account has not verified it.
+	ErrOIDCAccountExists = errors.New("an account with this email address already exists; log in with your password")
+)
+
+// OIDCService logs users in through an external OpenID Connect provider. The
+// provider's subject is linked to a local user on first login, creating the user
+// and their primary wallet if needed; later logins find the user by that link.
+type OIDCService struct {
+	provider     *oidc.Provider
+	stateRepo    *repository.OIDCLoginStateRepository
+	identityRepo *repository.UserIdentityRepository
+	userRepo     repository.UserStore
+	auth         *AuthService
+	cfg          *config.Config
+}
+
+// NewOIDCService creates a new OIDCService. provider is nil when external login is disabled.
+func NewOIDCService(provider *oidc.Provider, stateRepo *repository.OIDCLoginStateRepository, identityRepo *repository.UserIdentityRepository, userRepo repository.UserStore, auth *AuthService, cfg *config.Config) *OIDCService {
+	return &OIDCService{
+		provider:     provider,
+		stateRepo:    stateRepo,
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		auth:         auth,
+		cfg:          cfg,
+	}
+}
+
+// Authorize starts an external login and returns the provider URL to send the
+// user to. The state, with the nonce and PKCE verifier the callback needs, is
+// stored until it is used or expires.
+func (s *OIDCService) Authorize(ctx context.Context) (*models.OIDCAuthorizationResponse, error) {
+	if s.provider == nil {
+		return nil, ErrOIDCDisabled
+	}
+
+	state, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := utils.GenerateToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.stateRepo.Create(ctx, &models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(s.cfg.OIDC.StateTTL),
+	}); err != nil {
+		return nil, err
+	}
+	return &models.OIDCAuthorizationResponse{AuthorizationURL: authURL, State: state}, nil
+}
+
+// Callback completes an external login with the code and state the provider
+// returned, and logs the user in as Login does.
+func (s *OIDCService) Callback(ctx context.Context, req *models.OIDCCallbackRequest) (*models.AuthResponse, error) {
+	if s.provider == nil {
+		return nil, ErrOIDCDisabled
+	}
+
+	state, err := s.stateRepo.Consume(ctx, utils.HashToken(req.State), time.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidOIDCState
+		}
+		return nil, err
+	}
+
+	claims, err := s.provider.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		log.Printf("External login failed: %v", err)
+		return nil, ErrOIDCLoginFailed
+	}
+
+	user, err := s.findOrCreateUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	return s.auth.LoginExternal(ctx, user)
+}
+
+// PurgeExpiredStates deletes login states that can no longer be used and returns how many were removed.
+func (s *OIDCService) PurgeExpiredStates(ctx context.Context) (int64, error) {
+	return s.stateRepo.DeleteExpired(ctx, time.Now())
+}
+
+// findOrCreateUser returns the user linked to the provider identity. On first
+// login the identity is linked to the user with the same email address, if both
+// the provider and the user have verified it, or else to a new user.
+func (s *OIDCService) findOrCreateUser(ctx context.Context, claims *oidc.Claims) (*models.User, error) {
+	identity, err := s.identityRepo.FindBySubject(ctx, claims.Issuer, claims.Subject)
+	if err == nil {
+		return s.userRepo.FindByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if claims.Email == "" {
+		return nil, ErrOIDCEmailRequired
+	}
+	user, err := s.userRepo.FindByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		// Linking on an unverified address would let anyone claim the account.
+		if !claims.EmailVerified || !user.EmailVerified() {
+			return nil, ErrOIDCAccountExists
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		name := claims.Name
+		if name == "" {
+			name, _, _ = strings.Cut(claims.Email, "@")
+		}
+		if user, err = s.auth.RegisterExternal(ctx, name, claims.Email, claims.EmailVerified); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	if err := s.identityRepo.Create(ctx, &models.UserIdentity{
+		UserID:  user.ID,
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/db/dbtest"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+	"wallet-service/pkg/oidc"
+	"wallet-service/pkg/oidc/oidctest"
+	"wallet-service/pkg/utils"
+)
+
+// The callbacks below all fail before a user is logged in, so the service is
+// built without an AuthService.
+func TestOIDCService_CallbackRejectsMismatches(t *testing.T) {
+	gormDB := dbtest.Open(t)
+	ctx := context.Background()
+
+	mock, err := oidctest.NewServer("wallet-service", "secret")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer mock.Close()
+	cfg := &config.Config{OIDC: config.OIDCConfig{StateTTL: 10 * time.Minute}}
+	oidcService := service.NewOIDCService(
+		oidc.NewProvider(mock.ClientConfig("http://localhost:8080/login/callback")),
+		repository.NewOIDCLoginStateRepository(gormDB),
+		repository.NewUserIdentityRepository(gormDB),
+		repository.NewUserRepository(gormDB),
+		nil,
+		cfg,
+	)
+
+	// start begins a login and returns the state and the code the provider issued.
+	start := func() (state, code string) {
+		t.Helper()
+		authorization, err := oidcService.Authorize(ctx)
+		if err != nil {
+			t.Fatalf("Authorize: %v", err)
+		}
+		code, state, err = mock.Approve(authorization.AuthorizationURL)
+		if err != nil {
+			t.Fatalf("Approve: %v", err)
+		}
+		if state != authorization.State {
+			t.Fatalf("provider returned state %q, want %q", state, authorization.State)
+		}
+		return state, code
+	}
+	callback := func(code, state string) error {
+		t.Helper()
+		_, err := oidcService.Callback(ctx, &models.OIDCCallbackRequest{Code: code, State: state})
+		return err
+	}
+	tamper := func(state, column string, value interface{}) {
+		t.Helper()
+		err := gormDB.Model(&models.OIDCLoginState{}).Where("state_hash = ?", utils.HashToken(state)).Update(column, value).Error
+		if err != nil {
+			t.Fatalf("update %s: %v", column, err)
+		}
+	}
+
+	state, code := start()
+	if err := callback(code, "not-"+state); !errors.Is(err, service.ErrInvalidOIDCState) {
+		t.Fatalf("unknown state: err = %v, want ErrInvalidOIDCState", err)
+	}
+
+	// The state's PKCE verifier does not match the challenge the code was issued for.
+	tamper(state, "code_verifier", "another-verifier")
+	if err := callback(code, state); !errors.Is(err, service.ErrOIDCLoginFailed) {
+		t.Fatalf("PKCE mismatch: err = %v, want ErrOIDCLoginFailed", err)
+	}
+	// A state completes at most one callback, even a failed one.
+	if err := callback(code, state); !errors.Is(err, service.ErrInvalidOIDCState) {
+		t.Fatalf("reused state: err = %v, want ErrInvalidOIDCState", err)
+	}
+
+	// The ID token carries the nonce of the login the code was issued for.
+	state, code = start()
+	tamper(state, "nonce", "another-nonce")
+	if err := callback(code, state); !errors.Is(err, service.ErrOIDCLoginFailed) {
+		t.Fatalf("nonce mismatch: err = %v, want ErrOIDCLoginFailed", err)
+	}
+
+	// A code from one login cannot complete another.
+	first, _ := start()
+	_, second := start()
+	if err := callback(second, first); !errors.Is(err, service.ErrOIDCLoginFailed) {
+		t.Fatalf("swapped code: err = %v, want ErrOIDCLoginFailed", err)
+	}
+
+	state, code = start()
+	tamper(state, "expires_at", time.Now().Add(-time.Minute))
+	if err := callback(code, state); !errors.Is(err, service.ErrInvalidOIDCState) {
+		t.Fatalf("expired state: err = %v, want ErrInvalidOIDCState", err)
+	}
+}
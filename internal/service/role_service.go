@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRoleNotFound is returned when a role does not exist.
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleExists is returned when creating a role whose name is taken.
+	ErrRoleExists = errors.New("role already exists")
+	// ErrInvalidRoleName is returned when a role name is not lower-case letters, digits and underscores.
+	ErrInvalidRoleName = errors.New("role name must start with a letter and contain only lower-case letters, digits and underscores")
+	// ErrUnknownPermission is returned when granting a permission that does not exist.
+	ErrUnknownPermission = errors.New("unknown permission")
+	// ErrRoleBuiltIn is returned when deleting a built-in role.
+	ErrRoleBuiltIn = errors.New("built-in roles cannot be deleted")
+	// ErrRoleImmutable is returned when changing the admin role, which always holds every permission.
+	ErrRoleImmutable = errors.New("the admin role cannot be changed")
+	// ErrRoleInUse is returned when deleting a role that users still hold.
+	ErrRoleInUse = errors.New("role is assigned to users")
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// RoleService manages roles and the permissions they grant.
+type RoleService struct {
+	roleRepo      *repository.RoleRepository
+	tokenVersions *TokenVersionService
+}
+
+// NewRoleService creates a new RoleService.
+func NewRoleService(roleRepo *repository.RoleRepository, tokenVersions *TokenVersionService) *RoleService {
+	return &RoleService{roleRepo: roleRepo, tokenVersions: tokenVersions}
+}
+
+// ListRoles returns every role with its permissions.
+func (s *RoleService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	return s.roleRepo.List(ctx)
+}
+
+// GetRole returns a role with its permissions.
+func (s *RoleService) GetRole(ctx context.Context, name string) (*models.Role, error) {
+	role, err := s.roleRepo.FindByName(ctx, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoleNotFound
+	}
+	return role, err
+}
+
+// CreateRole creates a custom role.
+func (s *RoleService) CreateRole(ctx context.Context, req *models.CreateRoleRequest) (*models.Role, error) {
+	if !roleNamePattern.MatchString(req.Name) {
+		return nil, ErrInvalidRoleName
+	}
+	if _, err := s.roleRepo.FindByName(ctx, req.Name); err == nil {
+		return nil, ErrRoleExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	permissions, err := s.validatePermissions(ctx, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{Name: req.Name, Description: req.Description, Permissions: permissions}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// UpdateRole replaces a role's description and permissions. Built-in roles other
+// than admin may be changed. Access tokens of the role's users are revoked, since
+// their permission claims are out of date.
+func (s *RoleService) UpdateRole(ctx context.Context, name string, req *models.UpdateRoleRequest) (*models.Role, error) {
+	if name == models.RoleAdmin {
+		return nil, ErrRoleImmutable
+	}
+	role, err := s.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := s.validatePermissions(ctx, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role.Description = req.Description
+	role.Permissions = permissions
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, err
+	}
+	if err := s.tokenVersions.RevokeRole(ctx, role.Name); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// DeleteRole deletes a custom role that no user holds.
+func (s *RoleService) DeleteRole(ctx context.Context, name string) error {
+	role, err := s.GetRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role.BuiltIn {
+		return ErrRoleBuiltIn
+	}
+	users, err := s.roleRepo.CountUsers(ctx, name)
+	if err != nil {
+		return err
+	}
+	if users > 0 {
+		return ErrRoleInUse
+	}
+	return s.roleRepo.Delete(ctx, role.ID)
+}
+
+// ListPermissions returns every permission that can be granted.
+func (s *RoleService) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	return s.roleRepo.ListPermissions(ctx)
+}
+
+// RolePermissions returns the permissions granted by the named role.
+func (s *RoleService) RolePermissions(ctx context.Context, name string) ([]string, error) {
+	role, err := s.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return role.Permissions, nil
+}
+
+// validatePermissions deduplicates and sorts permissions and checks that they all exist.
+func (s *RoleService) validatePermissions(ctx context.Context, permissions []string) ([]string, error) {
+	unique := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		unique[permission] = true
+	}
+	result := make([]string, 0, len(unique))
+	for permission := range unique {
+		result = append(result, permission)
+	}
+	sort.Strings(result)
+	if len(result) == 0 {
+		return result, nil
+	}
+
+	known, err := s.roleRepo.CountPermissions(ctx, result)
+	if err != nil {
+		return nil, err
+	}
+	if known != int64(len(result)) {
+		return nil, ErrUnknownPermission
+	}
+	return result, nil
+}
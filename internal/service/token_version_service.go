@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+	"wallet-service/internal/repository"
+)
+
+// TokenVersionService tracks each user's token version, which access tokens carry
+// in their "tv" claim. Revoking bumps the version, so tokens issued earlier stop
+// validating and clients must refresh to pick up the user's new role and permissions.
+//
+// Versions are cached for a short TTL so that validating a token does not read the
+// database on every request. Revocations made by this instance take effect at once;
+// those made by other instances take effect within the TTL.
+type TokenVersionService struct {
+	userRepo repository.UserStore
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	cache map[uint]cachedTokenVersion
+}
+
+type cachedTokenVersion struct {
+	version   int
+	fetchedAt time.Time
+}
+
+// NewTokenVersionService creates a new TokenVersionService caching versions for ttl.
+func NewTokenVersionService(userRepo repository.UserStore, ttl time.Duration) *TokenVersionService {
+	return &TokenVersionService{userRepo: userRepo, ttl: ttl, cache: make(map[uint]cachedTokenVersion)}
+}
+
+// Current returns the user's current token version.
+func (s *TokenVersionService) Current(ctx context.Context, userID uint) (int, error) {
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[userID]
+	s.mu.Unlock()
+	if ok && now.Sub(cached.fetchedAt) < s.ttl {
+		return cached.version, nil
+	}
+
+	version, err := s.userRepo.TokenVersion(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	s.cache[userID] = cachedTokenVersion{version: version, fetchedAt: now}
+	s.mu.Unlock()
+	return version, nil
+}
+
+// Revoke invalidates every access token issued to the user so far.
+func (s *TokenVersionService) Revoke(ctx context.Context, userID uint) error {
+	if err := s.userRepo.BumpTokenVersion(ctx, userID); err != nil {
+		return err
+	}
+	s.Forget(userID)
+	return nil
+}
+
+// Forget drops the cached version of a user whose token version was bumped
+// outside Revoke, such as within a larger database transaction. Call it once
+// that transaction has committed.
+func (s *TokenVersionService) Forget(userID uint) {
+	s.mu.Lock()
+	delete(s.cache, userID)
+	s.mu.Unlock()
+}
+
+// RevokeRole invalidates every access token issued so far to users holding the role.
+func (s *TokenVersionService) RevokeRole(ctx context.Context, role string) error {
+	if err := s.userRepo.BumpTokenVersionForRole(ctx, role); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.cache = make(map[uint]cachedTokenVersion)
+	s.mu.Unlock()
+	return nil
+}
+
+// Prune drops cached versions older than the TTL, which would be fetched again anyway.
+func (s *TokenVersionService) Prune() {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for userID, cached := range s.cache {
+		if now.Sub(cached.fetchedAt) >= s.ttl {
+			delete(s.cache, userID)
+		}
+	}
+}
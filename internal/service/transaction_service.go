@@ -1,26 +1,170 @@
 package service
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 	"wallet-service/internal/models"
 	"wallet-service/internal/repository"
+	"wallet-service/pkg/money"
+)
+
+const (
+	// DefaultTransactionPageSize is the page size used when a query does not specify a limit.
+	DefaultTransactionPageSize = 20
+	// MaxTransactionPageSize is the largest page a single query may request.
+	MaxTransactionPageSize = 100
+)
+
+var (
+	// ErrInvalidCursor is returned when a pagination cursor cannot be decoded, or was
+	// issued for a different wallet, sort order or set of filters.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidFilter is returned when transaction filters are malformed or contradictory.
+	ErrInvalidFilter = errors.New("invalid filter")
 )
 
 // TransactionService provides transaction-related services.
 type TransactionService struct {
-	transactionRepo *repository.TransactionRepository
+	transactionRepo repository.TransactionStore
 }
 
 // NewTransactionService creates a new TransactionService.
-func NewTransactionService(transactionRepo *repository.TransactionRepository) *TransactionService {
+func NewTransactionService(transactionRepo repository.TransactionStore) *TransactionService {
 	return &TransactionService{transactionRepo: transactionRepo}
 }
 
-// GetTransactionsByWalletID retrieves all transactions for a specific wallet.
-func (s *TransactionService) GetTransactionsByWalletID(walletID uint) ([]models.Transaction, error) {
-	return s.transactionRepo.FindByWalletID(walletID)
+// transactionCursor is the position of the last transaction on a page, together
+// with a hash of the query that produced the page. It is handed to clients as
+// opaque base64-encoded JSON.
+type transactionCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"id"`
+	Query     string    `json:"q"`
+}
+
+// ListTransactions returns one page of a wallet's transaction history, newest first
+// unless the query asks for ascending order. Amount filters are decimal strings in
+// the wallet's currency.
+func (s *TransactionService) ListTransactions(ctx context.Context, wallet *models.Wallet, query models.TransactionQuery) (*models.TransactionPage, error) {
+	filter := repository.TransactionFilter{
+		WalletID:  wallet.ID,
+		Type:      query.Type,
+		Status:    query.Status,
+		From:      query.From,
+		To:        query.To,
+		Reference: query.Reference,
+		Ascending: query.Order == "asc",
+		Limit:     query.Limit,
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultTransactionPageSize
+	}
+	if filter.Limit > MaxTransactionPageSize {
+		filter.Limit = MaxTransactionPageSize
+	}
+
+	var err error
+	if filter.MinAmount, err = parseAmountFilter(query.MinAmount, wallet.Currency); err != nil {
+		return nil, err
+	}
+	if filter.MaxAmount, err = parseAmountFilter(query.MaxAmount, wallet.Currency); err != nil {
+		return nil, err
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return nil, fmt.Errorf("%w: min_amount is greater than max_amount", ErrInvalidFilter)
+	}
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return nil, fmt.Errorf("%w: from is after to", ErrInvalidFilter)
+	}
+
+	// A cursor only continues the query it came from; with another order or other
+	// filters its position would skip or repeat rows.
+	queryHash := hashTransactionQuery(filter)
+	if query.Cursor != "" {
+		cursor, err := decodeTransactionCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Query != queryHash {
+			return nil, fmt.Errorf("%w: it was issued for a different order or filters", ErrInvalidCursor)
+		}
+		filter.AfterCreatedAt = &cursor.CreatedAt
+		filter.AfterID = cursor.ID
+	}
+
+	// Fetch one extra row to learn whether another page follows.
+	limit := filter.Limit
+	filter.Limit++
+	transactions, err := s.transactionRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.TransactionPage{Transactions: transactions}
+	if len(transactions) > limit {
+		page.Transactions = transactions[:limit]
+		last := page.Transactions[limit-1]
+		page.NextCursor = encodeTransactionCursor(transactionCursor{CreatedAt: last.CreatedAt, ID: last.ID, Query: queryHash})
+	}
+	return page, nil
 }
 
 // CreateTransaction creates a new transaction record.
-func (s *TransactionService) CreateTransaction(transaction *models.Transaction) error {
-	return s.transactionRepo.Create(transaction)
+func (s *TransactionService) CreateTransaction(ctx context.Context, transaction *models.Transaction) error {
+	return s.transactionRepo.Create(ctx, transaction)
+}
+
+// parseAmountFilter converts an optional decimal amount filter into minor units.
+func parseAmountFilter(raw, currency string) (*int64, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	amount, err := money.Parse(raw, currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+	}
+	return &amount.Amount, nil
+}
+
+// hashTransactionQuery identifies the wallet, order and filters of a query. The
+// page size is left out, so it may change from one page to the next.
+func hashTransactionQuery(filter repository.TransactionFilter) string {
+	data, _ := json.Marshal(struct {
+		WalletID  uint
+		Type      string
+		Status    string
+		MinAmount *int64
+		MaxAmount *int64
+		From      *time.Time
+		To        *time.Time
+		Reference string
+		Ascending bool
+	}{
+		filter.WalletID, filter.Type, filter.Status, filter.MinAmount, filter.MaxAmount,
+		filter.From, filter.To, filter.Reference, filter.Ascending,
+	})
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+func encodeTransactionCursor(cursor transactionCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTransactionCursor(raw string) (transactionCursor, error) {
+	var cursor transactionCursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
 }
@@ -0,0 +1,67 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+)
+
+func TestTransactionService_ListTransactionsCursor(t *testing.T) {
+	ctx := context.Background()
+	store := repository.NewMemoryTransactionStore()
+	wallet := &models.Wallet{ID: 1, Currency: "USD"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, kind := range []string{"deposit", "withdrawal", "deposit"} {
+		err := store.Create(ctx, &models.Transaction{
+			WalletID:  wallet.ID,
+			Type:      kind,
+			Amount:    usd(t, "1.00"),
+			Currency:  "USD",
+			Status:    "completed",
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+		})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	transactions := service.NewTransactionService(store)
+
+	first, err := transactions.ListTransactions(ctx, wallet, models.TransactionQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListTransactions: %v", err)
+	}
+	if len(first.Transactions) != 2 || first.NextCursor == "" {
+		t.Fatalf("first page has %d transactions and cursor %q, want 2 and a cursor", len(first.Transactions), first.NextCursor)
+	}
+
+	next, err := transactions.ListTransactions(ctx, wallet, models.TransactionQuery{Limit: 2, Cursor: first.NextCursor})
+	if err != nil {
+		t.Fatalf("ListTransactions with cursor: %v", err)
+	}
+	if len(next.Transactions) != 1 || next.NextCursor != "" || next.Transactions[0].ID != 1 {
+		t.Errorf("second page = %+v, want only transaction 1 and no cursor", next)
+	}
+
+	mismatched := map[string]models.TransactionQuery{
+		"order":  {Order: "asc", Cursor: first.NextCursor},
+		"filter": {Type: "deposit", Cursor: first.NextCursor},
+		"wallet": {Cursor: first.NextCursor},
+	}
+	for name, query := range mismatched {
+		w := wallet
+		if name == "wallet" {
+			w = &models.Wallet{ID: 2, Currency: "USD"}
+		}
+		if _, err := transactions.ListTransactions(ctx, w, query); !errors.Is(err, service.ErrInvalidCursor) {
+			t.Errorf("%s changed: err = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+
+	if _, err := transactions.ListTransactions(ctx, wallet, models.TransactionQuery{Cursor: "not-a-cursor"}); !errors.Is(err, service.ErrInvalidCursor) {
+		t.Errorf("garbage cursor: err = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"wallet-service/internal/config"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/pkg/totp"
+	"wallet-service/pkg/utils"
+)
+
+// recoveryCodeCount is the number of recovery codes issued at a time.
+const recoveryCodeCount = 10
+
+var (
+	// ErrTwoFactorAlreadyEnabled is returned when enrolling a user who already has two-factor enabled.
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnrolled is returned when confirming without having started enrollment.
+	ErrTwoFactorNotEnrolled = errors.New("two-factor enrollment has not been started")
+	// ErrTwoFactorNotEnabled is returned when an action requires two-factor but the user has not enabled it.
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrInvalidTwoFactorCode is returned when a TOTP or recovery code is wrong, expired or already used.
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+)
+
+// TwoFactorService manages TOTP two-factor enrollment, verification and recovery codes.
+// TOTP secrets are stored encrypted with mfa.encryption_key. Wrong codes are
+// counted by loginProtection, which locks the user's second factor after
+// login.max_two_factor_failures of them. Enabling or disabling two-factor
+// revokes the user's access tokens, whose "mfa" claim no longer holds.
+type TwoFactorService struct {
+	userRepo        repository.UserStore
+	recoveryRepo    *repository.RecoveryCodeRepository
+	loginProtection *LoginProtectionService
+	tokenVersions   *TokenVersionService
+	cfg             *config.Config
+}
+
+// NewTwoFactorService creates a new TwoFactorService.
+func NewTwoFactorService(userRepo repository.UserStore, recoveryRepo *repository.RecoveryCodeRepository, loginProtection *LoginProtectionService, tokenVersions *TokenVersionService, cfg *config.Config) *TwoFactorService {
+	return &TwoFactorService{userRepo: userRepo, recoveryRepo: recoveryRepo, loginProtection: loginProtection, tokenVersions: tokenVersions, cfg: cfg}
+}
+
+// Enroll starts enrollment by generating a new TOTP secret for the user. The
+// secret is not enforced until Confirm proves the user's authenticator holds it.
+func (s *TwoFactorService) Enroll(ctx context.Context, userID uint) (*models.TwoFactorEnrollment, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.cfg.MFA.SecretKey()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := utils.Seal(key, secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateTOTP(ctx, user.ID, sealed, false); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.cfg.MFA.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables two-factor authentication once the user proves their
+// authenticator produces valid codes, and returns their first recovery codes.
+// Tokens issued before then claim the user has no second factor, so they are
+// revoked and the user must log in again with a code.
+func (s *TwoFactorService) Confirm(ctx context.Context, userID uint, code string) (*models.RecoveryCodesResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if err := s.checkCode(ctx, user, code, s.verifyTOTP); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateTOTP(ctx, user.ID, user.TOTPSecret, true); err != nil {
+		return nil, err
+	}
+	if err := s.tokenVersions.Revoke(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(ctx, user.ID)
+}
+
+// Disable turns two-factor authentication off after verifying a TOTP or recovery
+// code, revoking the user's access tokens.
+func (s *TwoFactorService) Disable(ctx context.Context, userID uint, code string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if err := s.Verify(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateTOTP(ctx, user.ID, "", false); err != nil {
+		return err
+	}
+	if err := s.tokenVersions.Revoke(ctx, user.ID); err != nil {
+		return err
+	}
+	return s.recoveryRepo.DeleteByUserID(ctx, user.ID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after verifying a TOTP code.
+// A recovery code is not accepted here, so a leaked code cannot mint new ones.
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) (*models.RecoveryCodesResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.checkCode(ctx, user, code, s.verifyTOTP); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(ctx, user.ID)
+}
+
+// Verify accepts either a current TOTP code or an unused recovery code for the user.
+// Each TOTP code and each recovery code is accepted at most once. Once the user's
+// second factor is locked after too many wrong codes, it returns a *LoginThrottledError.
+func (s *TwoFactorService) Verify(ctx context.Context, user *models.User, code string) error {
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	return s.checkCode(ctx, user, code, s.verifyCode)
+}
+
+// checkCode checks code with verify unless the user's second factor is locked,
+// counting a wrong code towards the lockout and clearing the count on success.
+func (s *TwoFactorService) checkCode(ctx context.Context, user *models.User, code string, verify func(context.Context, *models.User, string) error) error {
+	if err := s.loginProtection.CheckTwoFactor(ctx, user.ID); err != nil {
+		return err
+	}
+
+	err := verify(ctx, user, code)
+	switch {
+	case errors.Is(err, ErrInvalidTwoFactorCode):
+		if err := s.loginProtection.RecordTwoFactorFailure(ctx, user); err != nil {
+			log.Printf("Failed to record invalid two-factor code for user %d: %v", user.ID, err)
+		}
+	case err == nil:
+		if err := s.loginProtection.RecordTwoFactorSuccess(ctx, user.ID); err != nil {
+			log.Printf("Failed to reset invalid two-factor code counter for user %d: %v", user.ID, err)
+		}
+	}
+	return err
+}
+
+// verifyCode accepts a TOTP code or, failing that, an unused recovery code.
+func (s *TwoFactorService) verifyCode(ctx context.Context, user *models.User, code string) error {
+	if err := s.verifyTOTP(ctx, user, code); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		return err
+	}
+
+	used, err := s.recoveryRepo.Consume(ctx, user.ID, utils.HashToken(normalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// verifyTOTP checks a TOTP code and records its time step so it cannot be replayed.
+func (s *TwoFactorService) verifyTOTP(ctx context.Context, user *models.User, code string) error {
+	secret, err := s.totpSecret(user)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	advanced, err := s.userRepo.AdvanceTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// totpSecret decrypts the user's TOTP secret. A secret that cannot be decrypted
+// with mfa.encryption_key is an error, never a code to compare against.
+func (s *TwoFactorService) totpSecret(user *models.User) (string, error) {
+	key, err := s.cfg.MFA.SecretKey()
+	if err != nil {
+		return "", err
+	}
+	return utils.Open(key, user.TOTPSecret)
+}
+
+// issueRecoveryCodes generates a fresh set of recovery codes, storing only their hashes.
+func (s *TwoFactorService) issueRecoveryCodes(ctx context.Context, userID uint) (*models.RecoveryCodesResponse, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = utils.HashToken(normalizeRecoveryCode(code))
+	}
+
+	if err := s.recoveryRepo.Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// generateRecoveryCode returns a random code such as "k3j9x-2mqp8".
+func generateRecoveryCode() (string, error) {
+	bytes := make([]byte, 7)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(bytes))[:10]
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// normalizeRecoveryCode makes recovery codes insensitive to case, spaces and dashes.
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
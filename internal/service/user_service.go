@@ -1,33 +1,36 @@
 package service
 
 import (
+	"context"
 	"wallet-service/internal/models"
 	"wallet-service/internal/repository"
 )
 
 type UserService struct {
-	Repo *repository.UserRepository
+	Repo repository.UserStore
 }
 
-func NewUserService(repo *repository.UserRepository) *UserService {
+func NewUserService(repo repository.UserStore) *UserService {
 	return &UserService{Repo: repo}
 }
 
-func (s *UserService) GetAllUsers() ([]models.User, error) {
-	return s.Repo.GetAll()
+func (s *UserService) GetAllUsers(ctx context.Context) ([]models.User, error) {
+	return s.Repo.GetAll(ctx)
 }
 
-func (s *UserService) GetUserByID(id uint) (*models.User, error) {
-	return s.Repo.FindByID(id)
+func (s *UserService) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+	return s.Repo.FindByID(ctx, id)
 }
 
-func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
-	return s.Repo.FindByEmail(email)
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return s.Repo.FindByEmail(ctx, email)
 }
 
-func (s *UserService) CreateUser(user *models.User) error {
-	return s.Repo.Create(user)
+func (s *UserService) CreateUser(ctx context.Context, user *models.User) error {
+	// Roles are assigned through the admin API, which requires roles:write
+	user.Role = models.RoleUser
+	return s.Repo.Create(ctx, user)
 }
-func (s *UserService) DeleteUser(id uint) error {
-	return s.Repo.Delete(id)
+func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
+	return s.Repo.Delete(ctx, id)
 }
@@ -1,48 +1,599 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"wallet-service/internal/models"
 	"wallet-service/internal/repository"
+	"wallet-service/pkg/money"
+	"wallet-service/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrWalletNotFound is returned when a wallet does not exist or belongs to another user.
+	ErrWalletNotFound = errors.New("wallet not found")
+	// ErrInvalidAmount is returned when an amount is not strictly positive.
+	ErrInvalidAmount = errors.New("amount must be greater than zero")
+	// ErrInsufficientFunds is returned when a wallet's balance cannot cover a debit.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrRecipientNotFound is returned when a transfer's recipient wallet cannot be resolved.
+	ErrRecipientNotFound = errors.New("recipient wallet not found")
+	// ErrAmbiguousRecipient is returned when a transfer names its recipient both by wallet ID and by email.
+	ErrAmbiguousRecipient = errors.New("specify either to_wallet_id or to_email, not both")
+	// ErrSelfTransfer is returned when a transfer's source and destination are the same wallet.
+	ErrSelfTransfer = errors.New("cannot transfer to the same wallet")
+	// ErrCurrencyMismatch is returned when an amount or transfer does not match a wallet's currency.
+	ErrCurrencyMismatch = errors.New("wallet currencies do not match")
+	// ErrWalletInactive is returned when funds would move into or out of a deactivated wallet.
+	ErrWalletInactive = errors.New("wallet is inactive")
+	// ErrTransactionNotFound is returned when a transaction to reverse does not exist.
+	ErrTransactionNotFound = errors.New("transaction not found")
+	// ErrTransactionNotReversible is returned when a transaction is not a completed
+	// deposit, withdrawal or transfer, for example because it was already reversed.
+	ErrTransactionNotReversible = errors.New("only completed deposits, withdrawals and transfers can be reversed")
 )
 
 // WalletService provides wallet-related services.
+// Every balance change is posted to the ledger, recorded as a transaction and
+// applied to the cached wallet balance within a single database transaction.
 type WalletService struct {
-	walletRepo *repository.WalletRepository
+	txManager  repository.TxManager
+	walletRepo repository.WalletStore
 }
 
-// NewWalletService creates a new WalletService.
-func NewWalletService(walletRepo *repository.WalletRepository) *WalletService {
-	return &WalletService{walletRepo: walletRepo}
+// NewWalletService creates a new WalletService that runs its units of work through txManager.
+func NewWalletService(txManager repository.TxManager, walletRepo repository.WalletStore) *WalletService {
+	return &WalletService{txManager: txManager, walletRepo: walletRepo}
 }
 
-// CreateWallet creates a new wallet for a user.
-func (s *WalletService) CreateWallet(userID uint, name string) (*models.Wallet, error) {
+// CreateWallet creates a new wallet in currency for a user along with its ledger account.
+// A user's first wallet becomes their default wallet. The user's row is locked
+// while their wallets are counted, so concurrent requests cannot both create a
+// first wallet.
+func (s *WalletService) CreateWallet(ctx context.Context, userID uint, name, currency string) (*models.Wallet, error) {
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	currency = strings.ToUpper(currency)
+
 	wallet := &models.Wallet{
-		UserID: userID,
-		Name:   name,
+		UserID:   userID,
+		Name:     name,
+		Currency: currency,
+		Balance:  money.Zero(currency),
+		IsActive: true,
+	}
+	err := s.txManager.WithinTx(ctx, func(repos repository.Repositories) error {
+		walletRepo := repos.Wallets
+
+		if _, err := repos.Users.FindByIDForUpdate(ctx, userID); err != nil {
+			return err
+		}
+		count, err := walletRepo.CountByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		wallet.IsDefault = count == 0
+
+		if err := walletRepo.Create(ctx, wallet); err != nil {
+			return err
+		}
+		_, err = NewLedgerService(repos.Ledger).WalletAccount(ctx, wallet)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// GetWalletByUserID retrieves a user's default wallet.
+func (s *WalletService) GetWalletByUserID(ctx context.Context, userID uint) (*models.Wallet, error) {
+	return s.walletRepo.FindByUserID(ctx, userID)
+}
+
+// ListWallets retrieves all wallets belonging to a user.
+func (s *WalletService) ListWallets(ctx context.Context, userID uint) ([]models.Wallet, error) {
+	return s.walletRepo.FindAllByUserID(ctx, userID)
+}
+
+// GetWallet retrieves one of a user's wallets by ID.
+// Wallets owned by other users are reported as ErrWalletNotFound.
+func (s *WalletService) GetWallet(ctx context.Context, userID, walletID uint) (*models.Wallet, error) {
+	return findOwnedWallet(ctx, s.walletRepo, userID, walletID)
+}
+
+// RenameWallet changes the name of one of a user's wallets.
+func (s *WalletService) RenameWallet(ctx context.Context, userID, walletID uint, name string) (*models.Wallet, error) {
+	wallet, err := findOwnedWallet(ctx, s.walletRepo, userID, walletID)
+	if err != nil {
+		return nil, err
 	}
-	if err := s.walletRepo.Create(wallet); err != nil {
+	if err := s.walletRepo.Rename(ctx, wallet.ID, name); err != nil {
 		return nil, err
 	}
+	wallet.Name = name
 	return wallet, nil
 }
 
-// GetWalletByUserID retrieves a wallet for a specific user.
-func (s *WalletService) GetWalletByUserID(userID uint) (*models.Wallet, error) {
-	return s.walletRepo.FindByUserID(userID)
+// SetDefaultWallet makes one of a user's wallets their default wallet.
+func (s *WalletService) SetDefaultWallet(ctx context.Context, userID, walletID uint) (*models.Wallet, error) {
+	wallet, err := findOwnedWallet(ctx, s.walletRepo, userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.walletRepo.SetDefault(ctx, userID, wallet.ID); err != nil {
+		return nil, err
+	}
+	wallet.IsDefault = true
+	return wallet, nil
 }
 
-// FundWallet adds funds to a user's wallet.
-func (s *WalletService) FundWallet(userID uint, amount float64) (*models.Wallet, error) {
-	wallet, err := s.walletRepo.FindByUserID(userID)
+// FundWallet adds funds to one of a user's wallets from the currency's settlement account
+// and records a deposit transaction.
+func (s *WalletService) FundWallet(ctx context.Context, userID, walletID uint, amount money.Money) (*models.Wallet, error) {
+	var wallet *models.Wallet
+	err := s.txManager.WithinTx(ctx, func(repos repository.Repositories) error {
+		walletRepo := repos.Wallets
+		ledger := NewLedgerService(repos.Ledger)
+
+		current, err := findOwnedWallet(ctx, walletRepo, userID, walletID)
+		if err != nil {
+			return err
+		}
+		if err := checkAmount(current, amount); err != nil {
+			return err
+		}
+		if !current.IsActive {
+			return ErrWalletInactive
+		}
+
+		var before money.Money
+		if wallet, before, err = adjustBalance(ctx, walletRepo, current.ID, amount); err != nil {
+			return err
+		}
+
+		settlement, err := ledger.SettlementAccount(ctx, wallet.Currency)
+		if err != nil {
+			return err
+		}
+		account, err := ledger.WalletAccount(ctx, wallet)
+		if err != nil {
+			return err
+		}
+
+		_, err = record(ctx, repos, "deposit", "DEP", "Wallet funding", wallet, amount, before, settlement, account)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	wallet.Balance += amount
-	if err := s.walletRepo.Update(wallet); err != nil {
+	return wallet, nil
+}
+
+// Withdraw debits one of a user's wallets into the currency's settlement account and records a withdrawal transaction.
+// The balance check, the debit and the records are committed atomically under a row lock,
+// so concurrent withdrawals can never overdraw the wallet.
+func (s *WalletService) Withdraw(ctx context.Context, userID, walletID uint, amount money.Money, description string) (*models.Wallet, error) {
+	if description == "" {
+		description = "Wallet withdrawal"
+	}
+
+	var wallet *models.Wallet
+	err := s.txManager.WithinTx(ctx, func(repos repository.Repositories) error {
+		walletRepo := repos.Wallets
+		ledger := NewLedgerService(repos.Ledger)
+
+		current, err := findOwnedWallet(ctx, walletRepo, userID, walletID)
+		if err != nil {
+			return err
+		}
+		if err := checkAmount(current, amount); err != nil {
+			return err
+		}
+		if !current.IsActive {
+			return ErrWalletInactive
+		}
+
+		var before money.Money
+		if wallet, before, err = adjustBalance(ctx, walletRepo, current.ID, amount.Neg()); err != nil {
+			return err
+		}
+
+		account, err := ledger.WalletAccount(ctx, wallet)
+		if err != nil {
+			return err
+		}
+		settlement, err := ledger.SettlementAccount(ctx, wallet.Currency)
+		if err != nil {
+			return err
+		}
+
+		_, err = record(ctx, repos, "withdrawal", "WDR", description, wallet, amount, before, account, settlement)
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return wallet, nil
 }
+
+// Transfer moves funds from one of a user's wallets to another wallet, identified either by toWalletID
+// or by the owner's toEmail (in which case the recipient's wallet in the sender's currency is used);
+// naming both is ErrAmbiguousRecipient. Both balance changes, the journal entry and the paired "transfer"
+// transaction records are committed atomically.
+func (s *WalletService) Transfer(ctx context.Context, fromUserID, fromWalletID, toWalletID uint, toEmail string, amount money.Money, note string) (*models.TransferResponse, error) {
+	if toWalletID != 0 && toEmail != "" {
+		return nil, ErrAmbiguousRecipient
+	}
+
+	reference, err := utils.GenerateReference("TRF")
+	if err != nil {
+		return nil, err
+	}
+
+	description := note
+	if description == "" {
+		description = "Wallet transfer"
+	}
+
+	var resp *models.TransferResponse
+	err = s.txManager.WithinTx(ctx, func(repos repository.Repositories) error {
+		walletRepo := repos.Wallets
+
+		from, err := findOwnedWallet(ctx, walletRepo, fromUserID, fromWalletID)
+		if err != nil {
+			return err
+		}
+		if err := checkAmount(from, amount); err != nil {
+			return err
+		}
+
+		to, err := resolveRecipient(ctx, walletRepo, repos.Users, toWalletID, toEmail, from.Currency)
+		if err != nil {
+			return err
+		}
+
+		if from.ID == to.ID {
+			return ErrSelfTransfer
+		}
+		if !from.IsActive || !to.IsActive {
+			return ErrWalletInactive
+		}
+		if !strings.EqualFold(from.Currency, to.Currency) {
+			return ErrCurrencyMismatch
+		}
+
+		moved, err := moveFunds(ctx, repos, "transfer", reference, description, from, to, amount)
+		if err != nil {
+			return err
+		}
+
+		resp = &models.TransferResponse{Reference: reference, Wallet: moved.from, Transaction: moved.debitLeg}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ReverseTransaction undoes a completed deposit, withdrawal or transfer. The
+// transaction, and the other leg of a transfer, is marked "reversed", and the
+// opposite movement is posted to the ledger and recorded as "reversal"
+// transactions whose description names the original reference. A reversal that
+// would overdraw a wallet fails with ErrInsufficientFunds.
+func (s *WalletService) ReverseTransaction(ctx context.Context, transactionID uint, reason string) (*models.ReversalResponse, error) {
+	var resp *models.ReversalResponse
+	err := s.txManager.WithinTx(ctx, func(repos repository.Repositories) error {
+		original, err := repos.Transactions.FindByID(ctx, transactionID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTransactionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if original.Type != "deposit" && original.Type != "withdrawal" && original.Type != "transfer" {
+			return ErrTransactionNotReversible
+		}
+		marked, err := repos.Transactions.MarkReversed(ctx, original)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			return ErrTransactionNotReversible
+		}
+
+		description := "Reversal of " + original.Reference
+		if original.TransferReference != "" {
+			description = "Reversal of " + original.TransferReference
+		}
+		if reason != "" {
+			description += ": " + reason
+		}
+
+		wallet, err := repos.Wallets.FindByID(ctx, original.WalletID)
+		if err != nil {
+			return err
+		}
+		amount := original.Amount
+
+		if original.Type == "transfer" {
+			counterparty, err := repos.Wallets.FindByID(ctx, *original.CounterpartyWalletID)
+			if err != nil {
+				return err
+			}
+			// Move the money back from the wallet the transfer credited.
+			from, to := counterparty, wallet
+			if original.BalanceAfter.Amount > original.BalanceBefore.Amount {
+				from, to = wallet, counterparty
+			}
+			reference, err := utils.GenerateReference("REV")
+			if err != nil {
+				return err
+			}
+			moved, err := moveFunds(ctx, repos, "reversal", reference, description, from, to, amount)
+			if err != nil {
+				return err
+			}
+			resp = &models.ReversalResponse{Reference: reference, Transactions: []models.Transaction{*moved.debitLeg, *moved.creditLeg}}
+			return nil
+		}
+
+		delta := amount
+		if original.Type == "deposit" {
+			delta = amount.Neg()
+		}
+		updated, before, err := adjustBalance(ctx, repos.Wallets, wallet.ID, delta)
+		if err != nil {
+			return err
+		}
+		ledger := NewLedgerService(repos.Ledger)
+		account, err := ledger.WalletAccount(ctx, updated)
+		if err != nil {
+			return err
+		}
+		settlement, err := ledger.SettlementAccount(ctx, updated.Currency)
+		if err != nil {
+			return err
+		}
+		debit, credit := settlement, account
+		if original.Type == "deposit" {
+			debit, credit = account, settlement
+		}
+
+		reversal, err := record(ctx, repos, "reversal", "REV", description, updated, amount, before, debit, credit)
+		if err != nil {
+			return err
+		}
+		resp = &models.ReversalResponse{Reference: reversal.Reference, Transactions: []models.Transaction{*reversal}}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// AdjustBalance credits a wallet with delta, or debits it when delta is negative,
+// against the currency's settlement account and records an "adjustment"
+// transaction carrying reason. It is the back-office correction for any user's
+// wallet; an adjustment that would overdraw the wallet fails with ErrInsufficientFunds.
+func (s *WalletService) AdjustBalance(ctx context.Context, walletID uint, delta money.Money, reason string) (*models.Transaction, error) {
+	if delta.IsZero() {
+		return nil, ErrInvalidAmount
+	}
+
+	var transaction *models.Transaction
+	err := s.txManager.WithinTx(ctx, func(repos repository.Repositories) error {
+		current, err := repos.Wallets.FindByID(ctx, walletID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrWalletNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(delta.Currency, current.Currency) {
+			return ErrCurrencyMismatch
+		}
+
+		wallet, before, err := adjustBalance(ctx, repos.Wallets, current.ID, delta)
+		if err != nil {
+			return err
+		}
+		ledger := NewLedgerService(repos.Ledger)
+		account, err := ledger.WalletAccount(ctx, wallet)
+		if err != nil {
+			return err
+		}
+		settlement, err := ledger.SettlementAccount(ctx, wallet.Currency)
+		if err != nil {
+			return err
+		}
+		debit, credit := settlement, account
+		amount := delta
+		if delta.IsNegative() {
+			debit, credit = account, settlement
+			amount = delta.Neg()
+		}
+
+		transaction, err = record(ctx, repos, "adjustment", "ADJ", "Balance adjustment: "+reason, wallet, amount, before, debit, credit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// movement is the outcome of moveFunds: both wallets after the move and the
+// transaction recorded for each of them.
+type movement struct {
+	from, to            *models.Wallet
+	debitLeg, creditLeg *models.Transaction
+}
+
+// moveFunds debits from and credits to with amount, posts the journal entry and
+// records a pair of kind transactions sharing reference as their TransferReference.
+func moveFunds(ctx context.Context, repos repository.Repositories, kind, reference, description string, from, to *models.Wallet, amount money.Money) (*movement, error) {
+	ledger := NewLedgerService(repos.Ledger)
+
+	var fromBefore, toBefore money.Money
+	debitFrom := func() (err error) {
+		from, fromBefore, err = adjustBalance(ctx, repos.Wallets, from.ID, amount.Neg())
+		return err
+	}
+	creditTo := func() (err error) {
+		to, toBefore, err = adjustBalance(ctx, repos.Wallets, to.ID, amount)
+		return err
+	}
+
+	// Lock the two rows in wallet ID order so opposing transfers cannot deadlock.
+	steps := []func() error{debitFrom, creditTo}
+	if to.ID < from.ID {
+		steps[0], steps[1] = creditTo, debitFrom
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+
+	fromAccount, err := ledger.WalletAccount(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	toAccount, err := ledger.WalletAccount(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := ledger.Record(ctx, kind, reference, description, fromAccount, toAccount, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	debitLeg := &models.Transaction{
+		WalletID:             from.ID,
+		Type:                 kind,
+		Amount:               amount,
+		Currency:             from.Currency,
+		Description:          description,
+		Status:               "completed",
+		Reference:            reference + "-D",
+		BalanceBefore:        fromBefore,
+		BalanceAfter:         from.Balance,
+		TransferReference:    reference,
+		CounterpartyWalletID: &to.ID,
+		JournalEntryID:       &entry.ID,
+	}
+	creditLeg := &models.Transaction{
+		WalletID:             to.ID,
+		Type:                 kind,
+		Amount:               amount,
+		Currency:             to.Currency,
+		Description:          description,
+		Status:               "completed",
+		Reference:            reference + "-C",
+		BalanceBefore:        toBefore,
+		BalanceAfter:         to.Balance,
+		TransferReference:    reference,
+		CounterpartyWalletID: &from.ID,
+		JournalEntryID:       &entry.ID,
+	}
+	if err := repos.Transactions.Create(ctx, debitLeg); err != nil {
+		return nil, err
+	}
+	if err := repos.Transactions.Create(ctx, creditLeg); err != nil {
+		return nil, err
+	}
+	return &movement{from: from, to: to, debitLeg: debitLeg, creditLeg: creditLeg}, nil
+}
+
+// record posts a single-wallet movement to the ledger and writes the matching transaction row.
+func record(ctx context.Context, repos repository.Repositories, kind, prefix, description string, wallet *models.Wallet, amount, before money.Money, debitAccount, creditAccount *models.LedgerAccount) (*models.Transaction, error) {
+	reference, err := utils.GenerateReference(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := NewLedgerService(repos.Ledger).Record(ctx, kind, reference, description, debitAccount, creditAccount, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &models.Transaction{
+		WalletID:       wallet.ID,
+		Type:           kind,
+		Amount:         amount,
+		Currency:       wallet.Currency,
+		Description:    description,
+		Status:         "completed",
+		Reference:      reference,
+		BalanceBefore:  before,
+		BalanceAfter:   wallet.Balance,
+		JournalEntryID: &entry.ID,
+	}
+	if err := repos.Transactions.Create(ctx, transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// resolveRecipient finds the destination wallet of a transfer by wallet ID or, failing that,
+// the owner's email and the transfer currency.
+func resolveRecipient(ctx context.Context, walletRepo repository.WalletStore, userRepo repository.UserStore, toWalletID uint, toEmail, currency string) (*models.Wallet, error) {
+	var (
+		wallet *models.Wallet
+		err    error
+	)
+	if toWalletID != 0 {
+		wallet, err = walletRepo.FindByID(ctx, toWalletID)
+	} else {
+		var user *models.User
+		user, err = userRepo.FindByEmail(ctx, toEmail)
+		if err == nil {
+			wallet, err = walletRepo.FindByUserIDAndCurrency(ctx, user.ID, currency)
+		}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRecipientNotFound
+	}
+	return wallet, err
+}
+
+// findOwnedWallet loads a wallet by ID, reporting wallets of other users as ErrWalletNotFound.
+func findOwnedWallet(ctx context.Context, walletRepo repository.WalletStore, userID, walletID uint) (*models.Wallet, error) {
+	wallet, err := walletRepo.FindByIDAndUserID(ctx, walletID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWalletNotFound
+	}
+	return wallet, err
+}
+
+// checkAmount verifies that amount is positive and denominated in the wallet's currency.
+func checkAmount(wallet *models.Wallet, amount money.Money) error {
+	if !amount.IsPositive() {
+		return ErrInvalidAmount
+	}
+	if !strings.EqualFold(amount.Currency, wallet.Currency) {
+		return ErrCurrencyMismatch
+	}
+	return nil
+}
+
+// adjustBalance applies delta to a wallet under a row lock, translating an overdraft into ErrInsufficientFunds.
+func adjustBalance(ctx context.Context, walletRepo repository.WalletStore, walletID uint, delta money.Money) (*models.Wallet, money.Money, error) {
+	wallet, before, err := walletRepo.AdjustBalance(ctx, walletID, delta)
+	if errors.Is(err, repository.ErrNegativeBalance) {
+		return nil, money.Money{}, ErrInsufficientFunds
+	}
+	return wallet, before, err
+}
@@ -0,0 +1,190 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"wallet-service/internal/db/dbtest"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"gorm.io/gorm"
+)
+
+// postgresWallets builds a WalletService on the test database.
+func postgresWallets(t *testing.T) (*gorm.DB, *service.WalletService) {
+	t.Helper()
+	gormDB := dbtest.Open(t)
+	return gormDB, service.NewWalletService(repository.NewTxManager(gormDB), repository.NewWalletRepository(gormDB))
+}
+
+func TestWalletService_ConcurrentBalanceChanges(t *testing.T) {
+	gormDB, wallets := postgresWallets(t)
+	ctx := context.Background()
+
+	alice := dbtest.CreateUser(t, gormDB)
+	bob := dbtest.CreateUser(t, gormDB)
+	a := createWallet(t, wallets, alice.ID, "USD")
+	b := createWallet(t, wallets, bob.ID, "USD")
+	fund(t, wallets, alice.ID, a.ID, usd(t, "1000.00"))
+	fund(t, wallets, bob.ID, b.ID, usd(t, "100.00"))
+
+	five, two, three, one := usd(t, "5.00"), usd(t, "2.00"), usd(t, "3.00"), usd(t, "1.00")
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, 4*n)
+	run := func(op func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- op()
+		}()
+	}
+	for i := 0; i < n; i++ {
+		run(func() error {
+			_, err := wallets.FundWallet(ctx, alice.ID, a.ID, five)
+			return err
+		})
+		run(func() error {
+			_, err := wallets.Withdraw(ctx, alice.ID, a.ID, two, "")
+			return err
+		})
+		run(func() error {
+			_, err := wallets.Transfer(ctx, alice.ID, a.ID, b.ID, "", three, "")
+			return err
+		})
+		run(func() error {
+			_, err := wallets.Transfer(ctx, bob.ID, b.ID, a.ID, "", one, "")
+			return err
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent operation failed: %v", err)
+		}
+	}
+
+	// 1000 + 10×5 − 10×2 − 10×3 + 10×1, and 100 + 10×3 − 10×1.
+	checkPostgresWallet(t, gormDB, a, "1010.00", 1+4*n)
+	checkPostgresWallet(t, gormDB, b, "120.00", 1+2*n)
+}
+
+func TestWalletService_ConcurrentWithdrawalsCannotOverdraw(t *testing.T) {
+	gormDB, wallets := postgresWallets(t)
+	ctx := context.Background()
+
+	user := dbtest.CreateUser(t, gormDB)
+	wallet := createWallet(t, wallets, user.ID, "USD")
+	fund(t, wallets, user.ID, wallet.ID, usd(t, "10.00"))
+
+	one := usd(t, "1.00")
+	const attempts = 20
+	var (
+		wg                  sync.WaitGroup
+		mu                  sync.Mutex
+		succeeded, declined int
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := wallets.Withdraw(ctx, user.ID, wallet.ID, one, "")
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, service.ErrInsufficientFunds):
+				declined++
+			default:
+				t.Errorf("Withdraw: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded != 10 || declined != attempts-10 {
+		t.Errorf("got %d withdrawals and %d declines, want 10 and %d", succeeded, declined, attempts-10)
+	}
+	checkPostgresWallet(t, gormDB, wallet, "0.00", 1+succeeded)
+}
+
+func TestWalletService_ConcurrentFirstWalletsHaveOneDefault(t *testing.T) {
+	gormDB, wallets := postgresWallets(t)
+	ctx := context.Background()
+	user := dbtest.CreateUser(t, gormDB)
+
+	const n = 10
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := wallets.CreateWallet(ctx, user.ID, "Test wallet", "USD"); err != nil {
+				t.Errorf("CreateWallet: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	created, err := repository.NewWalletRepository(gormDB).FindAllByUserID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("FindAllByUserID: %v", err)
+	}
+	defaults := 0
+	for _, wallet := range created {
+		if wallet.IsDefault {
+			defaults++
+		}
+	}
+	if len(created) != n || defaults != 1 {
+		t.Errorf("got %d wallets with %d defaults, want %d with 1", len(created), defaults, n)
+	}
+}
+
+// checkPostgresWallet verifies a wallet's cached balance, the balance derived
+// from its ledger postings and its number of transaction records, and that the
+// ledger reconciliation does not report it.
+func checkPostgresWallet(t *testing.T, gormDB *gorm.DB, wallet *models.Wallet, want string, transactions int) {
+	t.Helper()
+	ctx := context.Background()
+
+	stored, err := repository.NewWalletRepository(gormDB).FindByID(ctx, wallet.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if got := stored.Balance.String(); got != want {
+		t.Errorf("wallet %d balance = %s, want %s", wallet.ID, got, want)
+	}
+
+	ledger := service.NewLedgerService(repository.NewLedgerRepository(gormDB))
+	derived, err := ledger.WalletBalance(ctx, stored)
+	if err != nil {
+		t.Fatalf("WalletBalance: %v", err)
+	}
+	if got := derived.String(); got != want {
+		t.Errorf("wallet %d ledger balance = %s, want %s", wallet.ID, got, want)
+	}
+
+	discrepancies, err := ledger.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	for _, discrepancy := range discrepancies {
+		if discrepancy.WalletID == wallet.ID {
+			t.Errorf("wallet %d does not reconcile: cached %s, ledger %s", wallet.ID, discrepancy.CachedBalance, discrepancy.LedgerBalance)
+		}
+	}
+
+	rows, err := repository.NewTransactionRepository(gormDB).FindByWalletID(ctx, wallet.ID)
+	if err != nil {
+		t.Fatalf("FindByWalletID: %v", err)
+	}
+	if len(rows) != transactions {
+		t.Errorf("wallet %d has %d transactions, want %d", wallet.ID, len(rows), transactions)
+	}
+}
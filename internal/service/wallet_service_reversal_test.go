@@ -0,0 +1,129 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"wallet-service/internal/db/dbtest"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"gorm.io/gorm"
+)
+
+func TestWalletService_ReverseTransaction(t *testing.T) {
+	gormDB, wallets := postgresWallets(t)
+	ctx := context.Background()
+
+	alice := dbtest.CreateUser(t, gormDB)
+	bob := dbtest.CreateUser(t, gormDB)
+	a := createWallet(t, wallets, alice.ID, "USD")
+	b := createWallet(t, wallets, bob.ID, "USD")
+	fund(t, wallets, alice.ID, a.ID, usd(t, "100.00"))
+	if _, err := wallets.Withdraw(ctx, alice.ID, a.ID, usd(t, "10.00"), ""); err != nil {
+		t.Fatalf("Withdraw: %v", err)
+	}
+	if _, err := wallets.Transfer(ctx, alice.ID, a.ID, b.ID, "", usd(t, "30.00"), ""); err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	deposit, withdrawal, transfer := firstTransactions(t, gormDB, a.ID)
+
+	// Reversing the credit leg reverses the whole transfer.
+	creditLeg := lastTransaction(t, gormDB, b.ID)
+	resp, err := wallets.ReverseTransaction(ctx, creditLeg.ID, "sent in error")
+	if err != nil {
+		t.Fatalf("ReverseTransaction(transfer): %v", err)
+	}
+	if len(resp.Transactions) != 2 || resp.Transactions[0].WalletID != b.ID || resp.Transactions[1].WalletID != a.ID {
+		t.Fatalf("transfer reversal = %+v, want a debit of wallet %d and a credit of wallet %d", resp.Transactions, b.ID, a.ID)
+	}
+	checkPostgresWallet(t, gormDB, a, "90.00", 4)
+	checkPostgresWallet(t, gormDB, b, "0.00", 2)
+	for _, leg := range []*models.Transaction{transfer, creditLeg} {
+		checkStatus(t, gormDB, leg.ID, "reversed")
+	}
+
+	if _, err := wallets.ReverseTransaction(ctx, transfer.ID, ""); !errors.Is(err, service.ErrTransactionNotReversible) {
+		t.Fatalf("reversing the transfer again: err = %v, want ErrTransactionNotReversible", err)
+	}
+	if _, err := wallets.ReverseTransaction(ctx, resp.Transactions[0].ID, ""); !errors.Is(err, service.ErrTransactionNotReversible) {
+		t.Fatalf("reversing a reversal: err = %v, want ErrTransactionNotReversible", err)
+	}
+
+	if _, err := wallets.ReverseTransaction(ctx, withdrawal.ID, ""); err != nil {
+		t.Fatalf("ReverseTransaction(withdrawal): %v", err)
+	}
+	checkPostgresWallet(t, gormDB, a, "100.00", 5)
+
+	if _, err := wallets.ReverseTransaction(ctx, deposit.ID, ""); err != nil {
+		t.Fatalf("ReverseTransaction(deposit): %v", err)
+	}
+	checkPostgresWallet(t, gormDB, a, "0.00", 6)
+	checkStatus(t, gormDB, deposit.ID, "reversed")
+
+	if _, err := wallets.ReverseTransaction(ctx, 1<<31, ""); !errors.Is(err, service.ErrTransactionNotFound) {
+		t.Fatalf("reversing an unknown transaction: err = %v, want ErrTransactionNotFound", err)
+	}
+}
+
+func TestWalletService_ReverseTransactionCannotOverdraw(t *testing.T) {
+	gormDB, wallets := postgresWallets(t)
+	ctx := context.Background()
+
+	alice := dbtest.CreateUser(t, gormDB)
+	a := createWallet(t, wallets, alice.ID, "USD")
+	fund(t, wallets, alice.ID, a.ID, usd(t, "50.00"))
+	if _, err := wallets.Withdraw(ctx, alice.ID, a.ID, usd(t, "20.00"), ""); err != nil {
+		t.Fatalf("Withdraw: %v", err)
+	}
+	deposit, _, _ := firstTransactions(t, gormDB, a.ID)
+
+	if _, err := wallets.ReverseTransaction(ctx, deposit.ID, ""); !errors.Is(err, service.ErrInsufficientFunds) {
+		t.Fatalf("ReverseTransaction: err = %v, want ErrInsufficientFunds", err)
+	}
+	checkPostgresWallet(t, gormDB, a, "30.00", 2)
+	checkStatus(t, gormDB, deposit.ID, "completed")
+}
+
+// firstTransactions returns a wallet's first three transactions, oldest first;
+// the third is nil if there are only two.
+func firstTransactions(t *testing.T, gormDB *gorm.DB, walletID uint) (first, second, third *models.Transaction) {
+	t.Helper()
+	rows, err := repository.NewTransactionRepository(gormDB).FindByWalletID(context.Background(), walletID)
+	if err != nil {
+		t.Fatalf("FindByWalletID: %v", err)
+	}
+	if len(rows) < 2 {
+		t.Fatalf("wallet %d has %d transactions, want at least 2", walletID, len(rows))
+	}
+	if len(rows) > 2 {
+		third = &rows[2]
+	}
+	return &rows[0], &rows[1], third
+}
+
+// lastTransaction returns a wallet's most recent transaction.
+func lastTransaction(t *testing.T, gormDB *gorm.DB, walletID uint) *models.Transaction {
+	t.Helper()
+	rows, err := repository.NewTransactionRepository(gormDB).FindByWalletID(context.Background(), walletID)
+	if err != nil {
+		t.Fatalf("FindByWalletID: %v", err)
+	}
+	if len(rows) == 0 {
+		t.Fatalf("wallet %d has no transactions", walletID)
+	}
+	return &rows[len(rows)-1]
+}
+
+// checkStatus verifies the status of a stored transaction.
+func checkStatus(t *testing.T, gormDB *gorm.DB, transactionID uint, want string) {
+	t.Helper()
+	transaction, err := repository.NewTransactionRepository(gormDB).FindByID(context.Background(), transactionID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if transaction.Status != want {
+		t.Errorf("transaction %d status = %q, want %q", transactionID, transaction.Status, want)
+	}
+}
@@ -0,0 +1,266 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+	"wallet-service/pkg/money"
+)
+
+// memoryWallets returns a WalletService running on in-memory stores, together
+// with the stores it uses.
+func memoryWallets(t *testing.T) (*service.WalletService, repository.Repositories) {
+	t.Helper()
+	wallets := repository.NewMemoryWalletStore()
+	repos := repository.Repositories{
+		Users:         repository.NewMemoryUserStore(),
+		Wallets:       wallets,
+		Transactions:  repository.NewMemoryTransactionStore(),
+		Ledger:        repository.NewMemoryLedgerStore(wallets),
+		UserTokens:    repository.NewMemoryUserTokenStore(),
+		RefreshTokens: repository.NewMemoryRefreshTokenStore(),
+	}
+	return service.NewWalletService(repository.NewMemoryTxManager(repos), wallets), repos
+}
+
+// memoryUser registers a user in the in-memory stores.
+func memoryUser(t *testing.T, repos repository.Repositories, name string) *models.User {
+	t.Helper()
+	user := &models.User{Name: name, Email: strings.ToLower(name) + "@example.com", Password: "password123"}
+	if err := repos.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	return user
+}
+
+// checkWallet verifies a wallet's cached balance, its balance derived from the
+// ledger and how many transactions it has.
+func checkWallet(t *testing.T, repos repository.Repositories, walletID uint, want string, transactions int) {
+	t.Helper()
+	ctx := context.Background()
+	wallet, err := repos.Wallets.FindByID(ctx, walletID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if got := wallet.Balance.String(); got != want {
+		t.Errorf("wallet %d balance = %s, want %s", walletID, got, want)
+	}
+	derived, err := service.NewLedgerService(repos.Ledger).WalletBalance(ctx, wallet)
+	if err != nil {
+		t.Fatalf("WalletBalance: %v", err)
+	}
+	if got := derived.String(); got != want {
+		t.Errorf("wallet %d ledger balance = %s, want %s", walletID, got, want)
+	}
+	rows, err := repos.Transactions.FindByWalletID(ctx, walletID)
+	if err != nil {
+		t.Fatalf("FindByWalletID: %v", err)
+	}
+	if len(rows) != transactions {
+		t.Errorf("wallet %d has %d transactions, want %d", walletID, len(rows), transactions)
+	}
+}
+
+func TestWalletService_CreateWallet(t *testing.T) {
+	wallets, repos := memoryWallets(t)
+	ctx := context.Background()
+	alice := memoryUser(t, repos, "Alice")
+
+	first := createWallet(t, wallets, alice.ID, "")
+	second := createWallet(t, wallets, alice.ID, "eur")
+	if first.Currency != "USD" || !first.IsDefault {
+		t.Errorf("first wallet = %+v, want the default USD wallet", first)
+	}
+	if second.Currency != "EUR" || second.IsDefault {
+		t.Errorf("second wallet = %+v, want a non-default EUR wallet", second)
+	}
+	for _, wallet := range []*models.Wallet{first, second} {
+		if _, err := repos.Ledger.FindAccountByWalletID(ctx, wallet.ID); err != nil {
+			t.Errorf("wallet %d has no ledger account: %v", wallet.ID, err)
+		}
+	}
+
+	if _, err := wallets.CreateWallet(ctx, 9999, "Test wallet", "USD"); err == nil {
+		t.Error("CreateWallet for an unknown user succeeded")
+	}
+}
+
+func TestWalletService_FundAndWithdraw(t *testing.T) {
+	wallets, repos := memoryWallets(t)
+	ctx := context.Background()
+	alice := memoryUser(t, repos, "Alice")
+	wallet := createWallet(t, wallets, alice.ID, "USD")
+
+	fund(t, wallets, alice.ID, wallet.ID, usd(t, "100.00"))
+	if _, err := wallets.Withdraw(ctx, alice.ID, wallet.ID, usd(t, "40.50"), "Rent"); err != nil {
+		t.Fatalf("Withdraw: %v", err)
+	}
+	checkWallet(t, repos, wallet.ID, "59.50", 2)
+
+	rejected := map[string]struct {
+		userID uint
+		amount string
+		want   error
+	}{
+		"overdraft":      {alice.ID, "59.51", service.ErrInsufficientFunds},
+		"zero amount":    {alice.ID, "0.00", service.ErrInvalidAmount},
+		"another's user": {alice.ID + 1, "1.00", service.ErrWalletNotFound},
+	}
+	for name, tc := range rejected {
+		if _, err := wallets.Withdraw(ctx, tc.userID, wallet.ID, usd(t, tc.amount), ""); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", name, err, tc.want)
+		}
+	}
+	euros := createWallet(t, wallets, alice.ID, "EUR")
+	if _, err := wallets.FundWallet(ctx, alice.ID, euros.ID, usd(t, "1.00")); !errors.Is(err, service.ErrCurrencyMismatch) {
+		t.Errorf("funding a EUR wallet with USD: err = %v, want ErrCurrencyMismatch", err)
+	}
+	checkWallet(t, repos, wallet.ID, "59.50", 2)
+}
+
+func TestWalletService_Transfer(t *testing.T) {
+	wallets, repos := memoryWallets(t)
+	ctx := context.Background()
+	alice := memoryUser(t, repos, "Alice")
+	bob := memoryUser(t, repos, "Bob")
+
+	a := createWallet(t, wallets, alice.ID, "USD")
+	b := createWallet(t, wallets, bob.ID, "USD")
+	fund(t, wallets, alice.ID, a.ID, usd(t, "100.00"))
+
+	resp, err := wallets.Transfer(ctx, alice.ID, a.ID, b.ID, "", usd(t, "30.00"), "Dinner")
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	if resp.Wallet.Balance.String() != "70.00" || resp.Transaction.Type != "transfer" {
+		t.Errorf("Transfer = %+v, want the sender's wallet at 70.00 and its debit leg", resp)
+	}
+	if _, err := wallets.Transfer(ctx, alice.ID, a.ID, 0, "bob@example.com", usd(t, "20.00"), ""); err != nil {
+		t.Fatalf("Transfer by email: %v", err)
+	}
+	checkWallet(t, repos, a.ID, "50.00", 3)
+	checkWallet(t, repos, b.ID, "50.00", 2)
+
+	rejected := map[string]struct {
+		to    uint
+		email string
+		want  error
+	}{
+		"to itself":         {a.ID, "", service.ErrSelfTransfer},
+		"unknown wallet":    {9999, "", service.ErrRecipientNotFound},
+		"unknown email":     {0, "nobody@example.com", service.ErrRecipientNotFound},
+		"wallet and email":  {b.ID, "bob@example.com", service.ErrAmbiguousRecipient},
+		"more than balance": {b.ID, "", service.ErrInsufficientFunds},
+	}
+	for name, tc := range rejected {
+		amount := usd(t, "1.00")
+		if name == "more than balance" {
+			amount = usd(t, "50.01")
+		}
+		if _, err := wallets.Transfer(ctx, alice.ID, a.ID, tc.to, tc.email, amount, ""); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", name, err, tc.want)
+		}
+	}
+
+	euros := createWallet(t, wallets, bob.ID, "EUR")
+	if _, err := wallets.Transfer(ctx, alice.ID, a.ID, euros.ID, "", usd(t, "1.00"), ""); !errors.Is(err, service.ErrCurrencyMismatch) {
+		t.Errorf("transfer to a EUR wallet: err = %v, want ErrCurrencyMismatch", err)
+	}
+	checkWallet(t, repos, a.ID, "50.00", 3)
+
+	discrepancies, err := service.NewLedgerService(repos.Ledger).Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if len(discrepancies) != 0 {
+		t.Errorf("Reconcile = %+v, want no discrepancies", discrepancies)
+	}
+}
+
+func TestWalletService_ReverseTransactionInMemory(t *testing.T) {
+	wallets, repos := memoryWallets(t)
+	ctx := context.Background()
+	alice := memoryUser(t, repos, "Alice")
+	bob := memoryUser(t, repos, "Bob")
+	a := createWallet(t, wallets, alice.ID, "USD")
+	b := createWallet(t, wallets, bob.ID, "USD")
+	fund(t, wallets, alice.ID, a.ID, usd(t, "100.00"))
+	transfer, err := wallets.Transfer(ctx, alice.ID, a.ID, b.ID, "", usd(t, "30.00"), "")
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	resp, err := wallets.ReverseTransaction(ctx, transfer.Transaction.ID, "sent in error")
+	if err != nil {
+		t.Fatalf("ReverseTransaction: %v", err)
+	}
+	if len(resp.Transactions) != 2 {
+		t.Fatalf("reversal recorded %d transactions, want 2", len(resp.Transactions))
+	}
+	checkWallet(t, repos, a.ID, "100.00", 3)
+	checkWallet(t, repos, b.ID, "0.00", 2)
+
+	reversed, err := repos.Transactions.FindByID(ctx, transfer.Transaction.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if reversed.Status != "reversed" {
+		t.Errorf("transfer status = %q, want reversed", reversed.Status)
+	}
+	if _, err := wallets.ReverseTransaction(ctx, transfer.Transaction.ID, ""); !errors.Is(err, service.ErrTransactionNotReversible) {
+		t.Errorf("reversing twice: err = %v, want ErrTransactionNotReversible", err)
+	}
+	if _, err := wallets.ReverseTransaction(ctx, 9999, ""); !errors.Is(err, service.ErrTransactionNotFound) {
+		t.Errorf("reversing an unknown transaction: err = %v, want ErrTransactionNotFound", err)
+	}
+}
+
+func TestWalletService_AdjustBalance(t *testing.T) {
+	wallets, repos := memoryWallets(t)
+	ctx := context.Background()
+	alice := memoryUser(t, repos, "Alice")
+	wallet := createWallet(t, wallets, alice.ID, "USD")
+
+	credit, err := wallets.AdjustBalance(ctx, wallet.ID, usd(t, "25.00"), "Goodwill credit")
+	if err != nil {
+		t.Fatalf("AdjustBalance: %v", err)
+	}
+	if credit.Type != "adjustment" || credit.Amount.String() != "25.00" || credit.BalanceAfter.String() != "25.00" {
+		t.Errorf("credit = %+v, want a 25.00 adjustment leaving 25.00", credit)
+	}
+	debit, err := wallets.AdjustBalance(ctx, wallet.ID, usd(t, "-10.00"), "Duplicate settlement")
+	if err != nil {
+		t.Fatalf("AdjustBalance: %v", err)
+	}
+	if debit.Amount.String() != "10.00" || debit.BalanceAfter.String() != "15.00" {
+		t.Errorf("debit = %+v, want a 10.00 adjustment leaving 15.00", debit)
+	}
+	checkWallet(t, repos, wallet.ID, "15.00", 2)
+
+	rejected := map[string]struct {
+		walletID uint
+		delta    string
+		want     error
+	}{
+		"overdraft":      {wallet.ID, "-15.01", service.ErrInsufficientFunds},
+		"zero":           {wallet.ID, "0.00", service.ErrInvalidAmount},
+		"unknown wallet": {9999, "1.00", service.ErrWalletNotFound},
+	}
+	for name, tc := range rejected {
+		if _, err := wallets.AdjustBalance(ctx, tc.walletID, usd(t, tc.delta), "Correction"); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", name, err, tc.want)
+		}
+	}
+	euros, err := money.Parse("1.00", "EUR")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, err := wallets.AdjustBalance(ctx, wallet.ID, euros, "Correction"); !errors.Is(err, service.ErrCurrencyMismatch) {
+		t.Errorf("adjusting a USD wallet in EUR: err = %v, want ErrCurrencyMismatch", err)
+	}
+	checkWallet(t, repos, wallet.ID, "15.00", 2)
+}
@@ -0,0 +1,561 @@
+// Package jwtkeys manages the asymmetric keys used to sign and verify JWTs.
+//
+// Keys live in a directory as PEM files named <kid>.pem. A file holding a private
+// key (PKCS#8 "PRIVATE KEY" or PKCS#1 "RSA PRIVATE KEY") can sign and verify; a
+// file holding only a public key ("PUBLIC KEY") is kept for verification. The key
+// that signs new tokens is recorded by kid in an ActiveKeyStore shared by every
+// instance, so all instances must also share the directory. A key that has been
+// replaced keeps verifying until every token it can have signed has expired, and
+// is then dropped.
+package jwtkeys
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms.
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// reloadCooldown limits how often an unknown kid may trigger a reload of the key directory.
+const reloadCooldown = time.Minute
+
+// kidTimeLayout is the creation time prefix of the kids Generate assigns.
+const kidTimeLayout = "20060102T150405Z"
+
+var (
+	// ErrNoSigningKey is returned when no signing key is active.
+	ErrNoSigningKey = errors.New("no signing key available")
+	// ErrUnknownKey is returned when a token names a kid that is not in the key set.
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrActiveKeyMissing is returned when the active kid has no private key in the
+	// directory, typically because instances do not share the key directory.
+	ErrActiveKeyMissing = errors.New("the active signing key is not in the key directory; every instance must share it")
+)
+
+// ActiveKeyStore records which kid signs new tokens. Every instance using a key
+// directory must share one store, such as a database row.
+type ActiveKeyStore interface {
+	// ActiveKey returns the active kid, or "" if none has been recorded.
+	ActiveKey(ctx context.Context) (string, error)
+	// SetActiveKey records kid as active if previous is still the active kid ("" for
+	// none), and reports whether it did.
+	SetActiveKey(ctx context.Context, previous, kid string) (bool, error)
+}
+
+// MemoryActiveKeyStore is an ActiveKeyStore kept in process memory, for tests and
+// single-instance use.
+type MemoryActiveKeyStore struct {
+	mu  sync.Mutex
+	kid string
+}
+
+// ActiveKey returns the active kid.
+func (s *MemoryActiveKeyStore) ActiveKey(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kid, nil
+}
+
+// SetActiveKey records kid as active if previous is the active kid.
+func (s *MemoryActiveKeyStore) SetActiveKey(ctx context.Context, previous, kid string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.kid != previous {
+		return false, nil
+	}
+	s.kid = kid
+	return true, nil
+}
+
+// Key is a signing or verification key identified by its kid.
+type Key struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer // nil for verification-only keys
+	Public    crypto.PublicKey
+	CreatedAt time.Time
+}
+
+// Manager holds the key set loaded from a directory and rotates its signing key.
+// It is safe for concurrent use.
+type Manager struct {
+	dir              string
+	algorithm        string
+	rotationInterval time.Duration
+	retention        time.Duration
+	store            ActiveKeyStore
+
+	mu         sync.RWMutex
+	keys       map[string]*Key
+	signing    *Key
+	lastReload time.Time
+}
+
+// NewManager loads the keys in dir and the active kid from store. If no key is
+// active yet, or the active one is older than rotationInterval, a new key for
+// algorithm is generated and made active. A zero rotationInterval disables
+// generation, leaving key management to operators; the newest private key is
+// then made active if none is. Replaced keys verify tokens for retention, which
+// must cover the longest lifetime of a token they sign.
+func NewManager(ctx context.Context, dir, algorithm string, rotationInterval, retention time.Duration, store ActiveKeyStore) (*Manager, error) {
+	if _, err := methodFor(algorithm); err != nil {
+		return nil, err
+	}
+	if retention <= 0 {
+		return nil, errors.New("key retention must be positive")
+	}
+	m := &Manager{dir: dir, algorithm: algorithm, rotationInterval: rotationInterval, retention: retention, store: store}
+	if err := m.Rotate(ctx, time.Now()); err != nil {
+		return nil, err
+	}
+	if _, err := m.SigningKey(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Rotate reloads the key directory and the active kid, picking up keys rotated in
+// by operators or other instances, and activates a new signing key if the current
+// one is due for rotation.
+func (m *Manager) Rotate(ctx context.Context, now time.Time) error {
+	if err := m.reload(ctx, now); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	current := m.signing
+	m.mu.RUnlock()
+	previous := ""
+	if current != nil {
+		previous = current.ID
+	}
+
+	var next *Key
+	switch {
+	case m.rotationInterval > 0:
+		if current != nil && now.Sub(current.CreatedAt) < m.rotationInterval {
+			return nil
+		}
+		generated, err := Generate(m.dir, m.algorithm)
+		if err != nil {
+			return err
+		}
+		next = generated
+	case current == nil:
+		if next = m.newestPrivateKey(); next == nil {
+			return ErrNoSigningKey
+		}
+	default:
+		return nil
+	}
+
+	activated, err := m.store.SetActiveKey(ctx, previous, next.ID)
+	if err != nil {
+		return err
+	}
+	if !activated && m.rotationInterval > 0 {
+		// Another instance rotated first; its key is used instead.
+		if err := os.Remove(filepath.Join(m.dir, next.ID+".pem")); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return m.reload(ctx, now)
+}
+
+// Reload replaces the key set with the keys currently in the directory and signs
+// with the key recorded as active.
+func (m *Manager) Reload(ctx context.Context) error {
+	return m.reload(ctx, time.Now())
+}
+
+func (m *Manager) reload(ctx context.Context, now time.Time) error {
+	active, err := m.store.ActiveKey(ctx)
+	if err != nil {
+		return err
+	}
+	return m.load(active, now)
+}
+
+// load replaces the key set with the keys in the directory, dropping keys retired
+// for longer than the retention period, and makes active the signing key.
+func (m *Manager) load(active string, now time.Time) error {
+	keys, err := LoadDir(m.dir)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]*Key, len(keys))
+	for _, key := range keys {
+		byID[key.ID] = key
+	}
+	var signing *Key
+	if active != "" {
+		signing = byID[active]
+		if signing == nil || signing.Private == nil {
+			return fmt.Errorf("%w: kid %q, directory %s", ErrActiveKeyMissing, active, m.dir)
+		}
+	}
+
+	// A key stopped signing when the next newer key was created; it can be dropped
+	// once every token it signed has expired.
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	for i := 0; i+1 < len(keys); i++ {
+		key := keys[i]
+		if key == signing || now.Sub(keys[i+1].CreatedAt) < m.retention {
+			continue
+		}
+		delete(byID, key.ID)
+		if m.rotationInterval > 0 {
+			if err := os.Remove(filepath.Join(m.dir, key.ID+".pem")); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	m.mu.Lock()
+	m.keys = byID
+	m.signing = signing
+	m.lastReload = now
+	m.mu.Unlock()
+	return nil
+}
+
+// newestPrivateKey returns the most recently created key that can sign, or nil.
+func (m *Manager) newestPrivateKey() *Key {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var newest *Key
+	for _, key := range m.keys {
+		if key.Private != nil && (newest == nil || key.CreatedAt.After(newest.CreatedAt)) {
+			newest = key
+		}
+	}
+	return newest
+}
+
+// SigningKey returns the key new tokens are signed with.
+func (m *Manager) SigningKey() (*Key, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.signing == nil {
+		return nil, ErrNoSigningKey
+	}
+	return m.signing, nil
+}
+
+// Sign signs claims with the current signing key and sets the kid header.
+func (m *Manager) Sign(claims jwt.Claims) (string, error) {
+	key, err := m.SigningKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Keyfunc resolves the verification key for a token from its kid header, for use
+// with jwt.Parse. An unknown kid triggers a rate-limited reload of the directory,
+// so keys rotated in by another instance are picked up promptly.
+func (m *Manager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownKey
+	}
+
+	key, ok := m.lookup(kid)
+	if !ok {
+		m.mu.RLock()
+		stale := time.Since(m.lastReload) >= reloadCooldown
+		active := ""
+		if m.signing != nil {
+			active = m.signing.ID
+		}
+		m.mu.RUnlock()
+		if stale {
+			// The active kid is refreshed by Rotate; only the directory is reread here.
+			if err := m.load(active, time.Now()); err != nil {
+				return nil, err
+			}
+			key, ok = m.lookup(kid)
+		}
+	}
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.Public, nil
+}
+
+func (m *Manager) lookup(kid string) (*Key, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	key, ok := m.keys[kid]
+	return key, ok
+}
+
+// JWKS returns the public half of every key in the set as a JSON Web Key Set.
+func (m *Manager) JWKS() JWKS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(m.keys))}
+	for _, key := range m.keys {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	return set
+}
+
+// JWKS is a JSON Web Key Set (RFC 7517).
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is the public part of a key in JSON Web Key form.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWK returns the public key in JSON Web Key form.
+func (k *Key) JWK() JWK {
+	jwk := JWK{KeyID: k.ID, Use: "sig", Algorithm: k.Method.Alg()}
+	switch public := k.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	}
+	return jwk
+}
+
+// PublicKey decodes the key and returns it with the signing method it verifies.
+// RSA, ECDSA (P-256 and P-384) and Ed25519 keys are supported, so that keys
+// published by other issuers can be used too.
+func (j JWK) PublicKey() (crypto.PublicKey, jwt.SigningMethod, error) {
+	switch j.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, nil, err
+		}
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		method := jwt.GetSigningMethod(j.Algorithm)
+		if _, ok := method.(*jwt.SigningMethodRSA); !ok {
+			method = jwt.SigningMethodRS256
+		}
+		return public, method, nil
+
+	case "EC":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return nil, nil, err
+		}
+		public := &ecdsa.PublicKey{X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		var method jwt.SigningMethod
+		switch j.Curve {
+		case "P-256":
+			public.Curve, method = elliptic.P256(), jwt.SigningMethodES256
+		case "P-384":
+			public.Curve, method = elliptic.P384(), jwt.SigningMethodES384
+		default:
+			return nil, nil, fmt.Errorf("unsupported curve %q", j.Curve)
+		}
+		if !public.Curve.IsOnCurve(public.X, public.Y) {
+			return nil, nil, errors.New("invalid EC public key")
+		}
+		return public, method, nil
+
+	case "OKP":
+		if j.Curve != "Ed25519" {
+			return nil, nil, fmt.Errorf("unsupported curve %q", j.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, nil, errors.New("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), jwt.SigningMethodEdDSA, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported key type %q", j.KeyType)
+}
+
+// LoadDir reads every <kid>.pem file in dir. A missing directory holds no keys.
+func LoadDir(dir string) ([]*Key, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*Key, 0, len(paths))
+	for _, path := range paths {
+		key, err := loadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue // dropped by another instance since the directory was listed
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load key %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func loadFile(path string) (*Key, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key := &Key{ID: strings.TrimSuffix(filepath.Base(path), ".pem"), CreatedAt: info.ModTime()}
+	// Generated kids start with their creation time, which survives copying the file.
+	if stamp, _, ok := strings.Cut(key.ID, "-"); ok {
+		if created, err := time.Parse(kidTimeLayout, stamp); err == nil {
+			key.CreatedAt = created
+		}
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", parsed)
+		}
+		key.Private = signer
+		key.Public = signer.Public()
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Private = parsed
+		key.Public = parsed.Public()
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Public = parsed
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+
+	switch key.Public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key.Public)
+	}
+	return key, nil
+}
+
+// Generate creates a new private key for algorithm and writes it to dir as
+// <kid>.pem, where the kid records the creation time.
+func Generate(dir, algorithm string) (*Key, error) {
+	method, err := methodFor(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	var signer crypto.Signer
+	switch algorithm {
+	case AlgorithmRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	kid := fmt.Sprintf("%s-%x", now.Format(kidTimeLayout), suffix)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		return nil, err
+	}
+
+	return &Key{ID: kid, Method: method, Private: signer, Public: signer.Public(), CreatedAt: now.Truncate(time.Second)}, nil
+}
+
+func methodFor(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256, nil
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+}
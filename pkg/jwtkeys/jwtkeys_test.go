@@ -0,0 +1,117 @@
+package jwtkeys
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKey generates a key in dir under the given kid.
+func writeKey(t *testing.T, dir, kid string) {
+	t.Helper()
+	key, err := Generate(dir, AlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if err := os.Rename(filepath.Join(dir, key.ID+".pem"), filepath.Join(dir, kid+".pem")); err != nil {
+		t.Fatalf("rename key: %v", err)
+	}
+}
+
+func jwksKIDs(m *Manager) []string {
+	var kids []string
+	for _, key := range m.JWKS().Keys {
+		kids = append(kids, key.KeyID)
+	}
+	sort.Strings(kids)
+	return kids
+}
+
+func TestManager_RotateDropsExpiredKeys(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	const (
+		kidA = "20240101T000000Z-0000000a"
+		kidB = "20240110T000000Z-0000000b"
+		kidC = "20240120T000000Z-0000000c"
+	)
+	for _, kid := range []string{kidA, kidB, kidC} {
+		writeKey(t, dir, kid)
+	}
+	store := &MemoryActiveKeyStore{kid: kidC}
+	day := 24 * time.Hour
+
+	m := &Manager{dir: dir, algorithm: AlgorithmEdDSA, rotationInterval: 30 * day, retention: 5 * day, store: store}
+	if err := m.Rotate(ctx, time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	// A stopped signing when B was created 11 days ago; B stopped when C was, 1 day ago.
+	if got, want := jwksKIDs(m), []string{kidB, kidC}; !slices.Equal(got, want) {
+		t.Errorf("JWKS kids = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, kidA+".pem")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expired key file still present: %v", err)
+	}
+	if signing, _ := m.SigningKey(); signing.ID != kidC {
+		t.Errorf("signing key = %s, want %s", signing.ID, kidC)
+	}
+
+	// Once C is older than the rotation interval a new key takes over, and B,
+	// retired for longer than the retention period, is dropped.
+	if err := m.Rotate(ctx, time.Date(2024, 2, 25, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	signing, err := m.SigningKey()
+	if err != nil {
+		t.Fatalf("SigningKey: %v", err)
+	}
+	if signing.ID == kidC || store.kid != signing.ID {
+		t.Errorf("signing key = %s, recorded %s; want a new key recorded as active", signing.ID, store.kid)
+	}
+	if got, want := jwksKIDs(m), []string{kidC, signing.ID}; !slices.Equal(got, want) {
+		t.Errorf("JWKS kids = %v, want %v", got, want)
+	}
+
+	token, err := m.Sign(jwt.MapClaims{"sub": "1"})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if _, err := jwt.Parse(token, m.Keyfunc); err != nil {
+		t.Errorf("token signed by the new key does not verify: %v", err)
+	}
+}
+
+func TestNewManager_RequiresActiveKeyInDirectory(t *testing.T) {
+	store := &MemoryActiveKeyStore{kid: "20240101T000000Z-0000000a"}
+	_, err := NewManager(context.Background(), t.TempDir(), AlgorithmEdDSA, time.Hour, time.Hour, store)
+	if !errors.Is(err, ErrActiveKeyMissing) {
+		t.Errorf("NewManager err = %v, want ErrActiveKeyMissing", err)
+	}
+}
+
+func TestNewManager_SharesActiveKey(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := &MemoryActiveKeyStore{}
+
+	first, err := NewManager(ctx, dir, AlgorithmEdDSA, time.Hour, time.Hour, store)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	second, err := NewManager(ctx, dir, AlgorithmEdDSA, time.Hour, time.Hour, store)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	a, _ := first.SigningKey()
+	b, _ := second.SigningKey()
+	if a.ID != b.ID {
+		t.Errorf("instances sign with %s and %s, want the same key", a.ID, b.ID)
+	}
+}
@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer is a development Mailer that never sends anything. With a Dir set it
+// writes each message there as an .eml file; otherwise it writes messages to the log.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// Send records msg in the directory or the log.
+func (m *FileMailer) Send(msg Message) error {
+	data := format(m.From, msg)
+	if m.Dir == "" {
+		log.Printf("📧 Mail to %s:\n%s", msg.To, data)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000Z"), sanitize(msg.To))
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return err
+	}
+	log.Printf("📧 Mail to %s written to %s", msg.To, path)
+	return nil
+}
+
+// sanitize keeps a recipient address safe to use in a file name.
+func sanitize(address string) string {
+	out := []rune(address)
+	for i, r := range out {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '@' || r == '.' || r == '-' || r == '_') {
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
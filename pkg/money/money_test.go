@@ -0,0 +1,211 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in       string
+		currency string
+		want     int64
+		err      error
+	}{
+		{"100.50", "USD", 10050, nil},
+		{"100.5", "USD", 10050, nil},
+		{"100", "USD", 10000, nil},
+		{"0.01", "USD", 1, nil},
+		{"007.10", "USD", 710, nil},
+		{"  2.00 ", "USD", 200, nil},
+		{"1.500", "USD", 150, nil}, // trailing zeros are not extra precision
+		{"-1.50", "USD", -150, nil},
+		{"-0", "USD", 0, nil},
+		{"1500", "JPY", 1500, nil},
+		{"1.234", "KWD", 1234, nil},
+		{"1.5", "xyz", 150, nil}, // unknown currencies have two decimal places
+
+		{"1.005", "USD", 0, ErrTooPrecise},
+		{"1.5", "JPY", 0, ErrTooPrecise},
+		{"0.0001", "KWD", 0, ErrTooPrecise},
+
+		{"", "USD", 0, ErrInvalidAmount},
+		{"-", "USD", 0, ErrInvalidAmount},
+		{"--1", "USD", 0, ErrInvalidAmount},
+		{"+1", "USD", 0, ErrInvalidAmount},
+		{".5", "USD", 0, ErrInvalidAmount},
+		{"1.", "USD", 0, ErrInvalidAmount},
+		{"1.2.3", "USD", 0, ErrInvalidAmount},
+		{"1e3", "USD", 0, ErrInvalidAmount},
+		{"1E-2", "USD", 0, ErrInvalidAmount},
+		{"0x10", "USD", 0, ErrInvalidAmount},
+		{"1,000.00", "USD", 0, ErrInvalidAmount},
+		{"12abc", "USD", 0, ErrInvalidAmount},
+		{"NaN", "USD", 0, ErrInvalidAmount},
+		{"Inf", "USD", 0, ErrInvalidAmount},
+		{"١٢", "USD", 0, ErrInvalidAmount}, // non-ASCII digits
+
+		{"92233720368547758.07", "USD", math.MaxInt64, nil},
+		{"-92233720368547758.07", "USD", -math.MaxInt64, nil},
+		{"92233720368547758.08", "USD", 0, ErrOverflow},
+		{"9223372036854775808", "JPY", 0, ErrOverflow},
+		{"100000000000000000000", "USD", 0, ErrOverflow},
+	}
+	for _, tc := range tests {
+		got, err := Parse(tc.in, tc.currency)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Parse(%q, %s): err = %v, want %v", tc.in, tc.currency, err, tc.err)
+			continue
+		}
+		if err == nil && (got.Amount != tc.want || got.Currency != tc.currency) {
+			t.Errorf("Parse(%q, %s) = %+v, want %d %s", tc.in, tc.currency, got, tc.want, tc.currency)
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{0, "USD", "0.00"},
+		{1, "USD", "0.01"},
+		{-1, "USD", "-0.01"},
+		{10050, "USD", "100.50"},
+		{-10050, "USD", "-100.50"},
+		{1500, "JPY", "1500"},
+		{-7, "JPY", "-7"},
+		{1234, "KWD", "1.234"},
+		{5, "KWD", "0.005"},
+		{math.MaxInt64, "USD", "92233720368547758.07"},
+		{math.MinInt64 + 1, "USD", "-92233720368547758.07"},
+		{math.MinInt64, "USD", "-92233720368547758.08"},
+	}
+	for _, tc := range tests {
+		m := New(tc.amount, tc.currency)
+		s := m.String()
+		if s != tc.want {
+			t.Errorf("New(%d, %s).String() = %q, want %q", tc.amount, tc.currency, s, tc.want)
+			continue
+		}
+		if tc.amount == math.MinInt64 {
+			continue // its magnitude does not fit in an int64, so Parse cannot read it back
+		}
+		parsed, err := Parse(s, tc.currency)
+		if err != nil || parsed != m {
+			t.Errorf("Parse(%q, %s) = %+v, %v; want %+v", s, tc.currency, parsed, err, m)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	usd := func(minor int64) Money { return New(minor, "USD") }
+
+	tests := []struct {
+		name string
+		op   func() (Money, error)
+		want Money
+		err  error
+	}{
+		{"add", func() (Money, error) { return usd(150).Add(usd(250)) }, usd(400), nil},
+		{"add negative", func() (Money, error) { return usd(150).Add(usd(-250)) }, usd(-100), nil},
+		{"add ignores currency case", func() (Money, error) { return usd(1).Add(New(1, "usd")) }, usd(2), nil},
+		{"add to max", func() (Money, error) { return usd(math.MaxInt64 - 1).Add(usd(1)) }, usd(math.MaxInt64), nil},
+		{"add overflow", func() (Money, error) { return usd(math.MaxInt64).Add(usd(1)) }, Money{}, ErrOverflow},
+		{"add underflow", func() (Money, error) { return usd(math.MinInt64).Add(usd(-1)) }, Money{}, ErrOverflow},
+		{"add currencies", func() (Money, error) { return usd(1).Add(New(1, "EUR")) }, Money{}, ErrCurrencyMismatch},
+		{"sub", func() (Money, error) { return usd(100).Sub(usd(250)) }, usd(-150), nil},
+		{"sub overflow", func() (Money, error) { return usd(0).Sub(usd(math.MinInt64)) }, Money{}, ErrOverflow},
+		{"sub underflow", func() (Money, error) { return usd(math.MinInt64).Sub(usd(1)) }, Money{}, ErrOverflow},
+		{"sub currencies", func() (Money, error) { return usd(1).Sub(New(1, "EUR")) }, Money{}, ErrCurrencyMismatch},
+	}
+	for _, tc := range tests {
+		got, err := tc.op()
+		if !errors.Is(err, tc.err) || (err == nil && got != tc.want) {
+			t.Errorf("%s = %+v, %v; want %+v, %v", tc.name, got, err, tc.want, tc.err)
+		}
+	}
+
+	if cmp, err := usd(1).Cmp(usd(2)); cmp != -1 || err != nil {
+		t.Errorf("Cmp(1, 2) = %d, %v; want -1", cmp, err)
+	}
+	if _, err := usd(1).Cmp(New(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Cmp across currencies: err = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Amount Money `json:"amount"`
+	}{New(-10050, "USD")})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(data) != `{"amount":"-100.50"}` {
+		t.Errorf("Marshal = %s, want the amount as a decimal string", data)
+	}
+
+	tests := []struct {
+		in       string
+		currency string
+		want     int64
+		err      error
+	}{
+		{`"100.50"`, "", 10050, nil},
+		{`"1500"`, "JPY", 1500, nil},
+		{`"1.5"`, "JPY", 0, ErrTooPrecise},
+		{`100.50`, "", 0, ErrInvalidAmount}, // numbers would go through floating point
+		{`null`, "", 0, ErrInvalidAmount},
+		{`"1e2"`, "", 0, ErrInvalidAmount},
+		{`{}`, "", 0, ErrInvalidAmount},
+	}
+	for _, tc := range tests {
+		m := Money{Currency: tc.currency}
+		err := json.Unmarshal([]byte(tc.in), &m)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Unmarshal(%s) into %s: err = %v, want %v", tc.in, tc.currency, err, tc.err)
+			continue
+		}
+		if err == nil && (m.Amount != tc.want || m.Currency != tc.currency) {
+			t.Errorf("Unmarshal(%s) into %s = %+v, want %d", tc.in, tc.currency, m, tc.want)
+		}
+	}
+}
+
+func TestValueAndScan(t *testing.T) {
+	value, err := New(-10050, "USD").Value()
+	if err != nil || value != int64(-10050) {
+		t.Errorf("Value = %v, %v; want int64 -10050", value, err)
+	}
+
+	tests := []struct {
+		in      interface{}
+		want    int64
+		wantErr bool
+	}{
+		{int64(10050), 10050, false},
+		{int64(math.MinInt64), math.MinInt64, false},
+		{[]byte("-42"), -42, false},
+		{"9223372036854775807", math.MaxInt64, false},
+		{nil, 0, false},
+		{"9223372036854775808", 0, true},
+		{"1.50", 0, true},
+		{[]byte("abc"), 0, true},
+		{1.5, 0, true},
+		{true, 0, true},
+	}
+	for _, tc := range tests {
+		m := Money{Amount: 99, Currency: "EUR"}
+		err := m.Scan(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("Scan(%#v): err = %v, want error %v", tc.in, err, tc.wantErr)
+			continue
+		}
+		if err == nil && (m.Amount != tc.want || m.Currency != "EUR") {
+			t.Errorf("Scan(%#v) = %+v, want %d with the currency untouched", tc.in, m, tc.want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// Package oidc is a minimal OpenID Connect relying party: provider discovery, the
+// authorization code flow with PKCE, and ID token verification against the
+// provider's published keys.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"wallet-service/pkg/jwtkeys"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keysRefreshCooldown limits how often an unknown kid may trigger a fetch of the provider's keys.
+const keysRefreshCooldown = time.Minute
+
+var (
+	// ErrInvalidIDToken is returned when an ID token's signature, issuer, audience,
+	// expiry or nonce is wrong.
+	ErrInvalidIDToken = errors.New("invalid ID token")
+	// ErrUnknownKey is returned when an ID token names a kid the provider does not publish.
+	ErrUnknownKey = errors.New("unknown provider signing key")
+)
+
+// Config identifies the provider and this application's registration with it.
+type Config struct {
+	// DiscoveryURL is the provider's OpenID configuration document, usually
+	// <issuer>/.well-known/openid-configuration.
+	DiscoveryURL string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is where the provider sends the user back with a code.
+	RedirectURL string
+	Scopes      []string
+}
+
+// Metadata is the part of a provider's discovery document used here.
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims are the identity claims read from a verified ID token.
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider talks to one OpenID Connect provider. Its discovery document is
+// fetched on first use and its signing keys whenever a token names an unknown
+// kid, so the application starts even while the provider is unreachable.
+// It is safe for concurrent use.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu        sync.Mutex
+	metadata  *Metadata
+	keys      map[string]jwtkeys.JWK
+	keysFetch time.Time
+}
+
+// NewProvider creates a Provider for cfg.
+func NewProvider(cfg Config) *Provider {
+	return &Provider{cfg: cfg, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// AuthCodeURL returns the provider URL to send the user to. The nonce is echoed
+// in the ID token, and codeVerifier is kept to redeem the code with Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	authURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+// Exchange redeems an authorization code and returns the claims of the verified
+// ID token, which must carry nonce.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Claims, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := p.do(req, &token); err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+	return p.VerifyIDToken(ctx, token.IDToken, nonce)
+}
+
+// VerifyIDToken checks an ID token's signature, issuer, audience, expiry and
+// nonce, and returns its identity claims.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		jwt.RegisteredClaims
+		Nonce         string      `json:"nonce"`
+		Email         string      `json:"email"`
+		EmailVerified interface{} `json:"email_verified"`
+		Name          string      `json:"name"`
+	}
+	keyfunc := func(token *jwt.Token) (interface{}, error) {
+		return p.key(ctx, metadata, token)
+	}
+	_, err = jwt.ParseWithClaims(rawIDToken, &claims, keyfunc,
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if claims.Nonce != nonce || claims.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	return &Claims{
+		Issuer:  metadata.Issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+		// Some providers send email_verified as the string "true".
+		EmailVerified: claims.EmailVerified == true || claims.EmailVerified == "true",
+		Name:          claims.Name,
+	}, nil
+}
+
+// Metadata returns the provider's discovery document, fetching it on first use.
+// Concurrent first calls may each fetch it; the first to finish is kept.
+func (p *Provider) Metadata(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	cached := p.metadata
+	p.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.DiscoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	var metadata Metadata
+	if err := p.do(req, &metadata); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	if metadata.Issuer == "" || metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("discovery: incomplete provider metadata")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata == nil {
+		p.metadata = &metadata
+	}
+	return p.metadata, nil
+}
+
+// key resolves an ID token's verification key from its kid header, fetching
+// the provider's keys when the kid is unknown, at most once per keysRefreshCooldown.
+func (p *Provider) key(ctx context.Context, metadata *Metadata, token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.Lock()
+	jwk, ok := p.keys[kid]
+	fetch := !ok && time.Since(p.keysFetch) >= keysRefreshCooldown
+	if fetch {
+		p.keysFetch = time.Now()
+	}
+	p.mu.Unlock()
+
+	if fetch {
+		keys, err := p.fetchKeys(ctx, metadata.JWKSURI)
+		if err != nil {
+			return nil, err
+		}
+		p.mu.Lock()
+		p.keys = keys
+		p.mu.Unlock()
+		jwk, ok = keys[kid]
+	}
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	public, method, err := jwk.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return public, nil
+}
+
+// fetchKeys fetches the provider's JWKS and returns its signing keys by kid.
+func (p *Provider) fetchKeys(ctx context.Context, jwksURI string) (map[string]jwtkeys.JWK, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set jwtkeys.JWKS
+	if err := p.do(req, &set); err != nil {
+		return nil, fmt.Errorf("fetching provider keys: %w", err)
+	}
+
+	keys := make(map[string]jwtkeys.JWK, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use == "" || key.Use == "sig" {
+			keys[key.KeyID] = key
+		}
+	}
+	return keys, nil
+}
+
+// do sends req and decodes a successful JSON response into out.
+func (p *Provider) do(req *http.Request, out interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// CodeChallenge returns the S256 PKCE code challenge for a code verifier.
+func CodeChallenge(codeVerifier string) string {
+	digest := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
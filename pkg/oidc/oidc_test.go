@@ -0,0 +1,92 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"wallet-service/pkg/oidc"
+	"wallet-service/pkg/oidc/oidctest"
+)
+
+const redirectURL = "http://localhost:8080/login/callback"
+
+// newProvider starts a mock provider and returns a relying party for it.
+func newProvider(t *testing.T) (*oidctest.Server, *oidc.Provider) {
+	t.Helper()
+	server, err := oidctest.NewServer("wallet-service", "secret")
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	t.Cleanup(server.Close)
+	return server, oidc.NewProvider(server.ClientConfig(redirectURL))
+}
+
+// authorize sends the user to the provider and returns the code it redirects back with.
+func authorize(t *testing.T, server *oidctest.Server, provider *oidc.Provider, state, nonce, verifier string) string {
+	t.Helper()
+	authURL, err := provider.AuthCodeURL(context.Background(), state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, returnedState, err := server.Approve(authURL)
+	if err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if returnedState != state {
+		t.Fatalf("provider returned state %q, want %q", returnedState, state)
+	}
+	return code
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	server, provider := newProvider(t)
+	ctx := context.Background()
+
+	code := authorize(t, server, provider, "state-1", "nonce-1", "verifier-1")
+	claims, err := provider.Exchange(ctx, code, "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if claims.Issuer != server.Issuer || claims.Subject != "mock-user" || claims.Email != "mock.user@example.com" || !claims.EmailVerified {
+		t.Fatalf("claims = %+v, want the mock provider's default user", claims)
+	}
+
+	if _, err := provider.Exchange(ctx, code, "verifier-1", "nonce-1"); err == nil {
+		t.Fatal("redeeming a code twice succeeded")
+	}
+}
+
+func TestProvider_ExchangeRejectsMismatches(t *testing.T) {
+	server, provider := newProvider(t)
+	ctx := context.Background()
+
+	// A code redeemed with another login's verifier fails PKCE.
+	code := authorize(t, server, provider, "state-1", "nonce-1", "verifier-1")
+	if _, err := provider.Exchange(ctx, code, "verifier-2", "nonce-1"); err == nil {
+		t.Fatal("Exchange with the wrong code verifier succeeded")
+	}
+
+	// An ID token issued for another login's nonce is rejected.
+	code = authorize(t, server, provider, "state-2", "nonce-2", "verifier-2")
+	if _, err := provider.Exchange(ctx, code, "verifier-2", "nonce-1"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("Exchange with the wrong nonce: err = %v, want ErrInvalidIDToken", err)
+	}
+}
+
+func TestProvider_HonoursContext(t *testing.T) {
+	_, provider := newProvider(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := provider.Metadata(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Metadata with a cancelled context: err = %v, want context.Canceled", err)
+	}
+
+	// A failed fetch is not cached.
+	if _, err := provider.Metadata(context.Background()); err != nil {
+		t.Fatalf("Metadata: %v", err)
+	}
+	if _, err := provider.Exchange(ctx, "code", "verifier", "nonce"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Exchange with a cancelled context: err = %v, want context.Canceled", err)
+	}
+}
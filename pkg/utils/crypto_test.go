@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+
+	sealed, err := Seal(key, "JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if _, err := Open(key, "JBSWY3DPEHPK3PXP"); !errors.Is(err, ErrInvalidSealedValue) {
+		t.Fatalf("Open of a plaintext secret: err = %v, want ErrInvalidSealedValue", err)
+	}
+
+	opened, err := Open(key, sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if opened != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("Open = %q, want the sealed plaintext", opened)
+	}
+
+	again, err := Seal(key, "JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if again == sealed {
+		t.Fatal("sealing the same plaintext twice gave the same value")
+	}
+}
+
+func TestOpenRejectsWrongKeyAndTampering(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	sealed, err := Seal(key, "secret")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	if _, err := Open(bytes.Repeat([]byte{8}, 32), sealed); !errors.Is(err, ErrInvalidSealedValue) {
+		t.Fatalf("Open with another key: err = %v, want ErrInvalidSealedValue", err)
+	}
+	tampered := sealed[:len(sealed)-2] + "AA"
+	if tampered == sealed {
+		tampered = sealed[:len(sealed)-2] + "BB"
+	}
+	if _, err := Open(key, tampered); !errors.Is(err, ErrInvalidSealedValue) {
+		t.Fatalf("Open of a tampered value: err = %v, want ErrInvalidSealedValue", err)
+	}
+	if _, err := Open(key, "secret"); !errors.Is(err, ErrInvalidSealedValue) {
+		t.Fatalf("Open of a plaintext value: err = %v, want ErrInvalidSealedValue", err)
+	}
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// GenerateReference creates a unique, human-readable transaction reference with the given prefix.
+func GenerateReference(prefix string) (string, error) {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return prefix + strings.ToUpper(hex.EncodeToString(bytes)), nil
+}